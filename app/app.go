@@ -0,0 +1,189 @@
+package app
+
+import (
+	"context"
+
+	"review_product_tokopedia_be/cache"
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/controller"
+	"review_product_tokopedia_be/database"
+	"review_product_tokopedia_be/middleware"
+	"review_product_tokopedia_be/repository"
+	"review_product_tokopedia_be/routes"
+	"review_product_tokopedia_be/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type (
+	// Options carries everything NewApp does not build itself.
+	Options struct {
+		DB          *gorm.DB
+		Config      config.Config
+		LLMProvider service.LLMProvider
+		// Cache may be nil, which disables result caching.
+		Cache cache.Cache
+	}
+
+	// App is the wired HTTP API together with its background workers.
+	App struct {
+		Router *gin.Engine
+
+		// The services the management commands call directly.
+		Users     service.UserService
+		Histories service.HistoryService
+		Analysis  service.AnalysisService
+
+		healthService      service.HealthService
+		geminiService      service.GeminiService
+		analysisJobService service.AnalysisJobService
+		watchlistService   service.WatchlistService
+		alertService       service.AlertService
+		resultCache        cache.Cache
+	}
+)
+
+// NewApp wires repositories, services, controllers and routes on top of an
+// already migrated database. The workers are not running until Start.
+func NewApp(opts Options) *App {
+	var (
+		db          = opts.DB
+		cfg         = opts.Config
+		resultCache = opts.Cache
+		cacheTTL    = cfg.Cache.TTL
+
+		// REPOSITORY
+		userRepository           repository.UserRepository           = repository.NewUserRepository(db)
+		historyRepository        repository.HistoryRepository        = repository.NewHistoryRepository(db)
+		analysisJobRepository    repository.AnalysisJobRepository    = repository.NewAnalysisJobRepository(db)
+		reviewRepository         repository.ReviewRepository         = repository.NewReviewRepository(db)
+		trackedProductRepository repository.TrackedProductRepository = repository.NewTrackedProductRepository(db)
+		watchlistRepository      repository.WatchlistRepository      = repository.NewWatchlistRepository(db)
+		alertRepository          repository.AlertRepository          = repository.NewAlertRepository(db)
+		comparisonRepository     repository.ComparisonRepository     = repository.NewComparisonRepository(db)
+
+		// SERVICE
+		jwtService          service.JWTService          = service.NewJWTService(cfg.JWT)
+		userService         service.UserService         = service.NewUserService(userRepository, jwtService)
+		historyService      service.HistoryService      = service.NewHistoryService(db, historyRepository, reviewRepository, trackedProductRepository)
+		reviewService       service.ReviewService       = service.NewReviewService(reviewRepository)
+		tokopediaService    service.TokopediaService    = service.NewTokopediaService(cfg.Endpoints.TokopediaURL, nil)
+		modelService        service.ModelService        = service.NewCachedModelService(service.NewModelService(cfg.Endpoints.ModelURL, cfg.Endpoints.ModelAPIKey.Value(), cfg.Endpoints.ModelPerStatementLimit), resultCache, cacheTTL)
+		geminiService       service.GeminiService       = service.NewCachedGeminiService(service.NewGeminiService(opts.LLMProvider, cfg.LLM), resultCache, cacheTTL)
+		marketplaceRegistry service.MarketplaceRegistry = service.NewMarketplaceRegistry(
+			service.NewCachedMarketplaceProvider(service.NewTokopediaProvider(tokopediaService), resultCache, cacheTTL),
+			service.NewCachedMarketplaceProvider(service.NewShopeeProvider(cfg.Endpoints.ShopeeURL), resultCache, cacheTTL),
+		)
+		alertService        service.AlertService        = service.NewAlertService(alertRepository, historyRepository, service.NewAlertNotifiers(cfg.SMTP))
+		analysisService     service.AnalysisService     = service.NewAnalysisService(marketplaceRegistry, modelService, geminiService, historyService, reviewService, alertService)
+		shopAnalysisService service.ShopAnalysisService = service.NewShopAnalysisService(tokopediaService, analysisService, geminiService)
+		analysisJobService  service.AnalysisJobService  = service.NewAnalysisJobService(analysisJobRepository, analysisService, shopAnalysisService, cfg.AnalysisJob)
+		watchlistService    service.WatchlistService    = service.NewWatchlistService(watchlistRepository, analysisJobRepository, analysisJobService, marketplaceRegistry, cfg.Watchlist)
+		comparisonService   service.ComparisonService   = service.NewComparisonService(comparisonRepository, analysisService, geminiService)
+		healthService       service.HealthService       = service.NewHealthService(
+			[]service.HealthCheck{
+				{Name: "database", Check: pingDatabase(db)},
+				{Name: "migrations", Check: func(ctx context.Context) error { return database.CheckMigrations(db.WithContext(ctx)) }},
+			},
+			[]service.HealthCheck{
+				{Name: "database", Check: pingDatabase(db)},
+				{Name: "tokopedia", Check: tokopediaService.Ping},
+				{Name: "model", Check: modelService.Ping},
+				{Name: "llm", Check: opts.LLMProvider.Ping},
+			},
+		)
+
+		// CONTROLLER
+		userController      controller.UserController      = controller.NewUserController(userService)
+		historyController   controller.HistoryController   = controller.NewHistoryController(historyService, comparisonService)
+		mlController        controller.MLController        = controller.NewMLController(analysisService, analysisJobService, comparisonService, shopAnalysisService)
+		watchlistController controller.WatchlistController = controller.NewWatchlistController(watchlistService)
+		alertController     controller.AlertController     = controller.NewAlertController(alertService)
+		healthController    controller.HealthController    = controller.NewHealthController(healthService)
+	)
+
+	// SERVER
+	server := gin.Default()
+
+	// Use middleware
+	server.Use(middleware.Logger())
+	server.Use(middleware.Recovery())
+	server.Use(middleware.CORSMiddleware())
+
+	// ROUTES
+	routes.Health(server, healthController)
+	apiGroup := server.Group("/api")
+	routes.User(apiGroup, userController, jwtService)
+	routes.ML(apiGroup, mlController, jwtService)
+	routes.History(apiGroup, historyController, jwtService)
+	routes.Watchlist(apiGroup, watchlistController, jwtService)
+	routes.Alert(apiGroup, alertController, jwtService)
+	routes.Admin(apiGroup, healthController, jwtService, userService)
+
+	return &App{
+		Router:             server,
+		Users:              userService,
+		Histories:          historyService,
+		Analysis:           analysisService,
+		healthService:      healthService,
+		geminiService:      geminiService,
+		analysisJobService: analysisJobService,
+		watchlistService:   watchlistService,
+		alertService:       alertService,
+		resultCache:        resultCache,
+	}
+}
+
+func pingDatabase(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// Start runs the analysis job, watchlist and alert delivery workers and
+// marks the app ready.
+func (a *App) Start() {
+	a.analysisJobService.Start()
+	a.watchlistService.Start()
+	a.alertService.Start()
+
+	a.healthService.SetReady(true)
+}
+
+// Drain marks the app not ready, so /readyz fails while the server keeps
+// answering the requests already routed to it.
+func (a *App) Drain() {
+	a.healthService.SetReady(false)
+}
+
+// Shutdown stops the workers, letting running analysis jobs finish until
+// ctx is done, and then releases the LLM client and the cache. The database
+// belongs to the caller and stays open.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.Drain()
+
+	// No new jobs are enqueued or delivered once the scheduler and the
+	// alert loop are stopped.
+	a.watchlistService.Stop()
+	err := a.analysisJobService.Shutdown(ctx)
+	a.alertService.Stop()
+
+	a.geminiService.CloseClient()
+	if a.resultCache != nil {
+		a.resultCache.Close()
+	}
+
+	return err
+}
+
+// Stop shuts down without waiting for running jobs.
+func (a *App) Stop() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	a.Shutdown(ctx)
+}
@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+// Cache stores opaque values by key. Get reports a miss with ok set to
+// false rather than an error.
+type Cache interface {
+	Get(ctx context.Context, key string) (value []byte, ok bool, err error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Close() error
+}
+
+// New builds the backend selected by cfg.Backend. It returns nil when
+// caching is disabled.
+func New(cfg config.CacheConfig) (Cache, error) {
+	switch cfg.Backend {
+	case constants.ENUM_CACHE_MEMORY:
+		return NewLRUCache(cfg.Size), nil
+	case constants.ENUM_CACHE_REDIS:
+		return NewRedisCache(cfg.Redis.Addr, cfg.Redis.Password.Value(), cfg.Redis.DB), nil
+	case constants.ENUM_CACHE_NONE:
+		return nil, nil
+	default:
+		return nil, dto.ErrUnsupportedCache
+	}
+}
@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(2)
+
+	c.Set(ctx, "a", []byte("1"), 0)
+	c.Set(ctx, "b", []byte("2"), 0)
+	// Reading a makes b the least recently used entry.
+	if _, ok, _ := c.Get(ctx, "a"); !ok {
+		t.Fatal("a missing before eviction")
+	}
+	c.Set(ctx, "c", []byte("3"), 0)
+
+	if _, ok, _ := c.Get(ctx, "b"); ok {
+		t.Error("b was not evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok, _ := c.Get(ctx, key); !ok {
+			t.Errorf("%s was evicted", key)
+		}
+	}
+
+	// Overwriting an entry refreshes it without growing the cache.
+	c.Set(ctx, "a", []byte("4"), 0)
+	c.Set(ctx, "d", []byte("5"), 0)
+	if value, ok, _ := c.Get(ctx, "a"); !ok || string(value) != "4" {
+		t.Errorf("a = %q, %v, want 4", value, ok)
+	}
+	if _, ok, _ := c.Get(ctx, "c"); ok {
+		t.Error("c was not evicted")
+	}
+}
+
+func TestLRUCacheTTL(t *testing.T) {
+	ctx := context.Background()
+	c := NewLRUCache(10)
+
+	c.Set(ctx, "short", []byte("1"), 20*time.Millisecond)
+	c.Set(ctx, "forever", []byte("2"), 0)
+	c.Set(ctx, "renewed", []byte("3"), 20*time.Millisecond)
+	c.Set(ctx, "renewed", []byte("3"), time.Hour)
+
+	if _, ok, _ := c.Get(ctx, "short"); !ok {
+		t.Fatal("short expired early")
+	}
+	time.Sleep(40 * time.Millisecond)
+
+	if _, ok, _ := c.Get(ctx, "short"); ok {
+		t.Error("short did not expire")
+	}
+	for _, key := range []string{"forever", "renewed"} {
+		if _, ok, _ := c.Get(ctx, key); !ok {
+			t.Errorf("%s expired", key)
+		}
+	}
+
+	// An expired entry is dropped on read, so it no longer takes a slot.
+	if n := c.(*lruCache).order.Len(); n != 2 {
+		t.Errorf("entries = %d, want 2", n)
+	}
+}
@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis is a RESP server with just enough of GET, SET, AUTH and SELECT
+// for the cache. GET on "wrongtype" replies with an error and GET on
+// "truncated" hangs up in the middle of the reply.
+type fakeRedis struct {
+	listener net.Listener
+	password string
+
+	mu       sync.Mutex
+	data     map[string]string
+	conns    int
+	commands [][]string
+}
+
+func newFakeRedis(t *testing.T, password string) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeRedis{
+		listener: listener,
+		password: password,
+		data:     make(map[string]string),
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.conns++
+			s.mu.Unlock()
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeRedis) addr() string {
+	return s.listener.Addr().String()
+}
+
+func (s *fakeRedis) connCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+// sent returns the commands received so far with the given name.
+func (s *fakeRedis) sent(name string) [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var cmds [][]string
+	for _, cmd := range s.commands {
+		if cmd[0] == name {
+			cmds = append(cmds, cmd)
+		}
+	}
+	return cmds
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	authed := s.password == ""
+	db := "0"
+	for {
+		cmd, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, cmd)
+		s.mu.Unlock()
+
+		var reply string
+		switch {
+		case cmd[0] == "AUTH":
+			if len(cmd) == 2 && cmd[1] == s.password {
+				authed = true
+				reply = "+OK\r\n"
+			} else {
+				reply = "-WRONGPASS invalid username-password pair\r\n"
+			}
+		case !authed:
+			reply = "-NOAUTH Authentication required.\r\n"
+		case cmd[0] == "SELECT":
+			db = cmd[1]
+			reply = "+OK\r\n"
+		case cmd[0] == "SET":
+			s.mu.Lock()
+			s.data[db+"/"+cmd[1]] = cmd[2]
+			s.mu.Unlock()
+			reply = "+OK\r\n"
+		case cmd[0] == "GET" && cmd[1] == "wrongtype":
+			reply = "-WRONGTYPE Operation against a key holding the wrong kind of value\r\n"
+		case cmd[0] == "GET" && cmd[1] == "truncated":
+			io.WriteString(conn, "$10\r\nabc")
+			return
+		case cmd[0] == "GET":
+			s.mu.Lock()
+			value, ok := s.data[db+"/"+cmd[1]]
+			s.mu.Unlock()
+			if ok {
+				reply = fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+			} else {
+				reply = "$-1\r\n"
+			}
+		default:
+			reply = fmt.Sprintf("-ERR unknown command '%s'\r\n", cmd[0])
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "*"), "\r\n"))
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("malformed command %q", line)
+	}
+
+	cmd := make([]string, n)
+	for i := range cmd {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(line, "$"), "\r\n"))
+		if err != nil {
+			return nil, fmt.Errorf("malformed bulk length %q", line)
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		cmd[i] = string(buf[:size])
+	}
+	return cmd, nil
+}
+
+func TestRedisCacheGetSet(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeRedis(t, "")
+	c := NewRedisCache(server.addr(), "", 0)
+	defer c.Close()
+
+	if value, ok, err := c.Get(ctx, "missing"); err != nil || ok || value != nil {
+		t.Fatalf("Get missing = %q, %v, %v", value, ok, err)
+	}
+
+	// Values are binary safe, including CRLF inside the payload.
+	want := "{\"summary\":\"a\r\nb\"}\x00"
+	if err := c.Set(ctx, "key", []byte(want), 1500*time.Millisecond); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	value, ok, err := c.Get(ctx, "key")
+	if err != nil || !ok || string(value) != want {
+		t.Fatalf("Get = %q, %v, %v, want %q", value, ok, err, want)
+	}
+
+	if err := c.Set(ctx, "forever", []byte("1"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	sets := server.sent("SET")
+	if len(sets) != 2 {
+		t.Fatalf("SET commands = %v", sets)
+	}
+	if got := strings.Join(sets[0][3:], " "); got != "PX 1500" {
+		t.Errorf("SET with ttl sent %q, want PX 1500", got)
+	}
+	if len(sets[1]) != 3 {
+		t.Errorf("SET without ttl sent %v", sets[1])
+	}
+
+	if n := server.connCount(); n != 1 {
+		t.Errorf("connections = %d, want the pooled one reused", n)
+	}
+}
+
+func TestRedisCacheAuthAndSelect(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeRedis(t, "secret")
+
+	c := NewRedisCache(server.addr(), "secret", 3)
+	defer c.Close()
+	if err := c.Set(ctx, "key", []byte("1"), 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if _, ok, err := c.Get(ctx, "key"); err != nil || !ok {
+		t.Fatalf("Get = %v, %v", ok, err)
+	}
+
+	if auths := server.sent("AUTH"); len(auths) != 1 {
+		t.Errorf("AUTH commands = %v, want one per connection", auths)
+	}
+	if selects := server.sent("SELECT"); len(selects) != 1 || selects[0][1] != "3" {
+		t.Errorf("SELECT commands = %v", selects)
+	}
+	server.mu.Lock()
+	_, stored := server.data["3/key"]
+	server.mu.Unlock()
+	if !stored {
+		t.Error("key was not stored in the selected database")
+	}
+
+	wrong := NewRedisCache(server.addr(), "wrong", 0)
+	defer wrong.Close()
+	if _, _, err := wrong.Get(ctx, "key"); err == nil || !strings.Contains(err.Error(), "WRONGPASS") {
+		t.Errorf("Get with a wrong password: err = %v", err)
+	}
+}
+
+func TestRedisCacheFailedConnectionsAreNotReused(t *testing.T) {
+	ctx := context.Background()
+	server := newFakeRedis(t, "")
+	c := NewRedisCache(server.addr(), "", 0)
+	defer c.Close()
+
+	if _, _, err := c.Get(ctx, "wrongtype"); err == nil || !strings.Contains(err.Error(), "WRONGTYPE") {
+		t.Fatalf("Get wrongtype: err = %v", err)
+	}
+	if _, _, err := c.Get(ctx, "truncated"); err == nil {
+		t.Fatal("Get truncated: want error")
+	}
+	if _, _, err := c.Get(ctx, "missing"); err != nil {
+		t.Fatalf("Get after failures: %v", err)
+	}
+
+	if n := server.connCount(); n != 3 {
+		t.Errorf("connections = %d, want a new one after each failure", n)
+	}
+}
+
+func TestRedisCacheUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	c := NewRedisCache(addr, "", 0)
+	defer c.Close()
+	if _, _, err := c.Get(context.Background(), "key"); err == nil {
+		t.Error("Get: want error")
+	}
+	if err := c.Set(context.Background(), "key", []byte("1"), 0); err == nil {
+		t.Error("Set: want error")
+	}
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/service"
+)
+
+// runAnalyze runs the analysis pipeline the HTTP handlers use and prints
+// the dto.MLResult. With -user the run is stored in that user's history,
+// otherwise it runs as a guest.
+func runAnalyze(args []string) error {
+	flags := newFlagSet("analyze")
+	userRef := flags.String("user", "", "email or ID of the user to store the history for")
+	refresh := flags.Bool("refresh", false, "ignore cached results and recompute every step")
+	flags.String("max-reviews", "", "maximum number of reviews to analyse")
+	flags.String("sort", "", "review order: newest, oldest, highest_rating, lowest_rating or most_helpful")
+	flags.String("rating", "", "only include these star ratings, comma separated")
+	flags.String("since", "", "only include reviews created at or after this date")
+	flags.String("until", "", "only include reviews created before this date")
+	flags.String("with-media", "", "only include reviews with photos or videos")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return fmt.Errorf("%w: expected one product URL", ErrUsage)
+	}
+	productUrl := flags.Arg(0)
+
+	reviewOpts, err := service.ParseReviewOptions(func(name string) string {
+		return flags.Lookup(strings.ReplaceAll(name, "_", "-")).Value.String()
+	})
+	if err != nil {
+		return err
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer config.CloseDatabaseConnection(db)
+	logToStderr(db)
+
+	application, err := newApp(db, cfg)
+	if err != nil {
+		return err
+	}
+	defer application.Stop()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	opts := dto.AnalysisOptions{
+		Reviews: reviewOpts,
+		Refresh: *refresh,
+	}
+	if *userRef != "" {
+		user, err := resolveUser(ctx, application.Users, *userRef)
+		if err != nil {
+			return fmt.Errorf("user %s: %w", *userRef, err)
+		}
+		opts.UserID = user.ID
+	}
+
+	result, err := application.Analysis.Analyze(ctx, productUrl, opts)
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(os.Stdout, result.Result)
+}
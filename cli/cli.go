@@ -0,0 +1,144 @@
+// Package cli implements the subcommands of the server binary: serving the
+// API and the management tasks operators run from a terminal.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"review_product_tokopedia_be/app"
+	"review_product_tokopedia_be/cache"
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+var ErrUsage = errors.New("invalid usage")
+
+var commands = []command{
+	{"serve", "[-migrate=false]", "run the HTTP API and background workers (default)", runServe},
+	{"migrate", "up|down [steps]|status|fresh", "manage the database schema", runMigrate},
+	{"seed", "", "insert the development users, APP_ENV=development only", runSeed},
+	{"user", "create [-admin] -name NAME -email EMAIL [-password PASSWORD]", "create a user", runUser},
+	{"analyze", "[-user EMAIL|ID] [review options] URL", "analyse a product and print the result as JSON", runAnalyze},
+	{"history", "export -user EMAIL|ID [-output FILE]", "export a user's analysis history as JSON", runHistory},
+}
+
+// Run executes the subcommand named by args[0], or serve when args is empty.
+func Run(args []string) error {
+	if len(args) == 0 {
+		return runServe(nil)
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		printUsage(os.Stdout)
+		return nil
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	printUsage(os.Stderr)
+	return fmt.Errorf("%w: unknown command %q", ErrUsage, args[0])
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [arguments]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+		if cmd.args != "" {
+			fmt.Fprintf(w, "           %s %s\n", cmd.name, cmd.args)
+		}
+	}
+}
+
+// newFlagSet returns a flag set that reports errors instead of exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	return flags
+}
+
+// connect loads and validates the configuration and opens the database.
+// The caller closes the connection with config.CloseDatabaseConnection.
+func connect() (config.Config, *gorm.DB, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return config.Config{}, nil, err
+	}
+
+	return cfg, config.SetupDatabaseConnection(cfg.Database), nil
+}
+
+// newApp wires the application the same way serve does, without starting
+// its workers. Stop on the returned app releases the LLM client and cache.
+func newApp(db *gorm.DB, cfg config.Config) (*app.App, error) {
+	llmProvider, err := service.NewLLMProvider(cfg.LLM)
+	if err != nil {
+		return nil, err
+	}
+
+	resultCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		return nil, err
+	}
+
+	return app.NewApp(app.Options{
+		DB:          db,
+		Config:      cfg,
+		LLMProvider: llmProvider,
+		Cache:       resultCache,
+	}), nil
+}
+
+// logToStderr keeps gorm and gin logging off stdout, so commands that print
+// JSON can be piped into other tools.
+func logToStderr(db *gorm.DB) {
+	gin.SetMode(gin.ReleaseMode)
+	gin.DefaultWriter = os.Stderr
+
+	db.Logger = logger.New(log.New(os.Stderr, "\r\n", log.LstdFlags), logger.Config{
+		SlowThreshold: 200 * time.Millisecond,
+		LogLevel:      logger.Warn,
+		Colorful:      false,
+	})
+}
+
+func writeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(v)
+}
+
+// resolveUser looks up the user a -user argument refers to, by email when
+// it has an @ and by ID otherwise.
+func resolveUser(ctx context.Context, users service.UserService, ref string) (dto.UserResponse, error) {
+	if strings.Contains(ref, "@") {
+		return users.GetUserByEmail(ctx, ref)
+	}
+	return users.GetUserById(ctx, ref)
+}
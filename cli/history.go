@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+)
+
+const historyExportPageSize = 100
+
+// runHistory handles `history export`, writing every history of a user as
+// a JSON array, newest first, to stdout or -output.
+func runHistory(args []string) error {
+	if len(args) == 0 || args[0] != "export" {
+		return fmt.Errorf("%w: expected history export", ErrUsage)
+	}
+
+	flags := newFlagSet("history export")
+	userRef := flags.String("user", "", "email or ID of the user")
+	output := flags.String("output", "", "file to write, stdout when empty")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *userRef == "" {
+		return fmt.Errorf("%w: -user is required", ErrUsage)
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer config.CloseDatabaseConnection(db)
+	logToStderr(db)
+
+	application, err := newApp(db, cfg)
+	if err != nil {
+		return err
+	}
+	defer application.Stop()
+
+	ctx := context.Background()
+
+	user, err := resolveUser(ctx, application.Users, *userRef)
+	if err != nil {
+		return fmt.Errorf("user %s: %w", *userRef, err)
+	}
+
+	histories := []entity.History{}
+	for page := 1; ; page++ {
+		res, err := application.Histories.GetHistories(ctx, dto.HistoriesGetRequest{
+			Page:  page,
+			Limit: historyExportPageSize,
+		}, user.ID)
+		if err != nil {
+			return err
+		}
+
+		histories = append(histories, res.Histories...)
+		if page >= res.Pages {
+			break
+		}
+	}
+
+	if *output == "" {
+		return writeJSON(os.Stdout, histories)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(file, histories); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/database"
+)
+
+// runMigrate handles `migrate up|down [steps]|status|fresh`. fresh drops
+// every table and is refused outside the development environment.
+func runMigrate(args []string) error {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer config.CloseDatabaseConnection(db)
+
+	switch command {
+	case "up":
+		return database.MigrateUp(db)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("%w: invalid number of steps %q", ErrUsage, args[1])
+			}
+			steps = n
+		}
+		return database.MigrateDown(db, steps)
+
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			appliedAt := "pending"
+			if state.AppliedAt != nil {
+				appliedAt = state.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%06d_%-40s %s\n", state.Version, state.Name, appliedAt)
+		}
+		return nil
+
+	case "fresh":
+		if !cfg.IsDevelopment() {
+			return fmt.Errorf("migrate fresh drops all data and only runs with APP_ENV=%s", constants.ENUM_RUN_DEV)
+		}
+		return database.MigrateFresh(db)
+
+	default:
+		return fmt.Errorf("%w: unknown migrate command %q, expected up, down, status or fresh", ErrUsage, command)
+	}
+}
+
+// runSeed inserts the development users. They have well-known passwords,
+// so seeding is refused outside the development environment.
+func runSeed(args []string) error {
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer config.CloseDatabaseConnection(db)
+
+	if !cfg.IsDevelopment() {
+		return fmt.Errorf("seed creates users with known passwords and only runs with APP_ENV=%s", constants.ENUM_RUN_DEV)
+	}
+
+	return database.Seeder(db)
+}
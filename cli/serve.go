@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/database"
+	_ "review_product_tokopedia_be/docs"
+
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+// runServe starts the API. Pending migrations are applied first unless
+// -migrate=false, for deployments that run `migrate up` as its own step.
+//
+// On SIGINT or SIGTERM /readyz starts failing, and after SHUTDOWN_DELAY the
+// listener closes. In-flight requests then get until DRAIN_TIMEOUT to
+// finish, and running analysis jobs WORKER_DRAIN_TIMEOUT after that, before
+// they are cancelled.
+func runServe(args []string) error {
+	flags := newFlagSet("serve")
+	migrate := flags.Bool("migrate", true, "apply pending migrations before starting")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	fmt.Println("STARTING...")
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer config.CloseDatabaseConnection(db)
+	fmt.Print("CONFIGURATION:\n", cfg)
+
+	if *migrate {
+		fmt.Println("MIGRATING DATABASE...")
+		if err := database.MigrateUp(db); err != nil {
+			return err
+		}
+		fmt.Println("> Database Migrated")
+	}
+
+	if cfg.IsDevelopment() {
+		fmt.Println("RUNNING ON DEV ENV")
+		fmt.Println("SEEDING DATABASE...")
+		if err := database.Seeder(db); err != nil {
+			return err
+		}
+		fmt.Println("> Database Seeded")
+	}
+
+	application, err := newApp(db, cfg)
+	if err != nil {
+		return err
+	}
+
+	server := application.Router
+
+	// RUNING THE SERVER
+	host := cfg.Server.PublicHost
+	if host == "" {
+		host = "localhost:" + cfg.Server.Port
+	}
+
+	url := ginSwagger.URL(fmt.Sprintf("http://%s/swagger/doc.json", host))
+	server.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, url))
+
+	// Requests derive their context from requestCtx, so cancelling it
+	// reaches every analysis still running when the drain timeout expires.
+	requestCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	httpServer := &http.Server{
+		Addr:        "0.0.0.0:" + cfg.Server.Port,
+		Handler:     server,
+		BaseContext: func(net.Listener) context.Context { return requestCtx },
+	}
+
+	// WORKERS
+	application.Start()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+
+	select {
+	case err := <-serveErr:
+		application.Stop()
+		return fmt.Errorf("server failed to start: %w", err)
+	case <-signalCtx.Done():
+	}
+	// A second signal kills the process right away
+	stopSignals()
+
+	fmt.Println("SHUTTING DOWN...")
+	application.Drain()
+	time.Sleep(cfg.Server.ShutdownDelay)
+
+	drainCtx, cancelDrain := context.WithTimeout(context.Background(), cfg.Server.DrainTimeout)
+	defer cancelDrain()
+
+	if err := httpServer.Shutdown(drainCtx); err != nil {
+		fmt.Println("> Drain timeout reached, cancelling in-flight requests")
+		cancelRequests()
+		httpServer.Close()
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		fmt.Println("Server stopped with error: ", err)
+	}
+	fmt.Println("> Server Stopped")
+
+	// The requests may have used up drainCtx, so the jobs get a budget of
+	// their own.
+	workerCtx, cancelWorkers := context.WithTimeout(context.Background(), cfg.Server.WorkerDrainTimeout)
+	defer cancelWorkers()
+
+	if err := application.Shutdown(workerCtx); err != nil {
+		fmt.Println("> Drain timeout reached, cancelled running analysis jobs")
+	}
+	fmt.Println("> Workers Stopped")
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/dto"
+)
+
+// runUser handles `user create`. Without -password the password is read
+// from the first line of stdin, so it stays out of the shell history.
+func runUser(args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return fmt.Errorf("%w: expected user create", ErrUsage)
+	}
+
+	flags := newFlagSet("user create")
+	admin := flags.Bool("admin", false, "give the user the admin role")
+	name := flags.String("name", "", "display name")
+	email := flags.String("email", "", "login email")
+	password := flags.String("password", "", "login password, read from stdin when empty")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *name == "" || *email == "" {
+		return fmt.Errorf("%w: -name and -email are required", ErrUsage)
+	}
+
+	if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("read password from stdin: %w", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+		if *password == "" {
+			return fmt.Errorf("%w: empty password", ErrUsage)
+		}
+	}
+
+	cfg, db, err := connect()
+	if err != nil {
+		return err
+	}
+	defer config.CloseDatabaseConnection(db)
+	logToStderr(db)
+
+	application, err := newApp(db, cfg)
+	if err != nil {
+		return err
+	}
+	defer application.Stop()
+
+	req := dto.UserCreateRequest{Name: *name, Email: *email, Password: *password}
+
+	var user dto.UserResponse
+	if *admin {
+		user, err = application.Users.RegisterAdmin(context.Background(), req)
+	} else {
+		user, err = application.Users.RegisterUser(context.Background(), req)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeJSON(os.Stdout, user)
+}
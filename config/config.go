@@ -0,0 +1,391 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultConfigFile = "config.yaml"
+	defaultEnvFile    = ".env"
+	redacted          = "[REDACTED]"
+)
+
+type (
+	// Config is the whole application configuration. It is loaded once at
+	// startup by Load and handed to the constructors that need a part of it.
+	Config struct {
+		// Env defaults to production, so a deployment that forgets to set
+		// it never seeds users or allows migrate fresh.
+		Env         string            `yaml:"env"`
+		Server      ServerConfig      `yaml:"server"`
+		Database    DatabaseConfig    `yaml:"database"`
+		JWT         JWTConfig         `yaml:"jwt"`
+		Endpoints   EndpointsConfig   `yaml:"endpoints"`
+		LLM         LLMConfig         `yaml:"llm"`
+		Cache       CacheConfig       `yaml:"cache"`
+		AnalysisJob AnalysisJobConfig `yaml:"analysis_job"`
+		Watchlist   WatchlistConfig   `yaml:"watchlist"`
+		SMTP        SMTPConfig        `yaml:"smtp"`
+	}
+
+	ServerConfig struct {
+		Port string `yaml:"port"`
+		// PublicHost is the host[:port] the API is reached at, used for the
+		// Swagger document URL. Defaults to localhost:<port>.
+		PublicHost string `yaml:"public_host"`
+		// DrainTimeout bounds how long shutdown waits for in-flight requests
+		// before cancelling them.
+		DrainTimeout time.Duration `yaml:"drain_timeout"`
+		// WorkerDrainTimeout bounds how long shutdown then waits for running
+		// analysis jobs before cancelling them. A cancelled job is picked up
+		// again once its heartbeat goes stale.
+		WorkerDrainTimeout time.Duration `yaml:"worker_drain_timeout"`
+		// ShutdownDelay keeps serving after readiness turns off, giving load
+		// balancers time to stop routing before the listener closes.
+		ShutdownDelay time.Duration `yaml:"shutdown_delay"`
+	}
+
+	DatabaseConfig struct {
+		Host     string `yaml:"host"`
+		Port     string `yaml:"port"`
+		User     string `yaml:"user"`
+		Password Secret `yaml:"password"`
+		Name     string `yaml:"name"`
+		TimeZone string `yaml:"time_zone"`
+	}
+
+	JWTConfig struct {
+		Secret Secret `yaml:"secret"`
+		Issuer string `yaml:"issuer"`
+	}
+
+	// EndpointsConfig holds the external service URLs. An empty marketplace
+	// URL means the public default of that marketplace.
+	EndpointsConfig struct {
+		TokopediaURL string `yaml:"tokopedia_url"`
+		ShopeeURL    string `yaml:"shopee_url"`
+		ModelURL     string `yaml:"model_url"`
+		ModelAPIKey  Secret `yaml:"model_api_key"`
+		// ModelPerStatementLimit lets a prediction of up to this many
+		// statements fall back to one request per statement when the ML
+		// service only reports batch counts. Zero leaves them unlabelled.
+		ModelPerStatementLimit int `yaml:"model_per_statement_limit"`
+	}
+
+	LLMConfig struct {
+		Provider      string `yaml:"provider"`
+		Model         string `yaml:"model"`
+		MaxRetries    int    `yaml:"max_retries"`
+		GeminiAPIKey  Secret `yaml:"gemini_api_key"`
+		OpenAIBaseURL string `yaml:"openai_base_url"`
+		OpenAIAPIKey  Secret `yaml:"openai_api_key"`
+		// Operations overrides the model settings per operation, keyed by
+		// analyze, summarize or compare.
+		Operations map[string]LLMOperationConfig `yaml:"operations"`
+	}
+
+	LLMOperationConfig struct {
+		Model       string   `yaml:"model"`
+		Temperature *float32 `yaml:"temperature"`
+		MaxTokens   int      `yaml:"max_tokens"`
+	}
+
+	CacheConfig struct {
+		Backend string        `yaml:"backend"`
+		Size    int           `yaml:"size"`
+		TTL     time.Duration `yaml:"ttl"`
+		Redis   RedisConfig   `yaml:"redis"`
+	}
+
+	RedisConfig struct {
+		Addr     string `yaml:"addr"`
+		Password Secret `yaml:"password"`
+		DB       int    `yaml:"db"`
+	}
+
+	AnalysisJobConfig struct {
+		Workers int `yaml:"workers"`
+	}
+
+	WatchlistConfig struct {
+		MaxItems  int `yaml:"max_items"`
+		DailyRuns int `yaml:"daily_runs"`
+	}
+
+	// SMTPConfig enables email alerts when Host and From are set.
+	SMTPConfig struct {
+		Host     string `yaml:"host"`
+		Port     string `yaml:"port"`
+		Username string `yaml:"username"`
+		Password Secret `yaml:"password"`
+		From     string `yaml:"from"`
+	}
+
+	// Secret is a configuration value that must not end up in logs. It
+	// prints and marshals as [REDACTED]; Value returns the real value.
+	Secret string
+
+	// ValidationError lists every problem found in a configuration.
+	ValidationError struct {
+		Problems []string
+	}
+)
+
+var llmOperations = []string{"analyze", "summarize", "compare"}
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return strconv.Quote(s.String())
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Default returns the configuration used for every value that is not set.
+func Default() Config {
+	return Config{
+		Env: constants.ENUM_RUN_PRODUCTION,
+		Server: ServerConfig{
+			Port:               "8080",
+			DrainTimeout:       30 * time.Second,
+			WorkerDrainTimeout: time.Minute,
+		},
+		Database: DatabaseConfig{
+			Port:     "5432",
+			TimeZone: "Asia/Jakarta",
+		},
+		JWT: JWTConfig{
+			Issuer: "Template",
+		},
+		LLM: LLMConfig{
+			Provider:   constants.ENUM_LLM_PROVIDER_GEMINI,
+			MaxRetries: 2,
+		},
+		Cache: CacheConfig{
+			Backend: constants.ENUM_CACHE_MEMORY,
+			Size:    1000,
+			TTL:     time.Hour,
+			Redis: RedisConfig{
+				Addr: "localhost:6379",
+			},
+		},
+		AnalysisJob: AnalysisJobConfig{
+			Workers: 2,
+		},
+		Watchlist: WatchlistConfig{
+			MaxItems:  10,
+			DailyRuns: 24,
+		},
+		SMTP: SMTPConfig{
+			Port: "587",
+		},
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, the YAML file named by CONFIG_FILE (config.yaml when it
+// exists), a .env file and the environment, and validates the result.
+func Load() (Config, error) {
+	cfg := Default()
+
+	if err := godotenv.Load(defaultEnvFile); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("load %s: %w", defaultEnvFile, err)
+	}
+
+	path, required := os.LookupEnv("CONFIG_FILE")
+	if !required {
+		path = defaultConfigFile
+	}
+	if err := loadFile(&cfg, path); err != nil {
+		if required || !errors.Is(err, fs.ErrNotExist) {
+			return Config{}, err
+		}
+	}
+
+	var problems []string
+	applyEnv(&cfg, &problems)
+	problems = append(problems, cfg.problems()...)
+	if len(problems) > 0 {
+		return Config{}, &ValidationError{Problems: problems}
+	}
+
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every missing or invalid value at once.
+func (c Config) Validate() error {
+	if problems := c.problems(); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// IsDevelopment reports whether the development-only commands and the
+// seeder may run.
+func (c Config) IsDevelopment() bool {
+	return c.Env == constants.ENUM_RUN_DEV
+}
+
+// String renders the configuration as YAML with every secret redacted, so
+// it is safe to log.
+func (c Config) String() string {
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+func (c Config) problems() []string {
+	var problems []string
+	require := func(value string, name string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, name+" is required")
+		}
+	}
+	positive := func(value int, name string) {
+		if value <= 0 {
+			problems = append(problems, name+" must be greater than 0")
+		}
+	}
+	port := func(value string, name string) {
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 || n > 65535 {
+			problems = append(problems, fmt.Sprintf("%s must be a port number, got %q", name, value))
+		}
+	}
+
+	switch c.Env {
+	case constants.ENUM_RUN_DEV, constants.ENUM_RUN_PRODUCTION:
+	default:
+		problems = append(problems, fmt.Sprintf("APP_ENV must be %s or %s, got %q", constants.ENUM_RUN_DEV, constants.ENUM_RUN_PRODUCTION, c.Env))
+	}
+
+	port(c.Server.Port, "PORT")
+	if c.Server.DrainTimeout <= 0 {
+		problems = append(problems, "DRAIN_TIMEOUT must be greater than 0")
+	}
+	if c.Server.WorkerDrainTimeout <= 0 {
+		problems = append(problems, "WORKER_DRAIN_TIMEOUT must be greater than 0")
+	}
+	if c.Server.ShutdownDelay < 0 {
+		problems = append(problems, "SHUTDOWN_DELAY must not be negative")
+	}
+
+	require(c.Database.Host, "DB_HOST")
+	require(c.Database.User, "DB_USER")
+	require(c.Database.Name, "DB_NAME")
+	port(c.Database.Port, "DB_PORT")
+	if _, err := time.LoadLocation(c.Database.TimeZone); err != nil {
+		problems = append(problems, fmt.Sprintf("DB_TIMEZONE %q is not a known time zone", c.Database.TimeZone))
+	}
+
+	require(c.JWT.Secret.Value(), "JWT_SECRET")
+
+	require(c.Endpoints.ModelURL, "ML_URL")
+	if c.Endpoints.ModelPerStatementLimit < 0 {
+		problems = append(problems, "ML_PER_STATEMENT_LIMIT must not be negative")
+	}
+
+	switch c.LLM.Provider {
+	case constants.ENUM_LLM_PROVIDER_GEMINI:
+		require(c.LLM.GeminiAPIKey.Value(), "GEMINI_API_KEY")
+	case constants.ENUM_LLM_PROVIDER_OPENAI:
+		require(c.LLM.OpenAIBaseURL, "OPENAI_BASE_URL")
+		// OpenAI-compatible servers have no default model to fall back on
+		if c.LLM.Model == "" {
+			for _, operation := range llmOperations {
+				if c.LLM.Operations[operation].Model == "" {
+					problems = append(problems, fmt.Sprintf("LLM_MODEL or LLM_%s_MODEL is required", strings.ToUpper(operation)))
+				}
+			}
+		}
+	case constants.ENUM_LLM_PROVIDER_FAKE:
+	default:
+		problems = append(problems, fmt.Sprintf("LLM_PROVIDER must be %s, %s or %s, got %q",
+			constants.ENUM_LLM_PROVIDER_GEMINI, constants.ENUM_LLM_PROVIDER_OPENAI, constants.ENUM_LLM_PROVIDER_FAKE, c.LLM.Provider))
+	}
+	if c.LLM.MaxRetries < 0 {
+		problems = append(problems, "LLM_MAX_RETRIES must not be negative")
+	}
+	for operation, override := range c.LLM.Operations {
+		if !isLLMOperation(operation) {
+			problems = append(problems, fmt.Sprintf("llm.operations has unknown operation %q", operation))
+		}
+		if override.MaxTokens < 0 {
+			problems = append(problems, fmt.Sprintf("LLM_%s_MAX_TOKENS must not be negative", strings.ToUpper(operation)))
+		}
+	}
+
+	switch c.Cache.Backend {
+	case constants.ENUM_CACHE_MEMORY:
+		positive(c.Cache.Size, "CACHE_SIZE")
+	case constants.ENUM_CACHE_REDIS:
+		require(c.Cache.Redis.Addr, "REDIS_ADDR")
+	case constants.ENUM_CACHE_NONE:
+	default:
+		problems = append(problems, fmt.Sprintf("CACHE_BACKEND must be %s, %s or %s, got %q",
+			constants.ENUM_CACHE_MEMORY, constants.ENUM_CACHE_REDIS, constants.ENUM_CACHE_NONE, c.Cache.Backend))
+	}
+	if c.Cache.Backend != constants.ENUM_CACHE_NONE && c.Cache.TTL <= 0 {
+		problems = append(problems, "CACHE_TTL must be greater than 0")
+	}
+
+	positive(c.AnalysisJob.Workers, "ANALYSIS_WORKERS")
+	positive(c.Watchlist.MaxItems, "WATCHLIST_MAX_ITEMS")
+	positive(c.Watchlist.DailyRuns, "WATCHLIST_DAILY_RUNS")
+
+	if c.SMTP.Host != "" || c.SMTP.From != "" {
+		require(c.SMTP.Host, "SMTP_HOST")
+		require(c.SMTP.From, "SMTP_FROM")
+		port(c.SMTP.Port, "SMTP_PORT")
+	}
+
+	return problems
+}
+
+func isLLMOperation(operation string) bool {
+	for _, known := range llmOperations {
+		if operation == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setRequiredEnv sets the values without a default and points CONFIG_FILE
+// at an empty file, so the tests do not depend on the machine they run on.
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+
+	empty := filepath.Join(t.TempDir(), "empty.yaml")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string]string{
+		"CONFIG_FILE":    empty,
+		"APP_ENV":        "",
+		"DB_HOST":        "localhost",
+		"DB_USER":        "postgres",
+		"DB_PASS":        "db-password",
+		"DB_NAME":        "ulascan",
+		"JWT_SECRET":     "jwt-secret",
+		"ML_URL":         "http://ml.local/predict",
+		"LLM_PROVIDER":   "fake",
+		"CACHE_BACKEND":  "",
+		"CACHE_TTL":      "",
+		"SMTP_HOST":      "",
+		"SMTP_FROM":      "",
+		"GEMINI_API_KEY": "",
+	} {
+		t.Setenv(name, value)
+	}
+}
+
+func TestLoadDefaultsAndEnv(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("CACHE_TTL", "30m")
+	t.Setenv("LLM_SUMMARIZE_TEMPERATURE", "0.4")
+	t.Setenv("WORKER_DRAIN_TIMEOUT", "5m")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	if cfg.Env != "production" || cfg.Server.Port != "8080" || cfg.Database.TimeZone != "Asia/Jakarta" {
+		t.Errorf("defaults not applied: %+v", cfg)
+	}
+	if cfg.Database.Host != "localhost" || cfg.JWT.Secret.Value() != "jwt-secret" {
+		t.Errorf("environment not applied: %+v", cfg)
+	}
+	if cfg.Cache.TTL != 30*time.Minute {
+		t.Errorf("CACHE_TTL = %v, want 30m", cfg.Cache.TTL)
+	}
+	if cfg.Server.DrainTimeout != 30*time.Second || cfg.Server.WorkerDrainTimeout != 5*time.Minute {
+		t.Errorf("drain timeouts = %v, %v, want 30s, 5m", cfg.Server.DrainTimeout, cfg.Server.WorkerDrainTimeout)
+	}
+	if temperature := cfg.LLM.Operations["summarize"].Temperature; temperature == nil || *temperature != 0.4 {
+		t.Errorf("summarize temperature = %v, want 0.4", temperature)
+	}
+}
+
+func TestLoadFileBelowEnv(t *testing.T) {
+	setRequiredEnv(t)
+
+	file := filepath.Join(t.TempDir(), "config.yaml")
+	content := "server:\n  port: \"9090\"\ndatabase:\n  host: db.internal\ncache:\n  ttl: 2h\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("DB_HOST", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Server.Port != "9090" || cfg.Database.Host != "db.internal" || cfg.Cache.TTL != 2*time.Hour {
+		t.Errorf("file not applied: %+v", cfg)
+	}
+
+	t.Setenv("DB_HOST", "db.env")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if cfg.Database.Host != "db.env" {
+		t.Errorf("DB_HOST = %q, want the environment to win over the file", cfg.Database.Host)
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("ML_URL", "")
+	t.Setenv("CACHE_SIZE", "lots")
+	t.Setenv("LLM_PROVIDER", "gemini")
+	t.Setenv("ML_PER_STATEMENT_LIMIT", "-1")
+
+	_, err := Load()
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("err = %v, want a ValidationError", err)
+	}
+	for _, name := range []string{"JWT_SECRET", "ML_URL", "CACHE_SIZE", "GEMINI_API_KEY", "ML_PER_STATEMENT_LIMIT"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("report does not mention %s:\n%v", name, err)
+		}
+	}
+}
+
+func TestLoadRequiresOpenAIModel(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("LLM_PROVIDER", "openai")
+	t.Setenv("OPENAI_BASE_URL", "http://localhost:11434/v1")
+	t.Setenv("LLM_MODEL", "")
+	t.Setenv("LLM_ANALYZE_MODEL", "llama3.1")
+	t.Setenv("LLM_SUMMARIZE_MODEL", "")
+	t.Setenv("LLM_COMPARE_MODEL", "")
+
+	_, err := Load()
+	if err == nil {
+		t.Fatal("load succeeded without a model")
+	}
+	for _, name := range []string{"LLM_SUMMARIZE_MODEL", "LLM_COMPARE_MODEL"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("report does not mention %s:\n%v", name, err)
+		}
+	}
+	if strings.Contains(err.Error(), "LLM_ANALYZE_MODEL") {
+		t.Errorf("report asks for the analyze model that is set:\n%v", err)
+	}
+
+	t.Setenv("LLM_SUMMARIZE_MODEL", "llama3.1")
+	t.Setenv("LLM_COMPARE_MODEL", "llama3.1")
+	if _, err := Load(); err != nil {
+		t.Errorf("load with a model per operation: %v", err)
+	}
+
+	t.Setenv("LLM_MODEL", "llama3.1")
+	t.Setenv("LLM_ANALYZE_MODEL", "")
+	t.Setenv("LLM_SUMMARIZE_MODEL", "")
+	t.Setenv("LLM_COMPARE_MODEL", "")
+	if _, err := Load(); err != nil {
+		t.Errorf("load with LLM_MODEL: %v", err)
+	}
+}
+
+func TestSecretsAreRedacted(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	for _, out := range []string{cfg.String(), fmt.Sprintf("%v", cfg), fmt.Sprintf("%+v", cfg.Database)} {
+		if strings.Contains(out, "db-password") || strings.Contains(out, "jwt-secret") {
+			t.Errorf("secret leaked into %q", out)
+		}
+	}
+	if !strings.Contains(cfg.String(), redacted) {
+		t.Errorf("String() does not mark redacted secrets:\n%s", cfg.String())
+	}
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv overrides cfg with every environment variable that is set and
+// not empty. Values that do not parse are added to problems.
+func applyEnv(cfg *Config, problems *[]string) {
+	env := envReader{problems: problems}
+
+	env.string(&cfg.Env, "APP_ENV")
+
+	env.string(&cfg.Server.Port, "PORT")
+	env.string(&cfg.Server.PublicHost, "IP_INSTANCE")
+	env.duration(&cfg.Server.DrainTimeout, "DRAIN_TIMEOUT")
+	env.duration(&cfg.Server.WorkerDrainTimeout, "WORKER_DRAIN_TIMEOUT")
+	env.duration(&cfg.Server.ShutdownDelay, "SHUTDOWN_DELAY")
+
+	env.string(&cfg.Database.Host, "DB_HOST")
+	env.string(&cfg.Database.Port, "DB_PORT")
+	env.string(&cfg.Database.User, "DB_USER")
+	env.secret(&cfg.Database.Password, "DB_PASS")
+	env.string(&cfg.Database.Name, "DB_NAME")
+	env.string(&cfg.Database.TimeZone, "DB_TIMEZONE")
+
+	env.secret(&cfg.JWT.Secret, "JWT_SECRET")
+
+	env.string(&cfg.Endpoints.TokopediaURL, "TOKOPEDIA_GRAPHQL_URL")
+	env.string(&cfg.Endpoints.ShopeeURL, "SHOPEE_URL")
+	env.string(&cfg.Endpoints.ModelURL, "ML_URL")
+	env.secret(&cfg.Endpoints.ModelAPIKey, "ML_API_KEY")
+	env.int(&cfg.Endpoints.ModelPerStatementLimit, "ML_PER_STATEMENT_LIMIT")
+
+	env.string(&cfg.LLM.Provider, "LLM_PROVIDER")
+	cfg.LLM.Provider = strings.ToLower(cfg.LLM.Provider)
+	env.string(&cfg.LLM.Model, "LLM_MODEL")
+	env.int(&cfg.LLM.MaxRetries, "LLM_MAX_RETRIES")
+	env.secret(&cfg.LLM.GeminiAPIKey, "GEMINI_API_KEY")
+	env.string(&cfg.LLM.OpenAIBaseURL, "OPENAI_BASE_URL")
+	env.secret(&cfg.LLM.OpenAIAPIKey, "OPENAI_API_KEY")
+	for _, operation := range llmOperations {
+		override := cfg.LLM.Operations[operation]
+		prefix := "LLM_" + strings.ToUpper(operation) + "_"
+
+		env.string(&override.Model, prefix+"MODEL")
+		env.float32(&override.Temperature, prefix+"TEMPERATURE")
+		env.int(&override.MaxTokens, prefix+"MAX_TOKENS")
+
+		if override != (LLMOperationConfig{}) {
+			if cfg.LLM.Operations == nil {
+				cfg.LLM.Operations = make(map[string]LLMOperationConfig)
+			}
+			cfg.LLM.Operations[operation] = override
+		}
+	}
+
+	env.string(&cfg.Cache.Backend, "CACHE_BACKEND")
+	cfg.Cache.Backend = strings.ToLower(cfg.Cache.Backend)
+	env.int(&cfg.Cache.Size, "CACHE_SIZE")
+	env.duration(&cfg.Cache.TTL, "CACHE_TTL")
+	env.string(&cfg.Cache.Redis.Addr, "REDIS_ADDR")
+	env.secret(&cfg.Cache.Redis.Password, "REDIS_PASSWORD")
+	env.int(&cfg.Cache.Redis.DB, "REDIS_DB")
+
+	env.int(&cfg.AnalysisJob.Workers, "ANALYSIS_WORKERS")
+	env.int(&cfg.Watchlist.MaxItems, "WATCHLIST_MAX_ITEMS")
+	env.int(&cfg.Watchlist.DailyRuns, "WATCHLIST_DAILY_RUNS")
+
+	env.string(&cfg.SMTP.Host, "SMTP_HOST")
+	env.string(&cfg.SMTP.Port, "SMTP_PORT")
+	env.string(&cfg.SMTP.Username, "SMTP_USERNAME")
+	env.secret(&cfg.SMTP.Password, "SMTP_PASSWORD")
+	env.string(&cfg.SMTP.From, "SMTP_FROM")
+}
+
+type envReader struct {
+	problems *[]string
+}
+
+func (r envReader) lookup(name string) (string, bool) {
+	value := strings.TrimSpace(os.Getenv(name))
+	return value, value != ""
+}
+
+func (r envReader) invalid(name string, value string, kind string) {
+	*r.problems = append(*r.problems, fmt.Sprintf("%s must be %s, got %q", name, kind, value))
+}
+
+func (r envReader) string(dst *string, name string) {
+	if value, ok := r.lookup(name); ok {
+		*dst = value
+	}
+}
+
+func (r envReader) secret(dst *Secret, name string) {
+	if value, ok := r.lookup(name); ok {
+		*dst = Secret(value)
+	}
+}
+
+func (r envReader) int(dst *int, name string) {
+	value, ok := r.lookup(name)
+	if !ok {
+		return
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		r.invalid(name, value, "an integer")
+		return
+	}
+	*dst = n
+}
+
+func (r envReader) float32(dst **float32, name string) {
+	value, ok := r.lookup(name)
+	if !ok {
+		return
+	}
+	f, err := strconv.ParseFloat(value, 32)
+	if err != nil {
+		r.invalid(name, value, "a number")
+		return
+	}
+	f32 := float32(f)
+	*dst = &f32
+}
+
+func (r envReader) duration(dst *time.Duration, name string) {
+	value, ok := r.lookup(name)
+	if !ok {
+		return
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		r.invalid(name, value, "a duration such as 30m or 1h")
+		return
+	}
+	*dst = d
+}
@@ -7,3 +7,65 @@ const (
 	ENUM_RUN_PRODUCTION = "production"
 	ENUM_RUN_DEV        = "development"
 )
+
+const (
+	ENUM_JOB_STATUS_QUEUED    = "queued"
+	ENUM_JOB_STATUS_RUNNING   = "running"
+	ENUM_JOB_STATUS_SUCCEEDED = "succeeded"
+	ENUM_JOB_STATUS_FAILED    = "failed"
+
+	ENUM_JOB_KIND_PRODUCT = "product"
+	ENUM_JOB_KIND_SHOP    = "shop"
+
+	ENUM_STAGE_STATUS_PENDING   = "pending"
+	ENUM_STAGE_STATUS_RUNNING   = "running"
+	ENUM_STAGE_STATUS_PARTIAL   = "partial"
+	ENUM_STAGE_STATUS_SUCCEEDED = "succeeded"
+	ENUM_STAGE_STATUS_FAILED    = "failed"
+)
+
+const (
+	ENUM_PROVIDER_TOKOPEDIA = "tokopedia"
+	ENUM_PROVIDER_SHOPEE    = "shopee"
+)
+
+const (
+	ENUM_SENTIMENT_POSITIVE = "positive"
+	ENUM_SENTIMENT_NEGATIVE = "negative"
+	ENUM_SENTIMENT_NEUTRAL  = "neutral"
+)
+
+const (
+	ENUM_LLM_PROVIDER_GEMINI = "gemini"
+	ENUM_LLM_PROVIDER_OPENAI = "openai"
+	ENUM_LLM_PROVIDER_FAKE   = "fake"
+)
+
+const (
+	ENUM_CACHE_MEMORY = "memory"
+	ENUM_CACHE_REDIS  = "redis"
+	ENUM_CACHE_NONE   = "none"
+)
+
+const (
+	ENUM_ALERT_METRIC_NEGATIVE_SHARE    = "negative_share"
+	ENUM_ALERT_METRIC_POSITIVE_SHARE    = "positive_share"
+	ENUM_ALERT_METRIC_BINTANG           = "bintang"
+	ENUM_ALERT_METRIC_PACKAGING         = "packaging"
+	ENUM_ALERT_METRIC_DELIVERY          = "delivery"
+	ENUM_ALERT_METRIC_ADMIN_RESPONSE    = "admin_response"
+	ENUM_ALERT_METRIC_PRODUCT_CONDITION = "product_condition"
+
+	ENUM_ALERT_CONDITION_ABOVE    = "above"
+	ENUM_ALERT_CONDITION_BELOW    = "below"
+	ENUM_ALERT_CONDITION_RISES_BY = "rises_by"
+	ENUM_ALERT_CONDITION_DROPS_BY = "drops_by"
+
+	ENUM_ALERT_CHANNEL_WEBHOOK = "webhook"
+	ENUM_ALERT_CHANNEL_EMAIL   = "email"
+
+	ENUM_DELIVERY_STATUS_PENDING   = "pending"
+	ENUM_DELIVERY_STATUS_SUCCEEDED = "succeeded"
+	ENUM_DELIVERY_STATUS_FAILED    = "failed"
+	ENUM_DELIVERY_STATUS_CANCELLED = "cancelled"
+)
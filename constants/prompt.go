@@ -8,28 +8,39 @@ Output:
 }
 	`
 
-	PROMPT_ANALYZE = ` Harap tentukan jumlah komentar di bawah ini yang menggambarkan kondisi baik, buruk, atau tidak memberikan informasi yang cukup di setiap aspek, yaitu: 
-	1. packaging atau pengemasan, 
-	2. delivery atau pengiriman, 
-	3. respon penjual atau respon admin, 
-	4. product condition atau kondisi produk. 
-	Pastikan bahwa total jumlah komentar tidak melebihi 100, tidak ada yang tumpang tindih, dan setiap komentar hanya diklasifikasikan ke satu kategori. Hanya perlu menampilkan jumlah komentar tanpa rincian komentar aslinya:
-jumlah = (komentar positif / (komentar positif + komentar negatif)) * 100
-jumlah dalam format float atau bilangan desimal.
-
-PENTING: Output harus berupa JSON yang menyimpan score dalam bentuk desimal atau float bukan string dengan format sebagai berikut:   
+	PROMPT_SUMMARIZE_STREAM = `Tolong summarize product reviews di bawah ini maksimal 5 kalimat, dan gunakan Bahasa Indonesia.
+Tuliskan hanya teks ringkasannya saja tanpa format JSON, judul, atau penjelasan tambahan:
+	`
+
+	PROMPT_ANALYZE = `Klasifikasikan setiap ulasan produk di bawah ini secara terpisah.
+Untuk setiap ulasan, tentukan satu aspek utama yang dibahas:
+	1. "packaging" untuk pengemasan,
+	2. "delivery" untuk pengiriman,
+	3. "admin_response" untuk respon penjual atau respon admin,
+	4. "product_condition" untuk kondisi produk,
+	5. "none" jika ulasan tidak membahas aspek di atas.
+Lalu tentukan sentimen ulasan terhadap aspek tersebut: "positive", "negative", atau "neutral".
+
+Input berupa array JSON dengan "id" dan "text". Gunakan "id" yang sama persis pada output dan berikan tepat satu label untuk setiap ulasan.
+
+PENTING: Output harus berupa JSON dengan format sebagai berikut:
 {
-	"packaging": float(jumlah),
-	"delivery": float(jumlah),
-	"admin_response": float(jumlah),
-	"product_condition": float(jumlah)
-}                                              
-Contoh Output:
+	"labels": [
+		{"id": "id ulasan", "aspect": "packaging", "sentiment": "positive"}
+	]
+}
+`
+
+	PROMPT_COMPARE = `Bandingkan produk-produk di bawah ini berdasarkan hasil analisis ulasannya dan gunakan Bahasa Indonesia.
+Input berupa array JSON. Setiap produk memiliki "position", "product_name", rata-rata bintang ("bintang"), persentase ulasan positif dan negatif ("positive_ratio", "negative_ratio"), serta skor aspek 0-100 untuk "packaging" (pengemasan), "delivery" (pengiriman), "admin_response" (respon penjual) dan "product_condition" (kondisi produk).
+Jelaskan produk mana yang unggul pada setiap aspek, sebut produk dengan namanya, lalu tutup dengan rekomendasi produk terbaik secara keseluruhan. Maksimal 8 kalimat.
+
+PENTING: Output harus berupa JSON dengan format sebagai berikut:
 {
-	"packaging": 12.34,
-	"delivery": 56.78,
-	"admin_response": 91.01,
-	"product_condition": 11.12
+	"verdict": verdict
 }
 `
+
+	PROMPT_RETRY_INVALID_OUTPUT = `Jawaban sebelumnya tidak valid: %s.
+Perbaiki dan kirim ulang jawaban lengkap dalam format JSON yang diminta, tanpa teks lain.`
 )
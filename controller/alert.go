@@ -0,0 +1,199 @@
+package controller
+
+import (
+	"net/http"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/service"
+	"review_product_tokopedia_be/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	AlertController interface {
+		CreateRule(ctx *gin.Context)
+		GetRules(ctx *gin.Context)
+		GetRule(ctx *gin.Context)
+		UpdateRule(ctx *gin.Context)
+		DeleteRule(ctx *gin.Context)
+		GetDeliveries(ctx *gin.Context)
+	}
+
+	alertController struct {
+		alertService service.AlertService
+	}
+)
+
+func NewAlertController(as service.AlertService) AlertController {
+	return &alertController{
+		alertService: as,
+	}
+}
+
+// CreateRule godoc
+// @Summary Create an alert rule.
+// @Description Get notified by webhook or email when an analysis crosses a threshold, e.g. negative_share above 30 or delivery drops_by 10. Leave provider and product_id empty to watch every product. Webhook rules return the signing secret.
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param request body dto.AlertRuleCreateRequest true "Rule definition"
+// @Success 201 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/alerts [post]
+func (c *alertController) CreateRule(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	var req dto.AlertRuleCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.alertService.CreateRule(ctx.Request.Context(), req, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ALERT_RULE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_ALERT_RULE, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// GetRules godoc
+// @Summary Retrieve the user's alert rules.
+// @Description Retrieve the user's alert rules.
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/alerts [get]
+func (c *alertController) GetRules(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	result, err := c.alertService.GetRules(ctx.Request.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ALERT_RULES, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ALERT_RULES, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetRule godoc
+// @Summary Retrieve an alert rule by id.
+// @Description Retrieve an alert rule by id.
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/alerts/{id} [get]
+func (c *alertController) GetRule(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	result, err := c.alertService.GetRuleById(ctx.Request.Context(), id, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ALERT_RULE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ALERT_RULE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// UpdateRule godoc
+// @Summary Update an alert rule.
+// @Description Change the condition, threshold or target of a rule, or pause and resume it. The channel and product scope cannot be changed.
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Param request body dto.AlertRuleUpdateRequest true "Fields to change"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/alerts/{id} [patch]
+func (c *alertController) UpdateRule(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	var req dto.AlertRuleUpdateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.alertService.UpdateRule(ctx.Request.Context(), req, id, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_ALERT_RULE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_ALERT_RULE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DeleteRule godoc
+// @Summary Delete an alert rule.
+// @Description Delete an alert rule together with its delivery log.
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/alerts/{id} [delete]
+func (c *alertController) DeleteRule(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	if err := c.alertService.DeleteRule(ctx.Request.Context(), id, userId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_ALERT_RULE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_ALERT_RULE, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetDeliveries godoc
+// @Summary Retrieve the delivery log of an alert rule.
+// @Description Retrieve the 50 most recent deliveries of a rule with their status, attempts and last error.
+// @Tags Alert
+// @Accept json
+// @Produce json
+// @Param id path string true "Alert rule ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/alerts/{id}/deliveries [get]
+func (c *alertController) GetDeliveries(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	result, err := c.alertService.GetDeliveries(ctx.Request.Context(), id, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ALERT_DELIVERIES, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ALERT_DELIVERIES, result)
+	ctx.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"net/http"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/service"
+	"review_product_tokopedia_be/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	HealthController interface {
+		Liveness(ctx *gin.Context)
+		Readiness(ctx *gin.Context)
+		Status(ctx *gin.Context)
+	}
+
+	healthController struct {
+		healthService service.HealthService
+	}
+)
+
+func NewHealthController(hs service.HealthService) HealthController {
+	return &healthController{
+		healthService: hs,
+	}
+}
+
+// Liveness godoc
+// @Summary Liveness probe
+// @Description Reports that the process is up and serving requests. It checks no dependency, so a database outage does not get the instance restarted.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Router /healthz [get]
+func (c *healthController) Liveness(ctx *gin.Context) {
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ALIVE, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Readiness godoc
+// @Summary Readiness probe
+// @Description Reports whether the instance should receive traffic: the database answers and every migration is applied. Returns 503 while starting, while draining during shutdown and when a check fails.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Failure 503 {object} utils.Response
+// @Router /readyz [get]
+func (c *healthController) Readiness(ctx *gin.Context) {
+	if err := c.healthService.Ready(ctx.Request.Context()); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_NOT_READY, err.Error(), nil)
+		ctx.JSON(http.StatusServiceUnavailable, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_READY, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// Status godoc
+// @Summary Dependency status
+// @Description Probes the database, Tokopedia, the ML service and the LLM provider, reporting the latency and last error of each. Results are reused for a few seconds.
+// @Tags Health
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.Response{data=dto.StatusResponse}
+// @Failure 401 {object} utils.Response
+// @Failure 403 {object} utils.Response
+// @Router /api/admin/status [get]
+func (c *healthController) Status(ctx *gin.Context) {
+	status := c.healthService.Status(ctx.Request.Context())
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_STATUS, status)
+	ctx.JSON(http.StatusOK, res)
+}
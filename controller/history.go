@@ -3,6 +3,7 @@ package controller
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"review_product_tokopedia_be/dto"
 	"review_product_tokopedia_be/service"
@@ -15,16 +16,23 @@ type (
 	HistoryController interface {
 		GetHistories(ctx *gin.Context)
 		GetHistory(ctx *gin.Context)
+		GetHistoryReviews(ctx *gin.Context)
+		GetSnapshots(ctx *gin.Context)
+		GetSnapshotDiff(ctx *gin.Context)
+		GetComparisons(ctx *gin.Context)
+		GetComparison(ctx *gin.Context)
 	}
 
 	historyController struct {
-		historyService service.HistoryService
+		historyService    service.HistoryService
+		comparisonService service.ComparisonService
 	}
 )
 
-func NewHistoryController(hs service.HistoryService) HistoryController {
+func NewHistoryController(hs service.HistoryService, cs service.ComparisonService) HistoryController {
 	return &historyController{
-		historyService: hs,
+		historyService:    hs,
+		comparisonService: cs,
 	}
 }
 
@@ -101,3 +109,169 @@ func (c *historyController) GetHistory(ctx *gin.Context) {
 	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_HISTORIES, result)
 	ctx.JSON(http.StatusOK, res)
 }
+
+// GetHistoryReviews godoc
+// @Summary List the reviews behind an analysis history.
+// @Description List the stored reviews of an analysis history, filtered by sentiment, aspect or a full-text query.
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param id path string true "History ID"
+// @Param sentiment query string false "Sentiment label (positive, negative, neutral)"
+// @Param aspect query string false "Aspect (packaging, delivery, admin_response, product_condition)"
+// @Param q query string false "Full-text search on the review message"
+// @Param page query int false "Page number (default 1)"
+// @Param limit query int false "Maximum number of results per page (default 20, max 100)"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/history/{id}/reviews [get]
+func (c *historyController) GetHistoryReviews(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	page, err := strconv.Atoi(ctx.DefaultQuery("page", "1"))
+	if err != nil || page < 1 {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS_OF_HISTORY, dto.ErrInvalidPagination.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS_OF_HISTORY, dto.ErrInvalidPagination.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	req := dto.HistoryReviewsGetRequest{
+		Page:      page,
+		Limit:     limit,
+		Sentiment: strings.ToLower(ctx.Query("sentiment")),
+		Aspect:    ctx.Query("aspect"),
+		Query:     strings.TrimSpace(ctx.Query("q")),
+	}
+
+	result, err := c.historyService.GetHistoryReviews(ctx.Request.Context(), req, id, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS_OF_HISTORY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccessWithMeta(dto.MESSAGE_SUCCESS_GET_REVIEWS_OF_HISTORY, result.Reviews, result.Meta)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetSnapshots godoc
+// @Summary Retrieve the analysis timeline of a product.
+// @Description Retrieve every analysis snapshot the user has taken of a product, oldest first.
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param productId path string true "Marketplace product ID"
+// @Param provider query string false "Marketplace (tokopedia or shopee)"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/history/products/{productId}/snapshots [get]
+func (c *historyController) GetSnapshots(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	productId := ctx.Param("productId")
+
+	result, err := c.historyService.GetSnapshots(ctx.Request.Context(), ctx.Query("provider"), productId, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SNAPSHOTS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_SNAPSHOTS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetSnapshotDiff godoc
+// @Summary Compare two analysis snapshots of a product.
+// @Description Show the change in counts, star average and aspect scores between two snapshots, with both summaries. Defaults to the latest snapshot and the one before it.
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param productId path string true "Marketplace product ID"
+// @Param provider query string false "Marketplace (tokopedia or shopee)"
+// @Param from query string false "Snapshot ID to compare from"
+// @Param to query string false "Snapshot ID to compare to"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/history/products/{productId}/diff [get]
+func (c *historyController) GetSnapshotDiff(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	productId := ctx.Param("productId")
+
+	var req dto.HistorySnapshotDiffRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SNAPSHOT_DIFF, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.historyService.GetSnapshotDiff(ctx.Request.Context(), req, productId, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SNAPSHOT_DIFF, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_SNAPSHOT_DIFF, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetComparisons godoc
+// @Summary Retrieve the user's saved comparisons.
+// @Description Retrieve the user's saved comparisons, newest first.
+// @Tags History
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/history/comparisons [get]
+func (c *historyController) GetComparisons(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	result, err := c.comparisonService.GetComparisons(ctx.Request.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_COMPARISONS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_COMPARISONS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetComparison godoc
+// @Summary Retrieve a saved comparison by id.
+// @Description Retrieve a saved comparison by id.
+// @Tags History
+// @Accept json
+// @Produce json
+// @Param id path string true "Comparison ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/history/comparisons/{id} [get]
+func (c *historyController) GetComparison(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	result, err := c.comparisonService.GetComparisonById(ctx.Request.Context(), id, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_COMPARISON, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_COMPARISON, result)
+	ctx.JSON(http.StatusOK, res)
+}
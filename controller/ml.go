@@ -2,45 +2,44 @@ package controller
 
 import (
 	"errors"
-	"fmt"
+	"io"
 	"net/http"
-	"net/url"
-	"strings"
-	"sync"
+	"strconv"
 
+	"review_product_tokopedia_be/constants"
 	"review_product_tokopedia_be/dto"
 	"review_product_tokopedia_be/service"
 	"review_product_tokopedia_be/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 type (
 	MLController interface {
 		GetSentimentAnalysisAndSummarization(ctx *gin.Context)
 		GetSentimentAnalysisAndSummarizationAsGuest(ctx *gin.Context)
+		StreamSentimentAnalysisAndSummarization(ctx *gin.Context)
+		CreateAnalysisJob(ctx *gin.Context)
+		GetAnalysisJob(ctx *gin.Context)
+		CompareProducts(ctx *gin.Context)
+		GetShopAnalysis(ctx *gin.Context)
+		CreateShopAnalysisJob(ctx *gin.Context)
 	}
 
 	mlController struct {
-		tokopediaService service.TokopediaService
-		modelService     service.ModelService
-		geminiService    service.GeminiService
-		historyService   service.HistoryService
+		analysisService     service.AnalysisService
+		analysisJobService  service.AnalysisJobService
+		comparisonService   service.ComparisonService
+		shopAnalysisService service.ShopAnalysisService
 	}
 )
 
-func NewMLController(
-	ts service.TokopediaService,
-	ms service.ModelService,
-	gs service.GeminiService,
-	hs service.HistoryService,
-) MLController {
+func NewMLController(as service.AnalysisService, ajs service.AnalysisJobService, cs service.ComparisonService, sas service.ShopAnalysisService) MLController {
 	return &mlController{
-		tokopediaService: ts,
-		modelService:     ms,
-		geminiService:    gs,
-		historyService:   hs,
+		analysisService:     as,
+		analysisJobService:  ajs,
+		comparisonService:   cs,
+		shopAnalysisService: sas,
 	}
 }
 
@@ -50,194 +49,39 @@ func NewMLController(
 // @Tags Analysis
 // @Accept json
 // @Produce json
-// @Param product_url query string true "Tokopedia Product Link"
+// @Param product_url query string true "Product link (Tokopedia or Shopee)"
+// @Param max_reviews query int false "Maximum number of reviews to analyse (default 100, max 1000)"
+// @Param sort query string false "Review order: newest, oldest, highest_rating, lowest_rating or most_helpful"
+// @Param rating query string false "Only include these star ratings, comma separated, e.g. 1,2"
+// @Param since query string false "Only include reviews created at or after this date (YYYY-MM-DD or RFC3339)"
+// @Param until query string false "Only include reviews created before this date (YYYY-MM-DD or RFC3339)"
+// @Param with_media query bool false "Only include reviews with photos or videos"
+// @Param refresh query bool false "Ignore cached results and recompute every step"
 // @Success 200 {object} utils.Response
 // @Failure 400 {object} utils.Response
 // @Router /api/ml/guest/analysis [get]
 func (c *mlController) GetSentimentAnalysisAndSummarizationAsGuest(ctx *gin.Context) {
 	productUrl := ctx.Query("product_url")
-	fmt.Printf("Received product_url: %s\n", productUrl)
-	if productUrl == "" {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, dto.ErrProductUrlMissing.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-		return
-	}
 
-	parsedUrl, err := url.Parse(productUrl)
+	reviewOpts, err := bindReviewOptions(ctx)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, err.Error(), nil)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
 
-	if parsedUrl.Host == "tokopedia.link" {
-		expandedUrl, err := expandUrl(productUrl)
-		if err != nil {
-			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, err.Error(), nil)
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-			return
-		}
-		productUrl = expandedUrl
-
-		parsedUrl, err = url.Parse(productUrl)
-		if err != nil {
-			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, err.Error(), nil)
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-			return
-		}
-	}
-
-	// Validate that the URL is from tokopedia.com
-	if parsedUrl.Host != "www.tokopedia.com" && parsedUrl.Host != "tokopedia.com" {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, dto.ErrNotTokopediaUrls.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-		return
-	}
-
-	pathParts := strings.Split(parsedUrl.Path, "/")
-	fmt.Printf("Parsed Path: %+v\n", pathParts)
-	if len(pathParts) < 3 {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, dto.ErrProductUrlWrongFormat.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-		return
-	}
-
-	productReq := dto.GetProductRequest{
-		ShopDomain: pathParts[1],
-		ProductKey: pathParts[2],
-		ProductUrl: "https://www.tokopedia.com/" + pathParts[1] + "/" + pathParts[2],
-	}
-
-	product, err := c.tokopediaService.GetProduct(ctx, productReq)
-	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_PRODUCT_ID, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
-		return
-	}
-
-	// fmt.Println("=== PRODUCT ID ===")
-	// fmt.Println(product)
-
-	reviewsReq := dto.GetReviewsRequest{
-		ProductUrl: productReq.ProductUrl,
-		ProductId:  product.ProductId,
-	}
+	refresh, _ := strconv.ParseBool(ctx.Query("refresh"))
 
-	reviews, err := c.tokopediaService.GetReviews(ctx, reviewsReq)
+	result, err := c.analysisService.Analyze(ctx.Request.Context(), productUrl, dto.AnalysisOptions{
+		Reviews: reviewOpts,
+		Refresh: refresh,
+	})
 	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		abortWithAnalysisError(ctx, err)
 		return
 	}
 
-	// fmt.Println("=== REVIEWS ===")
-	// fmt.Println(reviews)
-
-	// Assuming `reviews` is a slice of a struct with `Message` and `Rating` fields
-	statements := make([]string, len(reviews))
-	ratingSum := 0.0
-
-	for i, review := range reviews {
-		statements[i] = review.Message
-		ratingSum += float64(review.Rating)
-	}
-
-	var ratingAvg float64
-	if len(reviews) > 0 {
-		ratingAvg = ratingSum / float64(len(reviews))
-	} else {
-		ratingAvg = 0.0 // or handle the case where there are no reviews
-	}
-
-	predictReq := dto.PredictRequest{
-		Statements: statements,
-	}
-
-	// fmt.Println("=== PREDICT REQ ===")
-	// fmt.Println(predictReq)
-
-	var builder strings.Builder
-	for _, review := range reviews {
-		builder.WriteString(review.Message)
-		builder.WriteString("\n")
-	}
-	concatenatedMessage := builder.String()
-
-	var shopAvatar string
-	var predictResult dto.PredictResponse
-	var analyzeResult dto.AnalyzeResponse
-	var summarizeResult string
-
-	var wg sync.WaitGroup
-	// var shopAvatarErr, predictErr error
-	var shopAvatarErr, predictErr, analyzeErr, summarizeErr error
-
-	wg.Add(4)
-
-	go func() {
-		defer wg.Done()
-		shopAvatar, shopAvatarErr = c.tokopediaService.GetShopAvatar(ctx, productReq.ShopDomain)
-	}()
-
-	go func() {
-		defer wg.Done()
-		fmt.Println("Sending data to ML service...")
-		predictResult, predictErr = c.modelService.Predict(ctx, predictReq)
-		fmt.Printf("ML Service Response: %+v, Error: %v\n", predictResult, predictErr)
-	}()
-
-	go func() {
-		defer wg.Done()
-		analyzeResult, analyzeErr = c.geminiService.Analyze(ctx, concatenatedMessage)
-	}()
-
-	go func() {
-		defer wg.Done()
-		summarizeResult, err = c.geminiService.Summarize(ctx, concatenatedMessage)
-	}()
-
-	wg.Wait()
-
-	if shopAvatarErr != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SHOP_AVATAR, shopAvatarErr.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
-		return
-	}
-
-	if predictErr != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PREDICT, predictErr.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
-		return
-	}
-
-	if summarizeErr != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ANALYZE, summarizeErr.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
-		return
-	}
-	if analyzeErr != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ANALYZE, analyzeErr.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
-		return
-	}
-
-	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_REVIEWS, dto.MLResult{
-		ProductName:        product.ProductName,
-		ProductDescription: product.ProductDescription,
-		Rating:             len(reviews),
-		Ulasan:             predictResult.CountNegative + predictResult.CountPositive,
-		Bintang:            ratingAvg,
-		ImageUrls:          product.ImageUrls,
-		ShopName:           product.ShopName,
-		ShopAvatar:         shopAvatar,
-		CountNegative:      predictResult.CountNegative,
-		CountPositive:      predictResult.CountPositive,
-		Packaging:          analyzeResult.Packaging,
-		Delivery:           analyzeResult.Delivery,
-		AdminResponse:      analyzeResult.AdminResponse,
-		ProductCondition:   analyzeResult.ProductCondition,
-		Summary:            summarizeResult,
-	})
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_REVIEWS, result.Result)
 	ctx.JSON(http.StatusOK, res)
 }
 
@@ -247,300 +91,303 @@ func (c *mlController) GetSentimentAnalysisAndSummarizationAsGuest(ctx *gin.Cont
 // @Tags Analysis
 // @Accept json
 // @Produce json
-// @Param product_url query string true "Tokopedia Product Link"
+// @Param product_url query string true "Product link (Tokopedia or Shopee)"
+// @Param max_reviews query int false "Maximum number of reviews to analyse (default 100, max 1000)"
+// @Param sort query string false "Review order: newest, oldest, highest_rating, lowest_rating or most_helpful"
+// @Param rating query string false "Only include these star ratings, comma separated, e.g. 1,2"
+// @Param since query string false "Only include reviews created at or after this date (YYYY-MM-DD or RFC3339)"
+// @Param until query string false "Only include reviews created before this date (YYYY-MM-DD or RFC3339)"
+// @Param with_media query bool false "Only include reviews with photos or videos"
+// @Param refresh query bool false "Ignore cached results and recompute every step"
 // @Success 200 {object} utils.Response
 // @Failure 400 {object} utils.Response
 // @Security BearerAuth
 // @Router /api/ml/analysis [get]
 func (c *mlController) GetSentimentAnalysisAndSummarization(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
 	productUrl := ctx.Query("product_url")
-	fmt.Printf("Received product_url: %s\n", productUrl)
-	if productUrl == "" {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, dto.ErrProductUrlMissing.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-		return
-	}
 
-	parsedUrl, err := url.Parse(productUrl)
+	reviewOpts, err := bindReviewOptions(ctx)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, err.Error(), nil)
 		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
 
-	if parsedUrl.Host == "tokopedia.link" {
-		expandedUrl, err := expandUrl(productUrl)
-		if err != nil {
-			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, err.Error(), nil)
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-			return
-		}
-		productUrl = expandedUrl
-
-		parsedUrl, err = url.Parse(productUrl)
-		if err != nil {
-			res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, err.Error(), nil)
-			ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-			return
-		}
-	}
-
-	// Validate that the URL is from tokopedia.com
-	if parsedUrl.Host != "www.tokopedia.com" && parsedUrl.Host != "tokopedia.com" {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, dto.ErrNotTokopediaUrls.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-		return
-	}
-
-	pathParts := strings.Split(parsedUrl.Path, "/")
-	fmt.Printf("Parsed Path: %+v\n", pathParts)
-	if len(pathParts) < 3 {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, dto.ErrProductUrlWrongFormat.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
-		return
-	}
-
-	productReq := dto.GetProductRequest{
-		ShopDomain: pathParts[1],
-		ProductKey: pathParts[2],
-		ProductUrl: "https://www.tokopedia.com/" + pathParts[1] + "/" + pathParts[2],
-	}
+	refresh, _ := strconv.ParseBool(ctx.Query("refresh"))
 
-	product, err := c.tokopediaService.GetProduct(ctx, productReq)
+	result, err := c.analysisService.Analyze(ctx.Request.Context(), productUrl, dto.AnalysisOptions{
+		UserID:  userId,
+		Reviews: reviewOpts,
+		Refresh: refresh,
+	})
 	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_PRODUCT_ID, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		abortWithAnalysisError(ctx, err)
 		return
 	}
 
-	// fmt.Println("=== PRODUCT ID ===")
-	// fmt.Println(product)
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_REVIEWS, result.Result)
+	ctx.JSON(http.StatusOK, res)
+}
 
-	reviewsReq := dto.GetReviewsRequest{
-		ProductUrl: productReq.ProductUrl,
-		ProductId:  product.ProductId,
-	}
+// StreamSentimentAnalysisAndSummarization godoc
+// @Summary Stream product analysis
+// @Description Stream product analysis progress as Server-Sent Events. Each event is named after its stage (product, reviews, shop_avatar, predict, analyze, summarize, history) and carries the stage status and any partial data. The stream ends with either a result or an error event.
+// @Tags Analysis
+// @Produce text/event-stream
+// @Param product_url query string true "Product link (Tokopedia or Shopee)"
+// @Param max_reviews query int false "Maximum number of reviews to analyse (default 100, max 1000)"
+// @Param sort query string false "Review order: newest, oldest, highest_rating, lowest_rating or most_helpful"
+// @Param rating query string false "Only include these star ratings, comma separated, e.g. 1,2"
+// @Param since query string false "Only include reviews created at or after this date (YYYY-MM-DD or RFC3339)"
+// @Param until query string false "Only include reviews created before this date (YYYY-MM-DD or RFC3339)"
+// @Param with_media query bool false "Only include reviews with photos or videos"
+// @Param refresh query bool false "Ignore cached results and recompute every step"
+// @Success 200 {object} dto.AnalysisEvent
+// @Security BearerAuth
+// @Router /api/ml/analysis/stream [get]
+func (c *mlController) StreamSentimentAnalysisAndSummarization(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	productUrl := ctx.Query("product_url")
+	reqCtx := ctx.Request.Context()
 
-	reviews, err := c.tokopediaService.GetReviews(ctx, reviewsReq)
+	reviewOpts, err := bindReviewOptions(ctx)
 	if err != nil {
 		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_REVIEWS, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
 
-	// fmt.Println("=== REVIEWS ===")
-	// fmt.Println(reviews)
+	refresh, _ := strconv.ParseBool(ctx.Query("refresh"))
 
-	// Assuming `reviews` is a slice of a struct with `Message` and `Rating` fields
-	statements := make([]string, len(reviews))
-	ratingSum := 0.0
-
-	for i, review := range reviews {
-		statements[i] = review.Message
-		ratingSum += float64(review.Rating)
-	}
-
-	var ratingAvg float64
-	if len(reviews) > 0 {
-		ratingAvg = ratingSum / float64(len(reviews))
-	} else {
-		ratingAvg = 0.0 // or handle the case where there are no reviews
-	}
-
-	predictReq := dto.PredictRequest{
-		Statements: statements,
-	}
-
-	// fmt.Println("=== PREDICT REQ ===")
-	// fmt.Println(predictReq)
-
-	var builder strings.Builder
-	for _, review := range reviews {
-		builder.WriteString(review.Message)
-		builder.WriteString("\n")
+	events := make(chan dto.AnalysisEvent, 16)
+	send := func(event dto.AnalysisEvent) {
+		select {
+		case events <- event:
+		case <-reqCtx.Done():
+		}
 	}
-	concatenatedMessage := builder.String()
-
-	var shopAvatar string
-	var predictResult dto.PredictResponse
-	var analyzeResult dto.AnalyzeResponse
-	var summarizeResult string
-
-	var wg sync.WaitGroup
-	// var shopAvatarErr, predictErr error
-	var shopAvatarErr, predictErr, analyzeErr, summarizeErr error
-
-	wg.Add(4)
 
 	go func() {
-		defer wg.Done()
-		shopAvatar, shopAvatarErr = c.tokopediaService.GetShopAvatar(ctx, productReq.ShopDomain)
-	}()
+		defer close(events)
+
+		result, err := c.analysisService.Analyze(reqCtx, productUrl, dto.AnalysisOptions{
+			UserID:        userId,
+			Reviews:       reviewOpts,
+			StreamSummary: true,
+			Refresh:       refresh,
+			OnEvent:       send,
+		})
+		if err != nil {
+			data := dto.AnalysisErrorData{
+				Message: dto.MESSAGE_FAILED_PROSES_REQUEST,
+				Error:   err.Error(),
+			}
+			var analysisErr *dto.AnalysisError
+			if errors.As(err, &analysisErr) {
+				data.Stage = analysisErr.Stage
+				data.Message = analysisErr.Message
+			}
+			send(dto.AnalysisEvent{
+				Stage:  dto.ANALYSIS_EVENT_ERROR,
+				Status: constants.ENUM_STAGE_STATUS_FAILED,
+				Data:   data,
+			})
+			return
+		}
 
-	go func() {
-		defer wg.Done()
-		fmt.Println("Sending data to ML service...")
-		predictResult, predictErr = c.modelService.Predict(ctx, predictReq)
-		fmt.Printf("ML Service Response: %+v, Error: %v\n", predictResult, predictErr)
+		send(dto.AnalysisEvent{
+			Stage:  dto.ANALYSIS_EVENT_RESULT,
+			Status: constants.ENUM_STAGE_STATUS_SUCCEEDED,
+			Data:   result.Result,
+		})
 	}()
 
-	go func() {
-		defer wg.Done()
-		analyzeResult, analyzeErr = c.geminiService.Analyze(ctx, concatenatedMessage)
-	}()
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
 
-	go func() {
-		defer wg.Done()
-		summarizeResult, err = c.geminiService.Summarize(ctx, concatenatedMessage)
-	}()
+	ctx.Stream(func(w io.Writer) bool {
+		event, ok := <-events
+		if !ok {
+			return false
+		}
+		ctx.SSEvent(event.Stage, event)
+		return true
+	})
+}
 
-	wg.Wait()
+// CreateAnalysisJob godoc
+// @Summary Enqueue product analysis
+// @Description Enqueue a product analysis to be processed in the background. Poll the returned job for its progress.
+// @Tags Analysis
+// @Accept json
+// @Produce json
+// @Param job body dto.AnalysisJobCreateRequest true "Product link (Tokopedia or Shopee)"
+// @Success 202 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/ml/jobs [post]
+func (c *mlController) CreateAnalysisJob(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
 
-	if shopAvatarErr != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_SHOP_AVATAR, shopAvatarErr.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+	var req dto.AnalysisJobCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
 
-	if predictErr != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PREDICT, predictErr.Error(), nil)
+	result, err := c.analysisJobService.CreateJob(ctx.Request.Context(), req, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ANALYSIS_JOB, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
 		return
 	}
 
-	if summarizeErr != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ANALYZE, summarizeErr.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
-		return
-	}
-	if analyzeErr != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ANALYZE, analyzeErr.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
-		return
-	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_ANALYSIS_JOB, result)
+	ctx.JSON(http.StatusAccepted, res)
+}
 
-	userID, exists := ctx.Get("user_id")
+// GetAnalysisJob godoc
+// @Summary Get analysis job status
+// @Description Get the status, per-stage progress and, once finished, the result of an analysis job.
+// @Tags Analysis
+// @Accept json
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/ml/jobs/{id} [get]
+func (c *mlController) GetAnalysisJob(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
 
-	if !exists {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_USER, analyzeErr.Error(), nil)
+	result, err := c.analysisJobService.GetJobById(ctx.Request.Context(), id, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_ANALYSIS_JOB, err.Error(), nil)
 		ctx.JSON(http.StatusBadRequest, res)
 		return
 	}
 
-	userIDStr, ok := userID.(string)
-	if !ok {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_HISTORY, "Invalid user ID type", nil)
-		ctx.JSON(http.StatusInternalServerError, res)
-		return
-	}
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_ANALYSIS_JOB, result)
+	ctx.JSON(http.StatusOK, res)
+}
 
-	userUUID, err := uuid.Parse(userIDStr)
-	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_HISTORY, "Invalid user ID format", nil)
-		ctx.JSON(http.StatusInternalServerError, res)
+// CompareProducts godoc
+// @Summary Compare products side by side
+// @Description Analyse 2 to 5 product links and compare their star average, positive and negative ratio and aspect scores, with a verdict in Bahasa Indonesia on which product wins on which aspect. Set save to keep the comparison in the history.
+// @Tags Analysis
+// @Accept json
+// @Produce json
+// @Param request body dto.ComparisonCreateRequest true "Product links (Tokopedia or Shopee)"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/ml/compare [post]
+func (c *mlController) CompareProducts(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	var req dto.ComparisonCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
 		return
 	}
 
-
-	history := dto.HistoryCreateRequest{
-		UserID:           userUUID,
-		ProductID:        product.ProductId,
-		Rating:           len(reviews),
-		Ulasan:           predictResult.CountNegative + predictResult.CountPositive,
-		Bintang:          ratingAvg,
-		URL:              productReq.ProductUrl,
-		ProductName:      product.ProductName,
-		CountPositive:    predictResult.CountPositive,
-		CountNegative:    predictResult.CountNegative,
-		Packaging:        analyzeResult.Packaging,
-		Delivery:         analyzeResult.Delivery,
-		AdminResponse:    analyzeResult.AdminResponse,
-		ProductCondition: analyzeResult.ProductCondition,
-		Summary:          summarizeResult,
-	}
-	_, err = c.historyService.CreateHistory(ctx, history)
+	result, err := c.comparisonService.Compare(ctx.Request.Context(), req, userId)
 	if err != nil {
-		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_HISTORY, err.Error(), nil)
-		ctx.JSON(http.StatusInternalServerError, res)
+		abortWithAnalysisError(ctx, err)
 		return
 	}
 
-	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_REVIEWS, dto.MLResult{
-		ProductName:        product.ProductName,
-		ProductDescription: product.ProductDescription,
-		Rating:             len(reviews),
-		Ulasan:             predictResult.CountNegative + predictResult.CountPositive,
-		Bintang:            ratingAvg,
-		ImageUrls:          product.ImageUrls,
-		ShopName:           product.ShopName,
-		ShopAvatar:         shopAvatar,
-		CountNegative:      predictResult.CountNegative,
-		CountPositive:      predictResult.CountPositive,
-		Packaging:          analyzeResult.Packaging,
-		Delivery:           analyzeResult.Delivery,
-		AdminResponse:      analyzeResult.AdminResponse,
-		ProductCondition:   analyzeResult.ProductCondition,
-		Summary:            summarizeResult,
-	})
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_COMPARE_PRODUCTS, result)
 	ctx.JSON(http.StatusOK, res)
 }
 
-func expandUrl(shortUrl string) (string, error) {
-	fmt.Printf("Expanding URL: %s\n", shortUrl)
-	client := &http.Client{
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			// Prevent the default redirect behavior to handle it manually
-			return http.ErrUseLastResponse
-		},
+// GetShopAnalysis godoc
+// @Summary Get shop analysis
+// @Description Analyse a Tokopedia shop as a whole by sampling the newest reviews of its best-selling products. Returns shop-wide sentiment and aspect scores, a per-product breakdown and a shop summary. This runs one product analysis per product, so prefer the job endpoint for large samples.
+// @Tags Analysis
+// @Accept json
+// @Produce json
+// @Param shop_domain query string true "Shop domain or shop page link, e.g. officialstore"
+// @Param products query int false "Number of best-selling products to sample (default 10, max 20)"
+// @Param reviews_per_product query int false "Reviews analysed per product (default 30, max 100)"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/ml/shop/analysis [get]
+func (c *mlController) GetShopAnalysis(ctx *gin.Context) {
+	var req dto.ShopAnalysisRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_ANALYZE_SHOP, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
 	}
 
-	// First request
-	req1, err := http.NewRequest("GET", shortUrl, nil)
+	result, err := c.shopAnalysisService.Analyze(ctx.Request.Context(), req, nil)
 	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
+		abortWithAnalysisError(ctx, err)
+		return
 	}
-	// Set headers for the first request
-	req1.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1")
 
-	// Make the first request
-	resp1, err := client.Do(req1)
-	if err != nil {
-		return "", fmt.Errorf("error making first request: %v", err)
-	}
-	defer resp1.Body.Close()
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_ANALYZE_SHOP, result)
+	ctx.JSON(http.StatusOK, res)
+}
 
-	if resp1.StatusCode >= 400 {
-		return "", fmt.Errorf("unexpected status code from first request: %d", resp1.StatusCode)
-	}
+// CreateShopAnalysisJob godoc
+// @Summary Enqueue shop analysis
+// @Description Enqueue a shop analysis to be processed in the background. Poll /api/ml/jobs/{id} for its progress; the result is returned as shop_result.
+// @Tags Analysis
+// @Accept json
+// @Produce json
+// @Param job body dto.ShopAnalysisRequest true "Shop domain and sample size"
+// @Success 202 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/ml/shop/jobs [post]
+func (c *mlController) CreateShopAnalysisJob(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
 
-	newUrl1 := resp1.Header.Get("Location")
-	if newUrl1 == "" {
-		return "", errors.New("empty Location header in first response")
+	var req dto.ShopAnalysisRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
 	}
 
-	// Second request
-	req2, err := http.NewRequest("GET", newUrl1, nil)
+	result, err := c.analysisJobService.CreateShopJob(ctx.Request.Context(), req, userId)
 	if err != nil {
-		return "", fmt.Errorf("error creating second request: %v", err)
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_ANALYSIS_JOB, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
 	}
-	// Set headers for the second request
-	req2.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1")
 
-	// Make the second request
-	resp2, err := client.Do(req2)
-	if err != nil {
-		return "", fmt.Errorf("error making second request: %v", err)
-	}
-	defer resp2.Body.Close()
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_ANALYSIS_JOB, result)
+	ctx.JSON(http.StatusAccepted, res)
+}
 
-	if resp2.StatusCode >= 400 {
-		return "", fmt.Errorf("unexpected status code from second request: %d", resp2.StatusCode)
+// bindReviewOptions reads the review harvesting options from the query string.
+func bindReviewOptions(ctx *gin.Context) (dto.ReviewOptions, error) {
+	return service.ParseReviewOptions(ctx.Query)
+}
+
+func abortWithAnalysisError(ctx *gin.Context, err error) {
+	var analysisErr *dto.AnalysisError
+	if !errors.As(err, &analysisErr) {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, res)
+		return
 	}
 
-	finalUrl := resp2.Header.Get("Location")
-	if finalUrl == "" {
-		return "", errors.New("empty Location header in second response")
+	status := http.StatusBadRequest
+	if analysisErr.Stage == dto.ANALYSIS_STAGE_HISTORY {
+		status = http.StatusInternalServerError
 	}
 
-	return finalUrl, nil
+	res := utils.BuildResponseFailed(analysisErr.Message, analysisErr.Error(), nil)
+	ctx.AbortWithStatusJSON(status, res)
 }
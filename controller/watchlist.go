@@ -0,0 +1,172 @@
+package controller
+
+import (
+	"net/http"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/service"
+	"review_product_tokopedia_be/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type (
+	WatchlistController interface {
+		CreateWatchlist(ctx *gin.Context)
+		GetWatchlists(ctx *gin.Context)
+		GetWatchlist(ctx *gin.Context)
+		UpdateWatchlist(ctx *gin.Context)
+		DeleteWatchlist(ctx *gin.Context)
+	}
+
+	watchlistController struct {
+		watchlistService service.WatchlistService
+	}
+)
+
+func NewWatchlistController(ws service.WatchlistService) WatchlistController {
+	return &watchlistController{
+		watchlistService: ws,
+	}
+}
+
+// CreateWatchlist godoc
+// @Summary Add a product to the watchlist.
+// @Description Re-analyse a product automatically on a cron schedule, e.g. "0 8 * * *" or "@daily".
+// @Tags Watchlist
+// @Accept json
+// @Produce json
+// @Param request body dto.WatchlistCreateRequest true "Product link and schedule"
+// @Success 201 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/watchlist [post]
+func (c *watchlistController) CreateWatchlist(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	var req dto.WatchlistCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.watchlistService.CreateWatchlist(ctx.Request.Context(), req, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_CREATE_WATCHLIST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_CREATE_WATCHLIST, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// GetWatchlists godoc
+// @Summary Retrieve the user's watchlist.
+// @Description Retrieve the user's watchlist.
+// @Tags Watchlist
+// @Accept json
+// @Produce json
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/watchlist [get]
+func (c *watchlistController) GetWatchlists(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+
+	result, err := c.watchlistService.GetWatchlists(ctx.Request.Context(), userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_WATCHLISTS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_WATCHLISTS, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// GetWatchlist godoc
+// @Summary Retrieve a watchlist item by id.
+// @Description Retrieve a watchlist item by id.
+// @Tags Watchlist
+// @Accept json
+// @Produce json
+// @Param id path string true "Watchlist ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/watchlist/{id} [get]
+func (c *watchlistController) GetWatchlist(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	result, err := c.watchlistService.GetWatchlistById(ctx.Request.Context(), id, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_WATCHLIST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_GET_WATCHLIST, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// UpdateWatchlist godoc
+// @Summary Update a watchlist item.
+// @Description Change the product link or schedule of a watchlist item, or pause and resume it.
+// @Tags Watchlist
+// @Accept json
+// @Produce json
+// @Param id path string true "Watchlist ID"
+// @Param request body dto.WatchlistUpdateRequest true "Fields to change"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/watchlist/{id} [patch]
+func (c *watchlistController) UpdateWatchlist(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	var req dto.WatchlistUpdateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := c.watchlistService.UpdateWatchlist(ctx.Request.Context(), req, id, userId)
+	if err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_UPDATE_WATCHLIST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_UPDATE_WATCHLIST, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// DeleteWatchlist godoc
+// @Summary Remove a product from the watchlist.
+// @Description Remove a product from the watchlist. Stored analyses are kept.
+// @Tags Watchlist
+// @Accept json
+// @Produce json
+// @Param id path string true "Watchlist ID"
+// @Success 200 {object} utils.Response
+// @Failure 400 {object} utils.Response
+// @Security BearerAuth
+// @Router /api/watchlist/{id} [delete]
+func (c *watchlistController) DeleteWatchlist(ctx *gin.Context) {
+	userId := ctx.MustGet("user_id").(string)
+	id := ctx.Param("id")
+
+	if err := c.watchlistService.DeleteWatchlist(ctx.Request.Context(), id, userId); err != nil {
+		res := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DELETE_WATCHLIST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(dto.MESSAGE_SUCCESS_DELETE_WATCHLIST, nil)
+	ctx.JSON(http.StatusOK, res)
+}
@@ -1,27 +1,308 @@
 package database
 
 import (
-	"review_product_tokopedia_be/entity"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
 )
 
+// migrationLockKey is the pg_advisory_lock key held while migrating, so
+// replicas starting at the same time apply each migration only once.
+const migrationLockKey = 727_100_001
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var (
+	ErrMigrationFileName = errors.New("migration file name must look like 000001_name.up.sql or 000001_name.down.sql")
+	ErrMigrationMissing  = errors.New("applied migration has no migration file")
+	ErrMigrationsPending = errors.New("database has pending migrations")
+)
+
+type (
+	// Migration is a numbered pair of up and down SQL scripts.
+	Migration struct {
+		Version int64
+		Name    string
+		Up      string
+		Down    string
+	}
+
+	// MigrationState is a known migration and when it was applied, if ever.
+	MigrationState struct {
+		Version   int64
+		Name      string
+		AppliedAt *time.Time
+	}
+
+	schemaMigration struct {
+		Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+		Name      string    `gorm:"not null"`
+		AppliedAt time.Time `gorm:"not null"`
+	}
+)
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrateUp applies every migration that has not been applied yet, in
+// version order, each in its own transaction.
+func MigrateUp(db *gorm.DB) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(db, func(tx *gorm.DB) error {
+		applied, err := appliedMigrations(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			fmt.Printf("> Applying migration %06d_%s\n", migration.Version, migration.Name)
+			if err := tx.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&schemaMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					AppliedAt: time.Now(),
+				}).Error
+			}); err != nil {
+				return fmt.Errorf("migration %06d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// MigrateDown rolls back the last steps applied migrations, newest first.
+func MigrateDown(db *gorm.DB, steps int) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	byVersion := make(map[int64]Migration, len(migrations))
+	for _, migration := range migrations {
+		byVersion[migration.Version] = migration
+	}
+
+	return withMigrationLock(db, func(tx *gorm.DB) error {
+		var applied []schemaMigration
+		if err := tx.Order("version DESC").Limit(steps).Find(&applied).Error; err != nil {
+			return err
+		}
+
+		for _, record := range applied {
+			migration, ok := byVersion[record.Version]
+			if !ok {
+				return fmt.Errorf("%w: %06d_%s", ErrMigrationMissing, record.Version, record.Name)
+			}
+
+			fmt.Printf("> Reverting migration %06d_%s\n", migration.Version, migration.Name)
+			if err := tx.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&schemaMigration{}, record.Version).Error
+			}); err != nil {
+				return fmt.Errorf("migration %06d_%s: %w", migration.Version, migration.Name, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// MigrationStatus lists every migration file together with when it was
+// applied, followed by applied versions that no longer have a file.
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	err = withMigrationLock(db, func(tx *gorm.DB) error {
+		applied, err := appliedMigrations(tx)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			state := MigrationState{Version: migration.Version, Name: migration.Name}
+			if record, ok := applied[migration.Version]; ok {
+				appliedAt := record.AppliedAt
+				state.AppliedAt = &appliedAt
+				delete(applied, migration.Version)
+			}
+			states = append(states, state)
+		}
+
+		for _, record := range applied {
+			appliedAt := record.AppliedAt
+			states = append(states, MigrationState{Version: record.Version, Name: record.Name, AppliedAt: &appliedAt})
+		}
+		sort.Slice(states, func(i, j int) bool { return states[i].Version < states[j].Version })
+
+		return nil
+	})
+
+	return states, err
+}
+
+// MigrateFresh drops every table the migrations create and applies them
+// all again. It destroys all data and is meant for development only.
 func MigrateFresh(db *gorm.DB) error {
-	// Drop the tables if they exist
-	if err := db.Migrator().DropTable(
-		&entity.History{},
-		&entity.User{},
-	); err != nil {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	err = withMigrationLock(db, func(tx *gorm.DB) error {
+		// Run every down script, applied or not, so tables left behind by
+		// the old AutoMigrate setup are dropped as well.
+		for i := len(migrations) - 1; i >= 0; i-- {
+			if err := tx.Exec(migrations[i].Down).Error; err != nil {
+				return fmt.Errorf("migration %06d_%s: %w", migrations[i].Version, migrations[i].Name, err)
+			}
+		}
+		return tx.Migrator().DropTable(&schemaMigration{})
+	})
+	if err != nil {
+		return err
+	}
+
+	return MigrateUp(db)
+}
+
+// CheckMigrations returns ErrMigrationsPending when an embedded migration
+// has not been applied. It reads schema_migrations without taking the
+// migration lock, so it is cheap enough for the readiness probe.
+func CheckMigrations(db *gorm.DB) error {
+	migrations, err := loadMigrations()
+	if err != nil {
 		return err
 	}
 
-	// Auto-migrate the tables
-	if err := db.AutoMigrate(
-		&entity.User{},
-		&entity.History{},
-	); err != nil {
+	applied, err := appliedMigrations(db)
+	if err != nil {
 		return err
 	}
 
+	pending := 0
+	for _, migration := range migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%w: %d not applied", ErrMigrationsPending, pending)
+	}
 	return nil
 }
+
+// withMigrationLock runs fn on a single connection holding the migration
+// advisory lock, creating the schema_migrations table first if needed.
+func withMigrationLock(db *gorm.DB, fn func(tx *gorm.DB) error) error {
+	return db.Connection(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_lock(?)", migrationLockKey).Error; err != nil {
+			return err
+		}
+		defer tx.Exec("SELECT pg_advisory_unlock(?)", migrationLockKey)
+
+		if err := tx.AutoMigrate(&schemaMigration{}); err != nil {
+			return err
+		}
+
+		return fn(tx)
+	})
+}
+
+func appliedMigrations(tx *gorm.DB) (map[int64]schemaMigration, error) {
+	var records []schemaMigration
+	if err := tx.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]schemaMigration, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// loadMigrations reads the embedded migration files, e.g.
+// migrations/000002_add_index.up.sql and its .down.sql, sorted by version.
+// Every version needs both scripts.
+func loadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+
+		base, direction, ok := strings.Cut(strings.TrimSuffix(name, ".sql"), ".")
+		if !ok || (direction != "up" && direction != "down") {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationFileName, name)
+		}
+		versionPart, migrationName, ok := strings.Cut(base, "_")
+		if !ok || migrationName == "" {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationFileName, name)
+		}
+		version, err := strconv.ParseInt(versionPart, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrMigrationFileName, name)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join("migrations", name))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: migrationName}
+			byVersion[version] = migration
+		} else if migration.Name != migrationName {
+			return nil, fmt.Errorf("%w: %s does not match %06d_%s", ErrMigrationFileName, name, version, migration.Name)
+		}
+
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("%w: %06d_%s needs both an up and a down script", ErrMigrationFileName, migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"review_product_tokopedia_be/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			t.Errorf("migration %d has version %d, want versions numbered from 1 without gaps", i, migration.Version)
+		}
+		if migration.Up == "" || migration.Down == "" {
+			t.Errorf("migration %06d_%s is missing a script", migration.Version, migration.Name)
+		}
+	}
+}
+
+// baselineUser and baselineHistory are the entities of the first release,
+// whose boot built the schema with AutoMigrate.
+type (
+	baselineUser struct {
+		ID       uuid.UUID `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+		Name     string    `gorm:"not null"`
+		Email    string    `gorm:"unique;not null"`
+		Password string    `gorm:"not null"`
+		Role     string    `gorm:"not null"`
+
+		Histories []baselineHistory `gorm:"foreignKey:UserID"`
+
+		entity.Timestamp
+	}
+
+	baselineHistory struct {
+		ID               uuid.UUID    `gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+		URL              string       `gorm:"not null"`
+		ProductID        string       `gorm:"not null"`
+		ProductName      string       `gorm:"not null"`
+		CountPositive    int          `gorm:"not null"`
+		CountNegative    int          `gorm:"not null"`
+		Rating           int          `gorm:"not null"`
+		Ulasan           int          `gorm:"not null"`
+		Bintang          float64      `gorm:"not null"`
+		Packaging        float32      `gorm:"not null"`
+		Delivery         float32      `gorm:"not null"`
+		AdminResponse    float32      `gorm:"not null"`
+		ProductCondition float32      `gorm:"not null"`
+		Summary          string       `gorm:"not null"`
+		UserID           uuid.UUID    `gorm:"not null"`
+		User             baselineUser `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+
+		entity.Timestamp
+	}
+)
+
+func (baselineUser) TableName() string    { return "users" }
+func (baselineHistory) TableName() string { return "histories" }
+
+// openTestDatabase connects to a schema of its own on the E2E_DATABASE_DSN
+// server and drops it again when the test ends.
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("E2E_DATABASE_DSN")
+	if dsn == "" {
+		// CI provides a database, so a missing DSN there is a broken setup
+		// rather than a reason to skip.
+		if os.Getenv("CI") != "" {
+			t.Fatal("E2E_DATABASE_DSN is not set")
+		}
+		t.Skip("E2E_DATABASE_DSN is not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to %s: %v", dsn, err)
+	}
+
+	schema := fmt.Sprintf("migrate_%d", time.Now().UnixNano())
+	if err := admin.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatalf("create uuid-ossp: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema+",public"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to schema %s: %v", schema, err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+func TestMigrateUpAdoptsBaselineSchema(t *testing.T) {
+	db := openTestDatabase(t)
+
+	if err := db.AutoMigrate(&baselineUser{}, &baselineHistory{}); err != nil {
+		t.Fatalf("build baseline schema: %v", err)
+	}
+
+	user := baselineUser{Name: "Pengguna", Email: "pengguna@example.com", Password: "hash", Role: "user"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	start := time.Now().Add(-time.Hour)
+	for i, history := range []baselineHistory{
+		{URL: "https://www.tokopedia.com/toko/lama", ProductID: "1", ProductName: "Nama Lama"},
+		{URL: "https://www.tokopedia.com/toko/baru", ProductID: "1", ProductName: "Nama Baru"},
+		{URL: "https://www.tokopedia.com/toko/lain", ProductID: "2", ProductName: "Produk Lain"},
+	} {
+		history.UserID = user.ID
+		history.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+		if err := db.Create(&history).Error; err != nil {
+			t.Fatalf("create history: %v", err)
+		}
+	}
+
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if err := CheckMigrations(db); err != nil {
+		t.Fatalf("check migrations: %v", err)
+	}
+
+	var histories []entity.History
+	if err := db.Find(&histories).Error; err != nil {
+		t.Fatalf("read histories: %v", err)
+	}
+	for _, history := range histories {
+		if history.Provider != "tokopedia" || history.TrackedProductID == uuid.Nil {
+			t.Errorf("history %s not backfilled: provider %q, tracked product %s", history.ProductName, history.Provider, history.TrackedProductID)
+		}
+	}
+
+	var tracked []entity.TrackedProduct
+	if err := db.Order("product_id").Find(&tracked).Error; err != nil {
+		t.Fatalf("read tracked products: %v", err)
+	}
+	if len(tracked) != 2 || tracked[0].ProductName != "Nama Baru" {
+		t.Fatalf("tracked products = %+v, want one per product named after the latest history", tracked)
+	}
+
+	// The upgraded schema takes writes from the current entities
+	history := entity.History{
+		URL:              tracked[1].URL,
+		ProductID:        tracked[1].ProductID,
+		ProductName:      tracked[1].ProductName,
+		UserID:           user.ID,
+		TrackedProductID: tracked[1].ID,
+	}
+	if err := db.Create(&history).Error; err != nil {
+		t.Fatalf("create history on the upgraded schema: %v", err)
+	}
+
+	// The adoption can be reverted and applied again
+	if err := MigrateDown(db, 1); err != nil {
+		t.Fatalf("migrate down: %v", err)
+	}
+	if err := MigrateUp(db); err != nil {
+		t.Fatalf("migrate up again: %v", err)
+	}
+}
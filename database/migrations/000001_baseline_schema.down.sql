@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS histories;
+DROP TABLE IF EXISTS users;
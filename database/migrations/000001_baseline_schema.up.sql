@@ -0,0 +1,40 @@
+-- The schema the first release created with AutoMigrate on boot. Every
+-- statement is guarded so databases built by that release adopt it as is;
+-- 000002 brings them up to date.
+
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS users (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name text NOT NULL,
+    email text NOT NULL,
+    password text NOT NULL,
+    role text NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS histories (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    url text NOT NULL,
+    product_id text NOT NULL,
+    product_name text NOT NULL,
+    count_positive bigint NOT NULL,
+    count_negative bigint NOT NULL,
+    rating bigint NOT NULL,
+    ulasan bigint NOT NULL,
+    bintang double precision NOT NULL,
+    packaging real NOT NULL,
+    delivery real NOT NULL,
+    admin_response real NOT NULL,
+    product_condition real NOT NULL,
+    summary text NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_histories_deleted_at ON histories (deleted_at);
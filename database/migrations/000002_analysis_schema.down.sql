@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS comparison_items;
+DROP TABLE IF EXISTS comparisons;
+DROP TABLE IF EXISTS alert_deliveries;
+DROP TABLE IF EXISTS alert_rules;
+DROP TABLE IF EXISTS review_sentiments;
+DROP TABLE IF EXISTS reviews;
+DROP TABLE IF EXISTS analysis_jobs;
+DROP TABLE IF EXISTS watchlists;
+ALTER TABLE IF EXISTS histories DROP COLUMN IF EXISTS tracked_product_id;
+ALTER TABLE IF EXISTS histories DROP COLUMN IF EXISTS provider;
+DROP TABLE IF EXISTS tracked_products;
@@ -0,0 +1,199 @@
+-- Tables added since the first release, and the columns histories gained.
+-- Every statement is guarded so databases whose schema a later release
+-- built with AutoMigrate adopt it as is.
+
+CREATE TABLE IF NOT EXISTS tracked_products (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    provider text NOT NULL,
+    product_id text NOT NULL,
+    url text NOT NULL,
+    product_name text NOT NULL,
+    last_analysed_at timestamptz,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tracked_products_user_product ON tracked_products (provider, product_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_tracked_products_deleted_at ON tracked_products (deleted_at);
+
+-- Histories written before tracked products existed are all Tokopedia
+-- analyses. Each (user, product) pair gets the tracked product its
+-- histories are grouped under, named after its latest analysis.
+ALTER TABLE histories ADD COLUMN IF NOT EXISTS provider text;
+UPDATE histories SET provider = 'tokopedia' WHERE provider IS NULL;
+ALTER TABLE histories ALTER COLUMN provider SET DEFAULT 'tokopedia';
+ALTER TABLE histories ALTER COLUMN provider SET NOT NULL;
+
+ALTER TABLE histories ADD COLUMN IF NOT EXISTS tracked_product_id uuid;
+INSERT INTO tracked_products (provider, product_id, url, product_name, last_analysed_at, user_id, created_at, updated_at)
+SELECT DISTINCT ON (provider, product_id, user_id)
+    provider, product_id, url, product_name, created_at, user_id, created_at, created_at
+FROM histories
+WHERE tracked_product_id IS NULL
+ORDER BY provider, product_id, user_id, created_at DESC
+ON CONFLICT (provider, product_id, user_id) DO NOTHING;
+UPDATE histories
+SET tracked_product_id = tracked_products.id
+FROM tracked_products
+WHERE histories.tracked_product_id IS NULL
+    AND tracked_products.provider = histories.provider
+    AND tracked_products.product_id = histories.product_id
+    AND tracked_products.user_id = histories.user_id;
+ALTER TABLE histories ALTER COLUMN tracked_product_id SET NOT NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint
+        WHERE conrelid = 'histories'::regclass AND conname = 'fk_histories_tracked_product') THEN
+        ALTER TABLE histories ADD CONSTRAINT fk_histories_tracked_product
+            FOREIGN KEY (tracked_product_id) REFERENCES tracked_products (id) ON DELETE CASCADE;
+    END IF;
+END
+$$;
+CREATE INDEX IF NOT EXISTS idx_histories_tracked_product_id ON histories (tracked_product_id);
+
+CREATE TABLE IF NOT EXISTS watchlists (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_url text NOT NULL,
+    schedule text NOT NULL,
+    active boolean NOT NULL DEFAULT true,
+    next_run_at timestamptz NOT NULL,
+    last_run_at timestamptz,
+    last_job_id uuid,
+    last_error text,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_watchlists_next_run_at ON watchlists (next_run_at);
+CREATE INDEX IF NOT EXISTS idx_watchlists_user_id ON watchlists (user_id);
+CREATE INDEX IF NOT EXISTS idx_watchlists_deleted_at ON watchlists (deleted_at);
+
+CREATE TABLE IF NOT EXISTS analysis_jobs (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind text NOT NULL DEFAULT 'product',
+    product_url text NOT NULL,
+    review_options text,
+    status text NOT NULL,
+    product_status text NOT NULL,
+    reviews_status text NOT NULL,
+    predict_status text NOT NULL,
+    analyze_status text NOT NULL,
+    summarize_status text NOT NULL,
+    error text,
+    result text,
+    attempts bigint NOT NULL DEFAULT 0,
+    started_at timestamptz,
+    finished_at timestamptz,
+    history_id uuid,
+    watchlist_id uuid,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_status ON analysis_jobs (status);
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_watchlist_id ON analysis_jobs (watchlist_id);
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_deleted_at ON analysis_jobs (deleted_at);
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    provider text NOT NULL,
+    product_id text NOT NULL,
+    external_id text NOT NULL,
+    message text NOT NULL,
+    rating bigint NOT NULL,
+    variant text,
+    has_media boolean NOT NULL DEFAULT false,
+    reviewer text,
+    reviewed_at timestamptz,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_source ON reviews (provider, product_id, external_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_reviewed_at ON reviews (reviewed_at);
+CREATE INDEX IF NOT EXISTS idx_reviews_deleted_at ON reviews (deleted_at);
+-- Full-text index backing the history review search
+CREATE INDEX IF NOT EXISTS idx_reviews_message_fts ON reviews USING GIN (to_tsvector('simple', message));
+
+CREATE TABLE IF NOT EXISTS review_sentiments (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    label text,
+    confidence real,
+    aspect text,
+    aspect_sentiment text,
+    model_version text,
+    review_id uuid NOT NULL REFERENCES reviews (id) ON DELETE CASCADE,
+    history_id uuid NOT NULL REFERENCES histories (id) ON DELETE CASCADE,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_review_sentiments_history_review ON review_sentiments (review_id, history_id);
+CREATE INDEX IF NOT EXISTS idx_review_sentiments_label ON review_sentiments (label);
+CREATE INDEX IF NOT EXISTS idx_review_sentiments_aspect ON review_sentiments (aspect);
+CREATE INDEX IF NOT EXISTS idx_review_sentiments_deleted_at ON review_sentiments (deleted_at);
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name text NOT NULL,
+    provider text,
+    product_id text,
+    metric text NOT NULL,
+    condition text NOT NULL,
+    threshold double precision NOT NULL,
+    channel text NOT NULL,
+    target text NOT NULL,
+    secret text,
+    active boolean NOT NULL DEFAULT true,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_product_id ON alert_rules (product_id);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_user_id ON alert_rules (user_id);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_deleted_at ON alert_rules (deleted_at);
+
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    channel text NOT NULL,
+    target text NOT NULL,
+    payload text NOT NULL,
+    status text NOT NULL,
+    attempts bigint NOT NULL DEFAULT 0,
+    last_error text,
+    next_attempt_at timestamptz NOT NULL,
+    delivered_at timestamptz,
+    alert_rule_id uuid NOT NULL REFERENCES alert_rules (id) ON DELETE CASCADE,
+    history_id uuid NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_status ON alert_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_next_attempt_at ON alert_deliveries (next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_alert_rule_id ON alert_deliveries (alert_rule_id);
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_deleted_at ON alert_deliveries (deleted_at);
+
+CREATE TABLE IF NOT EXISTS comparisons (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    verdict text NOT NULL,
+    user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_comparisons_user_id ON comparisons (user_id);
+CREATE INDEX IF NOT EXISTS idx_comparisons_deleted_at ON comparisons (deleted_at);
+
+CREATE TABLE IF NOT EXISTS comparison_items (
+    id uuid PRIMARY KEY DEFAULT uuid_generate_v4(),
+    position bigint NOT NULL,
+    comparison_id uuid NOT NULL REFERENCES comparisons (id) ON DELETE CASCADE,
+    history_id uuid NOT NULL REFERENCES histories (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_comparison_items_comparison_id ON comparison_items (comparison_id);
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS histories DROP COLUMN IF EXISTS review_options;
@@ -0,0 +1,3 @@
+-- The review options a snapshot was analysed with. Incremental re-analysis
+-- only extends a snapshot taken with the same options.
+ALTER TABLE histories ADD COLUMN IF NOT EXISTS review_options text;
@@ -0,0 +1 @@
+ALTER TABLE IF EXISTS analysis_jobs DROP COLUMN IF EXISTS heartbeat_at;
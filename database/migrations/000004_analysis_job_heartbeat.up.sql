@@ -0,0 +1,4 @@
+-- Running workers refresh heartbeat_at, so only jobs whose worker stopped
+-- reporting are requeued.
+ALTER TABLE analysis_jobs ADD COLUMN IF NOT EXISTS heartbeat_at timestamptz;
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_heartbeat_at ON analysis_jobs (heartbeat_at);
@@ -22,8 +22,9 @@ func UserSeeder(db *gorm.DB) error {
 		},
 	}
 
+	// Seeding runs on every development boot, so existing users are kept.
 	for _, user := range userSeed {
-		if err := db.Create(&user).Error; err != nil {
+		if err := db.Where(entity.User{Email: user.Email}).FirstOrCreate(&user).Error; err != nil {
 			return err
 		}
 	}
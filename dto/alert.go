@@ -0,0 +1,118 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_CREATE_ALERT_RULE    = "failed create alert rule"
+	MESSAGE_FAILED_GET_ALERT_RULES      = "failed get alert rules"
+	MESSAGE_FAILED_GET_ALERT_RULE       = "failed get alert rule"
+	MESSAGE_FAILED_UPDATE_ALERT_RULE    = "failed update alert rule"
+	MESSAGE_FAILED_DELETE_ALERT_RULE    = "failed delete alert rule"
+	MESSAGE_FAILED_GET_ALERT_DELIVERIES = "failed get alert deliveries"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_ALERT_RULE    = "success create alert rule"
+	MESSAGE_SUCCESS_GET_ALERT_RULES      = "success get alert rules"
+	MESSAGE_SUCCESS_GET_ALERT_RULE       = "success get alert rule"
+	MESSAGE_SUCCESS_UPDATE_ALERT_RULE    = "success update alert rule"
+	MESSAGE_SUCCESS_DELETE_ALERT_RULE    = "success delete alert rule"
+	MESSAGE_SUCCESS_GET_ALERT_DELIVERIES = "success get alert deliveries"
+)
+
+var (
+	ErrCreateAlertRule      = errors.New("failed to create alert rule")
+	ErrGetAlertRules        = errors.New("failed to get alert rules")
+	ErrGetAlertRule         = errors.New("failed to get alert rule")
+	ErrUpdateAlertRule      = errors.New("failed to update alert rule")
+	ErrDeleteAlertRule      = errors.New("failed to delete alert rule")
+	ErrGetAlertDeliveries   = errors.New("failed to get alert deliveries")
+	ErrCreateAlertDelivery  = errors.New("failed to queue alert delivery")
+	ErrInvalidAlertMetric   = errors.New("invalid metric, use negative_share, positive_share, bintang, packaging, delivery, admin_response or product_condition")
+	ErrInvalidAlertCond     = errors.New("invalid condition, use above, below, rises_by or drops_by")
+	ErrInvalidAlertChannel  = errors.New("invalid channel, use webhook or email")
+	ErrInvalidWebhookTarget = errors.New("webhook target must be an http or https url")
+	ErrWebhookTargetPrivate = errors.New("webhook target must be a public address")
+	ErrInvalidEmailTarget   = errors.New("email target must be a valid email address")
+	ErrAlertProductScope    = errors.New("provider and product_id must be set together")
+	ErrSMTPNotConfigured    = errors.New("smtp is not configured, set SMTP_HOST and SMTP_FROM")
+	ErrWebhookStatus        = errors.New("webhook responded with a non-2xx status")
+)
+
+type (
+	AlertRuleCreateRequest struct {
+		Name      string  `json:"name" form:"name" binding:"required"`
+		Provider  string  `json:"provider" form:"provider"`
+		ProductID string  `json:"product_id" form:"product_id"`
+		Metric    string  `json:"metric" form:"metric" binding:"required"`
+		Condition string  `json:"condition" form:"condition" binding:"required"`
+		Threshold float64 `json:"threshold" form:"threshold"`
+		Channel   string  `json:"channel" form:"channel" binding:"required"`
+		Target    string  `json:"target" form:"target" binding:"required"`
+		Active    *bool   `json:"active" form:"active"`
+	}
+
+	AlertRuleUpdateRequest struct {
+		Name      *string  `json:"name" form:"name"`
+		Metric    *string  `json:"metric" form:"metric"`
+		Condition *string  `json:"condition" form:"condition"`
+		Threshold *float64 `json:"threshold" form:"threshold"`
+		Target    *string  `json:"target" form:"target"`
+		Active    *bool    `json:"active" form:"active"`
+	}
+
+	AlertRuleResponse struct {
+		ID        uuid.UUID `json:"id"`
+		Name      string    `json:"name"`
+		Provider  string    `json:"provider"`
+		ProductID string    `json:"product_id"`
+		Metric    string    `json:"metric"`
+		Condition string    `json:"condition"`
+		Threshold float64   `json:"threshold"`
+		Channel   string    `json:"channel"`
+		Target    string    `json:"target"`
+		Secret    string    `json:"secret,omitempty"`
+		Active    bool      `json:"active"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	AlertDeliveryResponse struct {
+		ID            uuid.UUID  `json:"id"`
+		HistoryID     uuid.UUID  `json:"history_id"`
+		Channel       string     `json:"channel"`
+		Target        string     `json:"target"`
+		Status        string     `json:"status"`
+		Attempts      int        `json:"attempts"`
+		LastError     string     `json:"last_error"`
+		NextAttemptAt time.Time  `json:"next_attempt_at"`
+		DeliveredAt   *time.Time `json:"delivered_at"`
+		CreatedAt     time.Time  `json:"created_at"`
+	}
+
+	AlertProduct struct {
+		Provider    string `json:"provider"`
+		ProductID   string `json:"product_id"`
+		ProductName string `json:"product_name"`
+		URL         string `json:"url"`
+	}
+
+	// AlertPayload is the body of a webhook delivery and the content of an
+	// email one. Previous is nil when the rule fired on the first analysis.
+	AlertPayload struct {
+		RuleID      uuid.UUID    `json:"rule_id"`
+		RuleName    string       `json:"rule_name"`
+		Metric      string       `json:"metric"`
+		Condition   string       `json:"condition"`
+		Threshold   float64      `json:"threshold"`
+		Previous    *float64     `json:"previous"`
+		Current     float64      `json:"current"`
+		HistoryID   uuid.UUID    `json:"history_id"`
+		Product     AlertProduct `json:"product"`
+		TriggeredAt time.Time    `json:"triggered_at"`
+	}
+)
@@ -0,0 +1,109 @@
+package dto
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Analysis stages
+	ANALYSIS_STAGE_URL         = "url"
+	ANALYSIS_STAGE_PRODUCT     = "product"
+	ANALYSIS_STAGE_REVIEWS     = "reviews"
+	ANALYSIS_STAGE_SHOP_AVATAR = "shop_avatar"
+	ANALYSIS_STAGE_PREDICT     = "predict"
+	ANALYSIS_STAGE_ANALYZE     = "analyze"
+	ANALYSIS_STAGE_SUMMARIZE   = "summarize"
+	ANALYSIS_STAGE_HISTORY     = "history"
+	ANALYSIS_STAGE_COMPARE     = "compare"
+
+	// Terminal stream events
+	ANALYSIS_EVENT_RESULT = "result"
+	ANALYSIS_EVENT_ERROR  = "error"
+)
+
+var (
+	ErrInvalidUserId = errors.New("invalid user id format")
+)
+
+type (
+	AnalysisOptions struct {
+		// UserID of the requester. When empty the analysis runs as guest and
+		// no history is stored.
+		UserID string
+
+		// Reviews selects which reviews are harvested for the analysis.
+		Reviews ReviewOptions
+
+		// StreamSummary generates the summary incrementally, reporting each
+		// chunk through OnEvent as it arrives.
+		StreamSummary bool
+		// Refresh skips cached results and recomputes every step.
+		Refresh bool
+
+		// OnEvent, when set, is called as each stage starts, produces partial
+		// data and finishes. Stages after reviews run concurrently, so it must
+		// be safe to call from several goroutines.
+		OnEvent func(event AnalysisEvent)
+	}
+
+	AnalysisEvent struct {
+		Stage  string `json:"stage"`
+		Status string `json:"status"`
+		Data   any    `json:"data,omitempty"`
+	}
+
+	AnalysisProductData struct {
+		ProductID          string   `json:"product_id"`
+		ProductName        string   `json:"product_name"`
+		ProductDescription string   `json:"product_description"`
+		ShopName           string   `json:"shop_name"`
+		ImageUrls          []string `json:"image_urls"`
+	}
+
+	AnalysisReviewsData struct {
+		Rating     int     `json:"rating"`
+		Bintang    float64 `json:"bintang"`
+		NewReviews int     `json:"new_reviews"`
+	}
+
+	AnalysisAspectData struct {
+		Aspect string  `json:"aspect"`
+		Score  float32 `json:"score"`
+	}
+
+	AnalysisSummaryData struct {
+		Text string `json:"text"`
+	}
+
+	AnalysisErrorData struct {
+		Stage   string `json:"stage"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+
+	AnalysisResult struct {
+		Provider   string
+		ProductID  string
+		ProductURL string
+		HistoryID  uuid.UUID
+		Result     MLResult
+	}
+
+	// AnalysisError wraps an error raised by one of the analysis stages
+	// together with the response message the handlers should report.
+	AnalysisError struct {
+		Stage   string
+		Message string
+		Err     error
+	}
+)
+
+func (e *AnalysisError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *AnalysisError) Unwrap() error {
+	return e.Err
+}
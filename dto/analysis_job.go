@@ -0,0 +1,57 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_CREATE_ANALYSIS_JOB = "failed create analysis job"
+	MESSAGE_FAILED_GET_ANALYSIS_JOB    = "failed get analysis job"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_ANALYSIS_JOB = "success create analysis job"
+	MESSAGE_SUCCESS_GET_ANALYSIS_JOB    = "success get analysis job"
+)
+
+var (
+	ErrCreateAnalysisJob = errors.New("failed to create analysis job")
+	ErrGetAnalysisJob    = errors.New("failed to get analysis job")
+)
+
+type (
+	AnalysisJobCreateRequest struct {
+		ProductUrl string        `json:"product_url" form:"product_url" binding:"required"`
+		Reviews    ReviewOptions `json:"reviews"`
+
+		// WatchlistID is set when the scheduler enqueues the job.
+		WatchlistID *uuid.UUID `json:"-"`
+	}
+
+	AnalysisJobStages struct {
+		Product   string `json:"product"`
+		Reviews   string `json:"reviews"`
+		Predict   string `json:"predict"`
+		Analyze   string `json:"analyze"`
+		Summarize string `json:"summarize"`
+	}
+
+	AnalysisJobResponse struct {
+		ID         uuid.UUID           `json:"id"`
+		Kind       string              `json:"kind"`
+		ProductURL string              `json:"product_url"`
+		Status     string              `json:"status"`
+		Stages     AnalysisJobStages   `json:"stages"`
+		Error      string              `json:"error,omitempty"`
+		Attempts   int                 `json:"attempts"`
+		HistoryID  *uuid.UUID          `json:"history_id,omitempty"`
+		Result     *MLResult           `json:"result,omitempty"`
+		ShopResult *ShopAnalysisResult `json:"shop_result,omitempty"`
+		CreatedAt  time.Time           `json:"created_at"`
+		StartedAt  *time.Time          `json:"started_at,omitempty"`
+		FinishedAt *time.Time          `json:"finished_at,omitempty"`
+	}
+)
@@ -0,0 +1,13 @@
+package dto
+
+import "errors"
+
+var (
+	ErrUnsupportedCache = errors.New("unsupported cache backend")
+)
+
+// CacheStatus reports which steps of an analysis were served from cache.
+type CacheStatus struct {
+	Hit    bool            `json:"hit"`
+	Stages map[string]bool `json:"stages"`
+}
@@ -9,3 +9,10 @@ var (
 	ErrParseJson            = errors.New("failed to parse response json")
 	ErrNotOk                = errors.New("received non-200 response code")
 )
+
+type PaginationMeta struct {
+	Page  int   `json:"page"`
+	Limit int   `json:"limit"`
+	Total int64 `json:"total"`
+	Pages int   `json:"pages"`
+}
@@ -0,0 +1,79 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_COMPARE_PRODUCTS  = "failed compare products"
+	MESSAGE_FAILED_CREATE_COMPARISON = "failed create comparison"
+	MESSAGE_FAILED_GET_COMPARISONS   = "failed get comparisons"
+	MESSAGE_FAILED_GET_COMPARISON    = "failed get comparison"
+
+	// Success
+	MESSAGE_SUCCESS_COMPARE_PRODUCTS = "success compare products"
+	MESSAGE_SUCCESS_GET_COMPARISONS  = "success get comparisons"
+	MESSAGE_SUCCESS_GET_COMPARISON   = "success get comparison"
+
+	// Comparison table columns
+	COMPARE_METRIC_BINTANG           = "bintang"
+	COMPARE_METRIC_POSITIVE_RATIO    = "positive_ratio"
+	COMPARE_METRIC_NEGATIVE_RATIO    = "negative_ratio"
+	COMPARE_METRIC_PACKAGING         = "packaging"
+	COMPARE_METRIC_DELIVERY          = "delivery"
+	COMPARE_METRIC_ADMIN_RESPONSE    = "admin_response"
+	COMPARE_METRIC_PRODUCT_CONDITION = "product_condition"
+)
+
+var (
+	ErrCompareProductCount = errors.New("compare needs between 2 and 5 product urls")
+	ErrCompareDuplicateUrl = errors.New("each product url can only be compared once")
+	ErrCreateComparison    = errors.New("failed to create comparison")
+	ErrGetComparisons      = errors.New("failed to get comparisons")
+	ErrGetComparison       = errors.New("failed to get comparison")
+)
+
+type (
+	ComparisonCreateRequest struct {
+		ProductUrls []string `json:"product_urls" form:"product_urls" binding:"required"`
+		// Save stores the comparison in the user's history.
+		Save bool `json:"save" form:"save"`
+	}
+
+	// ComparisonProduct is one row of the comparison table. Ratios are the
+	// share of labelled reviews that are positive or negative, in percent.
+	ComparisonProduct struct {
+		Position         int        `json:"position"`
+		HistoryID        *uuid.UUID `json:"history_id,omitempty"`
+		Provider         string     `json:"provider"`
+		ProductID        string     `json:"product_id"`
+		ProductURL       string     `json:"product_url"`
+		ProductName      string     `json:"product_name"`
+		Rating           int        `json:"rating"`
+		Ulasan           int        `json:"ulasan"`
+		Bintang          float64    `json:"bintang"`
+		CountPositive    int        `json:"count_positive"`
+		CountNegative    int        `json:"count_negative"`
+		PositiveRatio    float64    `json:"positive_ratio"`
+		NegativeRatio    float64    `json:"negative_ratio"`
+		Packaging        float32    `json:"packaging"`
+		Delivery         float32    `json:"delivery"`
+		AdminResponse    float32    `json:"admin_response"`
+		ProductCondition float32    `json:"product_condition"`
+	}
+
+	ComparisonResponse struct {
+		// ID is only set once the comparison is saved.
+		ID       *uuid.UUID          `json:"id"`
+		Products []ComparisonProduct `json:"products"`
+		// Winners maps every table column to the positions of the products
+		// that score best on it; ties list every product sharing the top.
+		Winners   map[string][]int `json:"winners"`
+		Verdict   string           `json:"verdict"`
+		CreatedAt *time.Time       `json:"created_at,omitempty"`
+	}
+)
@@ -1,6 +1,12 @@
 package dto
 
 const (
+	// Aspects
+	ASPECT_PACKAGING         = "packaging"
+	ASPECT_DELIVERY          = "delivery"
+	ASPECT_ADMIN_RESPONSE    = "admin_response"
+	ASPECT_PRODUCT_CONDITION = "product_condition"
+
 	// Failed
 	MESSAGE_FAILED_ANALYZE = "failed analyze"
 
@@ -8,13 +14,28 @@ const (
 // MESSAGE_SUCCESS_PREDICT = "success predict"
 )
 
-var (
-// ErrMarshallJson = errors.New("failed to marshall request body json")
-)
+type (
+	// AnalyzeReview is a single review sent for aspect classification. ID
+	// must be stable so the returned label can be matched back to it.
+	AnalyzeReview struct {
+		ID      string `json:"id"`
+		Message string `json:"text"`
+	}
 
-type AnalyzeResponse struct {
-	Packaging        float32 `json:"packaging"`
-	Delivery         float32 `json:"delivery"`
-	AdminResponse    float32 `json:"admin_response"`
-	ProductCondition float32 `json:"product_condition"`
-}
+	// ReviewAspectLabel is the aspect a review talks about and the sentiment
+	// it expresses towards it. Aspect is empty when the review does not
+	// mention any of the tracked aspects.
+	ReviewAspectLabel struct {
+		ID        string `json:"id"`
+		Aspect    string `json:"aspect"`
+		Sentiment string `json:"sentiment"`
+	}
+
+	AnalyzeResponse struct {
+		Packaging        float32             `json:"packaging"`
+		Delivery         float32             `json:"delivery"`
+		AdminResponse    float32             `json:"admin_response"`
+		ProductCondition float32             `json:"product_condition"`
+		Labels           []ReviewAspectLabel `json:"labels"`
+	}
+)
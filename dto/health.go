@@ -0,0 +1,39 @@
+package dto
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_NOT_READY = "service not ready"
+
+	// Success
+	MESSAGE_SUCCESS_ALIVE      = "service alive"
+	MESSAGE_SUCCESS_READY      = "service ready"
+	MESSAGE_SUCCESS_GET_STATUS = "success get status"
+)
+
+var (
+	ErrNotReady = errors.New("service is starting or shutting down")
+)
+
+type (
+	StatusResponse struct {
+		Ready        bool               `json:"ready"`
+		ReadyError   string             `json:"ready_error,omitempty"`
+		Dependencies []DependencyStatus `json:"dependencies"`
+	}
+
+	DependencyStatus struct {
+		Name      string `json:"name"`
+		Healthy   bool   `json:"healthy"`
+		LatencyMs int64  `json:"latency_ms"`
+		// LastError is kept after the dependency recovers, so a flapping
+		// dependency still shows up.
+		LastError   string     `json:"last_error,omitempty"`
+		LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+		CheckedAt   time.Time  `json:"checked_at"`
+	}
+)
@@ -2,6 +2,8 @@ package dto
 
 import (
 	"errors"
+	"time"
+
 	"review_product_tokopedia_be/entity"
 
 	"github.com/google/uuid"
@@ -9,21 +11,36 @@ import (
 
 const (
 	// Failed
-	MESSAGE_FAILED_CREATE_HISTORY = "failed create history"
-	MESSAGE_FAILED_GET_HISTORIES  = "failed get histories"
-	MESSAGE_FAILED_GET_HISTORY    = "failed get history"
+	MESSAGE_FAILED_CREATE_HISTORY         = "failed create history"
+	MESSAGE_FAILED_GET_HISTORIES          = "failed get histories"
+	MESSAGE_FAILED_GET_HISTORY            = "failed get history"
+	MESSAGE_FAILED_GET_REVIEWS_OF_HISTORY = "failed get history reviews"
+	MESSAGE_FAILED_GET_SNAPSHOTS          = "failed get snapshots"
+	MESSAGE_FAILED_GET_SNAPSHOT_DIFF      = "failed get snapshot diff"
 
 	// Success
-	MESSAGE_SUCCESS_CREATE_HISTORY = "success create history"
-	MESSAGE_SUCCESS_GET_HISTORIES  = "success get histories"
-	MESSAGE_SUCCESS_GET_HISTORY    = "success get history"
+	MESSAGE_SUCCESS_CREATE_HISTORY         = "success create history"
+	MESSAGE_SUCCESS_GET_HISTORIES          = "success get histories"
+	MESSAGE_SUCCESS_GET_HISTORY            = "success get history"
+	MESSAGE_SUCCESS_GET_REVIEWS_OF_HISTORY = "success get history reviews"
+	MESSAGE_SUCCESS_GET_SNAPSHOTS          = "success get snapshots"
+	MESSAGE_SUCCESS_GET_SNAPSHOT_DIFF      = "success get snapshot diff"
 )
 
 var (
-	ErrCreateHistory = errors.New("failed to create history")
-	ErrDeleteHistory = errors.New("failed to delete history")
-	ErrGetHistories  = errors.New("failed to get histories")
-	ErrGetHistory    = errors.New("failed to get history")
+	ErrCreateHistory     = errors.New("failed to create history")
+	ErrDeleteHistory     = errors.New("failed to delete history")
+	ErrGetHistories      = errors.New("failed to get histories")
+	ErrGetHistory        = errors.New("failed to get history")
+	ErrGetHistoryReviews = errors.New("failed to get history reviews")
+	ErrInvalidSentiment  = errors.New("invalid sentiment, use positive, negative or neutral")
+	ErrInvalidAspect     = errors.New("invalid aspect, use packaging, delivery, admin_response or product_condition")
+	ErrInvalidPagination = errors.New("page must be at least 1 and limit between 1 and 100")
+	ErrTrackProduct      = errors.New("failed to track product")
+	ErrProductNotTracked = errors.New("product has not been analysed yet")
+	ErrGetSnapshots      = errors.New("failed to get snapshots")
+	ErrSnapshotNotFound  = errors.New("snapshot not found for this product")
+	ErrNotEnoughSnapshot = errors.New("at least two snapshots are needed for a diff")
 )
 
 type (
@@ -33,6 +50,34 @@ type (
 		ProductName string `json:"product_name"`
 	}
 
+	HistoryReviewsGetRequest struct {
+		Page      int    `json:"page"`
+		Limit     int    `json:"limit"`
+		Sentiment string `json:"sentiment"`
+		Aspect    string `json:"aspect"`
+		Query     string `json:"q"`
+	}
+
+	HistoryReviewResponse struct {
+		ID              uuid.UUID `json:"id"`
+		ExternalID      string    `json:"external_id"`
+		Message         string    `json:"message"`
+		Rating          int       `json:"rating"`
+		Variant         string    `json:"variant"`
+		HasMedia        bool      `json:"has_media"`
+		Reviewer        string    `json:"reviewer"`
+		ReviewedAt      time.Time `json:"reviewed_at"`
+		Label           string    `json:"label"`
+		Confidence      *float32  `json:"confidence"`
+		Aspect          string    `json:"aspect"`
+		AspectSentiment string    `json:"aspect_sentiment"`
+	}
+
+	HistoryReviewsResponse struct {
+		Reviews []HistoryReviewResponse
+		Meta    PaginationMeta
+	}
+
 	HistoriesResponse struct {
 		Histories []entity.History `json:"histories"`
 		Page      int              `json:"page"`
@@ -42,25 +87,31 @@ type (
 	}
 
 	HistoryCreateRequest struct {
-		UserID           uuid.UUID `json:"user_id" form:"user_id" binding:"required"`
-		ProductID        string    `json:"product_id" form:"product_id" binding:"required"`
-		URL              string    `json:"url" form:"url" binding:"required"`
-		Rating           int       `json:"rating" form:"rating" binding:"required"`
-		Ulasan           int       `json:"ulasan" form:"ulasan" binding:"required"`
-		Bintang          float64   `json:"bintang" form:"bintang" binding:"required"`
-		ProductName      string    `json:"product_name" form:"product_name" binding:"required"`
-		CountPositive    int       `json:"count_positive" form:"count_positive" binding:"required"`
-		CountNegative    int       `json:"count_negative" form:"count_negative" binding:"required"`
-		Packaging        float32   `json:"packaging"  form:"packaging" binding:"required"`
-		Delivery         float32   `json:"delivery" form:"delivery" binding:"required"`
-		AdminResponse    float32   `json:"admin_response" form:"admin_response" binding:"required"`
-		ProductCondition float32   `json:"product_condition" form:"product_condition" binding:"required"`
-		Summary          string    `json:"summary" form:"content"`
+		UserID           uuid.UUID     `json:"user_id" form:"user_id" binding:"required"`
+		Provider         string        `json:"provider" form:"provider" binding:"required"`
+		ProductID        string        `json:"product_id" form:"product_id" binding:"required"`
+		URL              string        `json:"url" form:"url" binding:"required"`
+		Rating           int           `json:"rating" form:"rating" binding:"required"`
+		Ulasan           int           `json:"ulasan" form:"ulasan" binding:"required"`
+		Bintang          float64       `json:"bintang" form:"bintang" binding:"required"`
+		ProductName      string        `json:"product_name" form:"product_name" binding:"required"`
+		CountPositive    int           `json:"count_positive" form:"count_positive" binding:"required"`
+		CountNegative    int           `json:"count_negative" form:"count_negative" binding:"required"`
+		Packaging        float32       `json:"packaging"  form:"packaging" binding:"required"`
+		Delivery         float32       `json:"delivery" form:"delivery" binding:"required"`
+		AdminResponse    float32       `json:"admin_response" form:"admin_response" binding:"required"`
+		ProductCondition float32       `json:"product_condition" form:"product_condition" binding:"required"`
+		Summary          string        `json:"summary" form:"content"`
+		ReviewOptions    ReviewOptions `json:"review_options"`
+		// Reviews are stored together with the history. Their HistoryID is
+		// filled in once the history exists.
+		Reviews ReviewsSaveRequest `json:"-"`
 	}
 
 	HistoryResponse struct {
 		ID               uuid.UUID `json:"id"`
 		UserID           uuid.UUID `json:"user_id"`
+		Provider         string    `json:"provider"`
 		ProductID        string    `json:"product_id"`
 		URL              string    `json:"url"`
 		Rating           int       `json:"rating"`
@@ -74,5 +125,65 @@ type (
 		AdminResponse    float32   `json:"admin_response"`
 		ProductCondition float32   `json:"product_condition"`
 		Summary          string    `json:"summary"`
+		// ReviewOptions is nil for snapshots stored before the options were.
+		ReviewOptions *ReviewOptions `json:"review_options,omitempty"`
+	}
+
+	HistorySnapshotResponse struct {
+		ID               uuid.UUID `json:"id"`
+		CreatedAt        time.Time `json:"created_at"`
+		Rating           int       `json:"rating"`
+		Ulasan           int       `json:"ulasan"`
+		Bintang          float64   `json:"bintang"`
+		CountPositive    int       `json:"count_positive"`
+		CountNegative    int       `json:"count_negative"`
+		Packaging        float32   `json:"packaging"`
+		Delivery         float32   `json:"delivery"`
+		AdminResponse    float32   `json:"admin_response"`
+		ProductCondition float32   `json:"product_condition"`
+		Summary          string    `json:"summary"`
+	}
+
+	TrackedProductSnapshotsResponse struct {
+		ID             uuid.UUID                 `json:"id"`
+		Provider       string                    `json:"provider"`
+		ProductID      string                    `json:"product_id"`
+		URL            string                    `json:"url"`
+		ProductName    string                    `json:"product_name"`
+		LastAnalysedAt *time.Time                `json:"last_analysed_at"`
+		Snapshots      []HistorySnapshotResponse `json:"snapshots"`
+	}
+
+	HistorySnapshotDiffRequest struct {
+		Provider string `json:"provider" form:"provider"`
+		From     string `json:"from" form:"from"`
+		To       string `json:"to" form:"to"`
+	}
+
+	// HistorySnapshotDelta holds To minus From for every aggregate.
+	HistorySnapshotDelta struct {
+		Rating           int     `json:"rating"`
+		Ulasan           int     `json:"ulasan"`
+		Bintang          float64 `json:"bintang"`
+		CountPositive    int     `json:"count_positive"`
+		CountNegative    int     `json:"count_negative"`
+		Packaging        float32 `json:"packaging"`
+		Delivery         float32 `json:"delivery"`
+		AdminResponse    float32 `json:"admin_response"`
+		ProductCondition float32 `json:"product_condition"`
+	}
+
+	HistorySnapshotSummaries struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	}
+
+	HistorySnapshotDiffResponse struct {
+		Provider  string                   `json:"provider"`
+		ProductID string                   `json:"product_id"`
+		From      HistorySnapshotResponse  `json:"from"`
+		To        HistorySnapshotResponse  `json:"to"`
+		Delta     HistorySnapshotDelta     `json:"delta"`
+		Summaries HistorySnapshotSummaries `json:"summaries"`
 	}
 )
@@ -0,0 +1,56 @@
+package dto
+
+import "errors"
+
+const (
+	// Operations
+	LLM_OPERATION_ANALYZE   = "analyze"
+	LLM_OPERATION_SUMMARIZE = "summarize"
+	LLM_OPERATION_COMPARE   = "compare"
+
+	// Schema types
+	LLM_SCHEMA_OBJECT = "object"
+	LLM_SCHEMA_ARRAY  = "array"
+	LLM_SCHEMA_STRING = "string"
+	LLM_SCHEMA_NUMBER = "number"
+)
+
+var (
+	ErrUnsupportedLLMProvider = errors.New("unsupported llm provider")
+	ErrLLMProviderConfig      = errors.New("llm provider is not configured")
+	ErrLLMEmptyResponse       = errors.New("llm returned an empty response")
+	ErrLLMNoJSON              = errors.New("llm response does not contain a json object")
+	ErrLLMInvalidResponse     = errors.New("llm response failed validation")
+)
+
+type (
+	// LLMOperationConfig tunes the model for one operation. Zero values
+	// leave the choice to the provider.
+	LLMOperationConfig struct {
+		Model       string
+		Temperature *float32
+		MaxTokens   int
+	}
+
+	// LLMRequest is a single prompt sent to an LLMProvider. Instruction
+	// holds the task description and Input the data it applies to, so
+	// providers that support system messages can keep them apart.
+	LLMRequest struct {
+		Operation   string
+		Instruction string
+		Input       string
+		JSON        bool
+		Schema      *LLMSchema
+		Config      LLMOperationConfig
+	}
+
+	// LLMSchema describes the expected JSON output. Providers that support
+	// constrained decoding use it; the others rely on the prompt alone.
+	LLMSchema struct {
+		Type       string
+		Properties map[string]*LLMSchema
+		Items      *LLMSchema
+		Required   []string
+		Enum       []string
+	}
+)
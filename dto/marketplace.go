@@ -0,0 +1,13 @@
+package dto
+
+import "errors"
+
+var (
+	ErrUnsupportedMarketplace = errors.New("invalid domain, marketplace is not supported")
+	ErrMarketplaceNotFound    = errors.New("marketplace provider not found")
+)
+
+type ShopInfoResponse struct {
+	Name   string
+	Avatar string
+}
@@ -1,6 +1,7 @@
 package dto
 
 type MLResult struct {
+	Provider           string   `json:"provider"`
 	ProductName        string   `json:"product_name"`
 	ProductDescription string   `json:"product_description"`
 	Rating             int      `json:"rating"`
@@ -16,4 +17,21 @@ type MLResult struct {
 	AdminResponse      float32  `json:"admin_response"`
 	ProductCondition   float32  `json:"product_condition"`
 	Summary            string   `json:"summary"`
+
+	ProductMetadata
+
+	// AspectLabels are the per-review labels the aspect scores were
+	// computed from.
+	AspectLabels []ReviewAspectLabel `json:"aspect_labels,omitempty"`
+
+	// Incremental is set when only the NewReviews reviews were labelled and
+	// the rest were reused from the previous analysis of the product.
+	Incremental bool `json:"incremental"`
+	NewReviews  int  `json:"new_reviews"`
+
+	// UnlabelledReviews counts the reviews stored without a sentiment label
+	// because the ML service only reported batch counts for them.
+	UnlabelledReviews int `json:"unlabelled_reviews"`
+
+	Cache *CacheStatus `json:"cache,omitempty"`
 }
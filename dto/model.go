@@ -13,6 +13,7 @@ const (
 var (
 	ErrMarshallJson             = errors.New("failed to marshall request body json")
 	ErrModelInternalServerError = errors.New("internal server error from ml erver")
+	ErrPredictionCount          = errors.New("ml server returned a different number of predictions than statements")
 )
 
 type PredictRequest struct {
@@ -22,4 +23,18 @@ type PredictRequest struct {
 type PredictResponse struct {
 	CountNegative int `json:"Negative"`
 	CountPositive int `json:"Positive"`
+
+	// Per-statement output. Predictions is empty when the ML service only
+	// reports counts and the batch is over the per-statement limit;
+	// ModelVersion is only set when the service reports it.
+	Predictions  []StatementPrediction `json:"predictions,omitempty"`
+	ModelVersion string                `json:"model_version,omitempty"`
+}
+
+// StatementPrediction is the label the ML service assigned to one
+// statement of a PredictRequest, in request order.
+type StatementPrediction struct {
+	Label string `json:"label"`
+	// Confidence is nil when the ML service did not report one.
+	Confidence *float32 `json:"confidence"`
 }
@@ -0,0 +1,30 @@
+package dto
+
+type (
+	OpenAIChatMessage struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}
+
+	OpenAIResponseFormat struct {
+		Type string `json:"type"`
+	}
+
+	OpenAIChatRequest struct {
+		Model          string                `json:"model,omitempty"`
+		Messages       []OpenAIChatMessage   `json:"messages"`
+		Temperature    *float32              `json:"temperature,omitempty"`
+		MaxTokens      int                   `json:"max_tokens,omitempty"`
+		Stream         bool                  `json:"stream,omitempty"`
+		ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+	}
+
+	// OpenAIChatResponse covers both full completions and streamed chunks,
+	// which carry their text in Delta instead of Message.
+	OpenAIChatResponse struct {
+		Choices []struct {
+			Message OpenAIChatMessage `json:"message"`
+			Delta   OpenAIChatMessage `json:"delta"`
+		} `json:"choices"`
+	}
+)
@@ -0,0 +1,62 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Review sort orders
+	REVIEW_SORT_NEWEST         = "newest"
+	REVIEW_SORT_OLDEST         = "oldest"
+	REVIEW_SORT_HIGHEST_RATING = "highest_rating"
+	REVIEW_SORT_LOWEST_RATING  = "lowest_rating"
+	REVIEW_SORT_MOST_HELPFUL   = "most_helpful"
+
+	DEFAULT_MAX_REVIEWS = 100
+	LIMIT_MAX_REVIEWS   = 1000
+)
+
+var (
+	ErrInvalidReviewOptions = errors.New("invalid review options")
+	ErrInvalidReviewSort    = errors.New("invalid review sort, use newest, oldest, highest_rating, lowest_rating or most_helpful")
+	ErrInvalidReviewRating  = errors.New("invalid review rating, ratings must be between 1 and 5")
+	ErrInvalidReviewWindow  = errors.New("invalid review date window, since must be before until")
+	ErrSaveReviews          = errors.New("failed to save reviews")
+	ErrGetAnalysedReviews   = errors.New("failed to get analysed reviews")
+)
+
+// ReviewOptions controls which reviews are harvested from the marketplace.
+// Zero values fall back to the newest DEFAULT_MAX_REVIEWS reviews.
+type ReviewOptions struct {
+	MaxReviews int        `json:"max_reviews,omitempty"`
+	SortBy     string     `json:"sort,omitempty"`
+	Ratings    []int      `json:"ratings,omitempty"`
+	Since      *time.Time `json:"since,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	WithMedia  bool       `json:"with_media,omitempty"`
+}
+
+// ReviewsSaveRequest carries the reviews used by one analysis run together
+// with the per-review output of the ML service, aligned by index, and the
+// aspect labels keyed by review external ID.
+type ReviewsSaveRequest struct {
+	HistoryID    uuid.UUID
+	Provider     string
+	ProductID    string
+	Reviews      []ReviewResponse
+	Predictions  []StatementPrediction
+	ModelVersion string
+	AspectLabels []ReviewAspectLabel
+}
+
+// AnalysedReview is a stored review with the labels an earlier analysis
+// gave it.
+type AnalysedReview struct {
+	Review       ReviewResponse
+	Prediction   StatementPrediction
+	AspectLabel  ReviewAspectLabel
+	ModelVersion string
+}
@@ -0,0 +1,78 @@
+package dto
+
+import "errors"
+
+const (
+	// Failed
+	MESSAGE_FAILED_ANALYZE_SHOP      = "failed analyze shop"
+	MESSAGE_FAILED_GET_SHOP_PRODUCTS = "failed get shop products"
+
+	// Success
+	MESSAGE_SUCCESS_ANALYZE_SHOP = "success analyze shop"
+
+	// Shop analysis stages
+	SHOP_ANALYSIS_STAGE_CATALOGUE = "catalogue"
+	SHOP_ANALYSIS_STAGE_PRODUCTS  = "products"
+	SHOP_ANALYSIS_STAGE_SUMMARIZE = "summarize"
+)
+
+var (
+	ErrShopDomainMissing  = errors.New("shop domain is required")
+	ErrInvalidShopOptions = errors.New("products must be between 1 and 20 and reviews_per_product between 1 and 100")
+	ErrShopProductsFailed = errors.New("none of the shop's products could be analysed")
+)
+
+type (
+	ShopAnalysisRequest struct {
+		ShopDomain string `json:"shop_domain" form:"shop_domain" binding:"required"`
+		// Products is how many best-selling products are sampled.
+		Products int `json:"products" form:"products"`
+		// ReviewsPerProduct is how many of the newest reviews are analysed
+		// for each product.
+		ReviewsPerProduct int `json:"reviews_per_product" form:"reviews_per_product"`
+	}
+
+	// ShopProductAnalysis is one product of the per-product breakdown. A
+	// product that failed to analyse only carries its error.
+	ShopProductAnalysis struct {
+		ProductID        string  `json:"product_id"`
+		ProductName      string  `json:"product_name"`
+		ProductURL       string  `json:"product_url"`
+		Rating           int     `json:"rating"`
+		Bintang          float64 `json:"bintang"`
+		CountPositive    int     `json:"count_positive"`
+		CountNegative    int     `json:"count_negative"`
+		Packaging        float32 `json:"packaging"`
+		Delivery         float32 `json:"delivery"`
+		AdminResponse    float32 `json:"admin_response"`
+		ProductCondition float32 `json:"product_condition"`
+		Summary          string  `json:"summary"`
+		Error            string  `json:"error,omitempty"`
+	}
+
+	// ShopAnalysisResult aggregates the reviews of every analysed product.
+	// Aspect scores are computed over the pooled review labels, so products
+	// with more reviews weigh more.
+	ShopAnalysisResult struct {
+		ShopID           string                `json:"shop_id"`
+		ShopName         string                `json:"shop_name"`
+		ShopDomain       string                `json:"shop_domain"`
+		ShopAvatar       string                `json:"shop_avatar"`
+		ProductsAnalysed int                   `json:"products_analysed"`
+		Rating           int                   `json:"rating"`
+		Bintang          float64               `json:"bintang"`
+		CountPositive    int                   `json:"count_positive"`
+		CountNegative    int                   `json:"count_negative"`
+		Packaging        float32               `json:"packaging"`
+		Delivery         float32               `json:"delivery"`
+		AdminResponse    float32               `json:"admin_response"`
+		ProductCondition float32               `json:"product_condition"`
+		Summary          string                `json:"summary"`
+		Products         []ShopProductAnalysis `json:"products"`
+	}
+
+	ShopAnalysisProgressData struct {
+		Done  int `json:"done"`
+		Total int `json:"total"`
+	}
+)
@@ -0,0 +1,40 @@
+package dto
+
+type ShopeeItemResponse struct {
+	Error int `json:"error"`
+	Data  *struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Images      []string `json:"images"`
+	} `json:"data"`
+}
+
+type ShopeeRatingsResponse struct {
+	Error int `json:"error"`
+	Data  struct {
+		Ratings []struct {
+			CmtID          int64    `json:"cmtid"`
+			Comment        string   `json:"comment"`
+			RatingStar     int      `json:"rating_star"`
+			CTime          int64    `json:"ctime"`
+			AuthorUsername string   `json:"author_username"`
+			Images         []string `json:"images"`
+			Videos         []struct {
+				ID string `json:"id"`
+			} `json:"videos"`
+			ProductItems []struct {
+				ModelName string `json:"model_name"`
+			} `json:"product_items"`
+		} `json:"ratings"`
+	} `json:"data"`
+}
+
+type ShopeeShopResponse struct {
+	Error int `json:"error"`
+	Data  *struct {
+		Name    string `json:"name"`
+		Account struct {
+			Portrait string `json:"portrait"`
+		} `json:"account"`
+	} `json:"data"`
+}
@@ -1,6 +1,12 @@
 package dto
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
 
 const (
 	// Failed
@@ -17,21 +23,50 @@ const (
 var (
 	ErrProductUrlMissing     = errors.New("product url is required")
 	ErrProductUrlWrongFormat = errors.New("invalid product url format")
-	ErrNotTokopediaUrls      = errors.New("invalid domain, only tokopedia.com urls are accepted")
 	ErrProductId             = errors.New("failed to extract product id")
 	ErrShopAvatarNotFound    = errors.New("shop avatar not found")
 	ErrProductNotFound       = errors.New("product not found")
+	ErrReviewsUnavailable    = errors.New("gateway returned an error for the review list")
+	ErrShopNotFound          = errors.New("shop not found")
+	ErrShopHasNoProducts     = errors.New("shop has no products")
 )
 
 type ProductReviewResponseTokopedia struct {
 	Data struct {
 		ProductrevGetProductReviewList struct {
 			List []struct {
-				Message       string `json:"message"`
-				ProductRating int    `json:"productRating"`
+				ID                    string `json:"id"`
+				VariantName           string `json:"variantName"`
+				Message               string `json:"message"`
+				ProductRating         int    `json:"productRating"`
+				ReviewCreateTimestamp string `json:"reviewCreateTimestamp"`
+				IsAnonymous           bool   `json:"isAnonymous"`
+				ImageAttachments      []struct {
+					AttachmentID string `json:"attachmentID"`
+				} `json:"imageAttachments"`
+				VideoAttachments []struct {
+					AttachmentID string `json:"attachmentID"`
+				} `json:"videoAttachments"`
+				User struct {
+					FullName string `json:"fullName"`
+				} `json:"user"`
 			} `json:"list"`
+			HasNext      bool `json:"hasNext"`
+			TotalReviews int  `json:"totalReviews"`
 		} `json:"productrevGetProductReviewList"`
 	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type PingResponseTokopedia struct {
+	Data struct {
+		Typename string `json:"__typename"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
 }
 
 type ShopAvatarResponseTokopedia struct {
@@ -46,6 +81,157 @@ type ShopAvatarResponseTokopedia struct {
 	} `json:"data"`
 }
 
+// PDPLayoutResponseTokopedia is the response of PDPGetLayoutQuery. The
+// data of each component depends on its type, so it is kept raw and decoded
+// by __typename.
+type PDPLayoutResponseTokopedia struct {
+	Data struct {
+		PDPGetLayout *struct {
+			BasicInfo struct {
+				ID       string `json:"id"`
+				ShopName string `json:"shopName"`
+				Category struct {
+					ID     string `json:"id"`
+					Name   string `json:"name"`
+					Detail []struct {
+						ID   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"detail"`
+				} `json:"category"`
+				TxStats struct {
+					CountSold TokopediaNumber `json:"countSold"`
+				} `json:"txStats"`
+				Stats struct {
+					CountReview TokopediaNumber `json:"countReview"`
+					Rating      TokopediaNumber `json:"rating"`
+				} `json:"stats"`
+			} `json:"basicInfo"`
+			Components []struct {
+				Name string            `json:"name"`
+				Data []json.RawMessage `json:"data"`
+			} `json:"components"`
+		} `json:"pdpGetLayout"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+// PDPComponentTypeTokopedia only reads the type of a component's data so it
+// can be decoded into the matching struct below.
+type PDPComponentTypeTokopedia struct {
+	Typename string `json:"__typename"`
+}
+
+type PDPProductContentTokopedia struct {
+	Name  string `json:"name"`
+	Price struct {
+		Value          TokopediaNumber `json:"value"`
+		PriceFmt       string          `json:"priceFmt"`
+		SlashPriceFmt  string          `json:"slashPriceFmt"`
+		DiscPercentage TokopediaNumber `json:"discPercentage"`
+	} `json:"price"`
+	Stock struct {
+		Value TokopediaNumber `json:"value"`
+	} `json:"stock"`
+}
+
+type PDPProductMediaTokopedia struct {
+	Media []struct {
+		Type        string `json:"type"`
+		URLOriginal string `json:"urlOriginal"`
+	} `json:"media"`
+}
+
+type PDPProductDetailTokopedia struct {
+	Content []struct {
+		Title    string `json:"title"`
+		Subtitle string `json:"subtitle"`
+	} `json:"content"`
+}
+
+type PDPProductVariantTokopedia struct {
+	Variants []struct {
+		Name   string `json:"name"`
+		Option []struct {
+			Value string `json:"value"`
+		} `json:"option"`
+	} `json:"variants"`
+	Children []struct {
+		Stock struct {
+			Stock TokopediaNumber `json:"stock"`
+		} `json:"stock"`
+	} `json:"children"`
+}
+
+type PDPSocialProofTokopedia struct {
+	Content []struct {
+		Title    string  `json:"title"`
+		Subtitle string  `json:"subtitle"`
+		Type     string  `json:"type"`
+		Rating   float64 `json:"rating"`
+	} `json:"content"`
+}
+
+type PDPCategoryCarouselTokopedia struct {
+	List []struct {
+		CategoryID string `json:"categoryID"`
+		Title      string `json:"title"`
+	} `json:"list"`
+}
+
+type ProductRatingResponseTokopedia struct {
+	Data struct {
+		ProductrevGetProductRatingAndTopics struct {
+			Rating struct {
+				RatingScore TokopediaNumber `json:"ratingScore"`
+				TotalRating TokopediaNumber `json:"totalRating"`
+				Detail      []struct {
+					Rate            TokopediaNumber `json:"rate"`
+					TotalReviews    TokopediaNumber `json:"totalReviews"`
+					PercentageFloat TokopediaNumber `json:"percentageFloat"`
+				} `json:"detail"`
+			} `json:"rating"`
+		} `json:"productrevGetProductRatingAndTopics"`
+	} `json:"data"`
+}
+
+// ProductMetadata is the listing information shown next to the analysis.
+// Every field is best effort and left empty when the marketplace does not
+// provide it.
+type ProductMetadata struct {
+	Price              int64             `json:"price"`
+	PriceFmt           string            `json:"price_fmt"`
+	OriginalPriceFmt   string            `json:"original_price_fmt"`
+	DiscountPercentage int               `json:"discount_percentage"`
+	Stock              int               `json:"stock"`
+	Condition          string            `json:"condition"`
+	Category           ProductCategory   `json:"category"`
+	Variants           []ProductVariant  `json:"variants"`
+	SoldCount          int               `json:"sold_count"`
+	ProductRating      float64           `json:"product_rating"`
+	TotalRatings       int               `json:"total_ratings"`
+	RatingBreakdown    []RatingBreakdown `json:"rating_breakdown"`
+}
+
+type ProductCategory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Path lists the category tree from the root down to Name.
+	Path []string `json:"path"`
+}
+
+type ProductVariant struct {
+	Name    string   `json:"name"`
+	Options []string `json:"options"`
+}
+
+type RatingBreakdown struct {
+	Rate       int     `json:"rate"`
+	Count      int     `json:"count"`
+	Percentage float64 `json:"percentage"`
+}
+
 type GetProductRequest struct {
 	ProductUrl string
 	ProductKey string
@@ -56,16 +242,100 @@ type GetProductResponse struct {
 	ProductName        string
 	ProductDescription string
 	ShopName           string
+	ShopDomain         string
+	ShopId             string
 	ProductId          string
+	ProductUrl         string
 	ImageUrls          []string
+
+	ProductMetadata
 }
 
 type GetReviewsRequest struct {
 	ProductUrl string
 	ProductId  string
+	ShopId     string
+	Options    ReviewOptions
 }
 
 type ReviewResponse struct {
-	Message string `json:"message"`
-	Rating  int    `json:"rating"`
+	ID        string    `json:"id"`
+	Message   string    `json:"message"`
+	Rating    int       `json:"rating"`
+	CreatedAt time.Time `json:"created_at"`
+	Variant   string    `json:"variant,omitempty"`
+	HasMedia  bool      `json:"has_media"`
+	Reviewer  string    `json:"reviewer,omitempty"`
+}
+
+type ShopInfoResponseTokopedia struct {
+	Data struct {
+		ShopInfoByID struct {
+			Result []struct {
+				ShopCore struct {
+					ShopID string `json:"shopID"`
+					Name   string `json:"name"`
+					Domain string `json:"domain"`
+				} `json:"shopCore"`
+				ShopAssets struct {
+					Avatar string `json:"avatar"`
+				} `json:"shopAssets"`
+			} `json:"result"`
+		} `json:"shopInfoByID"`
+	} `json:"data"`
+}
+
+type ShopProductsResponseTokopedia struct {
+	Data struct {
+		GetShopProduct struct {
+			Data []struct {
+				ProductID  string `json:"product_id"`
+				Name       string `json:"name"`
+				ProductURL string `json:"product_url"`
+				Stats      struct {
+					AverageRating string `json:"averageRating"`
+					CountReview   int    `json:"countReview"`
+				} `json:"stats"`
+			} `json:"data"`
+		} `json:"GetShopProduct"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type ShopProduct struct {
+	ProductID   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	ProductURL  string  `json:"product_url"`
+	Rating      float64 `json:"rating"`
+	ReviewCount int     `json:"review_count"`
+}
+
+type GetShopProductsResponse struct {
+	ShopID     string
+	ShopName   string
+	ShopDomain string
+	ShopAvatar string
+	Products   []ShopProduct
+}
+
+// TokopediaNumber is a number the gateway sends either as a JSON number or
+// as a string, e.g. 1234 or "1234". Empty strings, null and values that do
+// not parse read as zero instead of failing the whole response.
+type TokopediaNumber float64
+
+func (n *TokopediaNumber) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		*n = 0
+		return nil
+	}
+	*n = TokopediaNumber(value)
+	return nil
+}
+
+func (n TokopediaNumber) Int() int {
+	return int(n)
 }
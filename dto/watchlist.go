@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Failed
+	MESSAGE_FAILED_CREATE_WATCHLIST = "failed create watchlist"
+	MESSAGE_FAILED_GET_WATCHLISTS   = "failed get watchlists"
+	MESSAGE_FAILED_GET_WATCHLIST    = "failed get watchlist"
+	MESSAGE_FAILED_UPDATE_WATCHLIST = "failed update watchlist"
+	MESSAGE_FAILED_DELETE_WATCHLIST = "failed delete watchlist"
+
+	// Success
+	MESSAGE_SUCCESS_CREATE_WATCHLIST = "success create watchlist"
+	MESSAGE_SUCCESS_GET_WATCHLISTS   = "success get watchlists"
+	MESSAGE_SUCCESS_GET_WATCHLIST    = "success get watchlist"
+	MESSAGE_SUCCESS_UPDATE_WATCHLIST = "success update watchlist"
+	MESSAGE_SUCCESS_DELETE_WATCHLIST = "success delete watchlist"
+)
+
+var (
+	ErrCreateWatchlist     = errors.New("failed to create watchlist")
+	ErrGetWatchlists       = errors.New("failed to get watchlists")
+	ErrGetWatchlist        = errors.New("failed to get watchlist")
+	ErrUpdateWatchlist     = errors.New("failed to update watchlist")
+	ErrDeleteWatchlist     = errors.New("failed to delete watchlist")
+	ErrWatchlistQuota      = errors.New("watchlist quota reached, deactivate or delete another item first")
+	ErrWatchlistNeverRuns  = errors.New("schedule never runs")
+	ErrWatchlistDailyQuota = errors.New("daily scheduled analysis quota reached")
+)
+
+type (
+	WatchlistCreateRequest struct {
+		ProductUrl string `json:"product_url" form:"product_url" binding:"required"`
+		Schedule   string `json:"schedule" form:"schedule" binding:"required"`
+		Active     *bool  `json:"active" form:"active"`
+	}
+
+	WatchlistUpdateRequest struct {
+		ProductUrl *string `json:"product_url" form:"product_url"`
+		Schedule   *string `json:"schedule" form:"schedule"`
+		Active     *bool   `json:"active" form:"active"`
+	}
+
+	WatchlistResponse struct {
+		ID         uuid.UUID  `json:"id"`
+		ProductURL string     `json:"product_url"`
+		Schedule   string     `json:"schedule"`
+		Active     bool       `json:"active"`
+		NextRunAt  time.Time  `json:"next_run_at"`
+		LastRunAt  *time.Time `json:"last_run_at"`
+		LastJobID  *uuid.UUID `json:"last_job_id"`
+		LastError  string     `json:"last_error"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+)
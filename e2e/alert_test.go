@@ -0,0 +1,86 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/entity"
+	"review_product_tokopedia_be/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// A pending delivery of a deleted rule must neither be claimed nor block
+// the deliveries queued behind it.
+func TestAlertDeliveryOfDeletedRule(t *testing.T) {
+	db := openTestDatabase(t)
+	ctx := context.Background()
+	alertRepo := repository.NewAlertRepository(db)
+
+	user := entity.User{Name: "Alert", Email: "alert@example.com", Password: "password", Role: constants.ENUM_ROLE_USER}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	newRule := func(name string) entity.AlertRule {
+		rule, err := alertRepo.CreateRule(ctx, nil, entity.AlertRule{
+			Name:      name,
+			Metric:    constants.ENUM_ALERT_METRIC_NEGATIVE_SHARE,
+			Condition: constants.ENUM_ALERT_CONDITION_ABOVE,
+			Threshold: 20,
+			Channel:   constants.ENUM_ALERT_CHANNEL_WEBHOOK,
+			Target:    "https://hooks.example.com/" + name,
+			Active:    true,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			t.Fatalf("create rule %s: %v", name, err)
+		}
+		return rule
+	}
+	deleted := newRule("deleted")
+	kept := newRule("kept")
+
+	now := time.Now()
+	err := alertRepo.CreateDeliveries(ctx, nil, []entity.AlertDelivery{
+		{Channel: deleted.Channel, Target: deleted.Target, Payload: "{}", Status: constants.ENUM_DELIVERY_STATUS_PENDING, NextAttemptAt: now.Add(-2 * time.Minute), AlertRuleID: deleted.ID, HistoryID: uuid.New()},
+		{Channel: kept.Channel, Target: kept.Target, Payload: "{}", Status: constants.ENUM_DELIVERY_STATUS_PENDING, NextAttemptAt: now.Add(-time.Minute), AlertRuleID: kept.ID, HistoryID: uuid.New()},
+	})
+	if err != nil {
+		t.Fatalf("create deliveries: %v", err)
+	}
+
+	if err := alertRepo.DeleteRule(ctx, nil, deleted.ID.String(), user.ID.String()); err != nil {
+		t.Fatalf("delete rule: %v", err)
+	}
+
+	var cancelled entity.AlertDelivery
+	if err := db.Take(&cancelled, "alert_rule_id = ?", deleted.ID).Error; err != nil {
+		t.Fatalf("load delivery of the deleted rule: %v", err)
+	}
+	if cancelled.Status != constants.ENUM_DELIVERY_STATUS_CANCELLED {
+		t.Errorf("delivery of the deleted rule is %q, want %q", cancelled.Status, constants.ENUM_DELIVERY_STATUS_CANCELLED)
+	}
+
+	// Rules deleted by earlier releases left their deliveries pending, so
+	// the claim has to skip them on its own.
+	if err := db.Model(&cancelled).Update("status", constants.ENUM_DELIVERY_STATUS_PENDING).Error; err != nil {
+		t.Fatalf("reset delivery: %v", err)
+	}
+
+	claimed, err := alertRepo.ClaimDueDelivery(ctx, nil, now, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if claimed.AlertRuleID != kept.ID || claimed.AlertRule.ID != kept.ID {
+		t.Errorf("claimed a delivery of rule %s, want %s", claimed.AlertRuleID, kept.ID)
+	}
+
+	if _, err := alertRepo.ClaimDueDelivery(ctx, nil, now, time.Minute); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("second claim: err = %v, want an empty queue", err)
+	}
+}
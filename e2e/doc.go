@@ -0,0 +1,11 @@
+// Package e2e holds the end-to-end API tests. They boot the application
+// from app.NewApp against a throwaway Postgres schema, with local fake
+// servers standing in for Tokopedia, the ML service and the LLM.
+//
+// The tests are skipped unless E2E_DATABASE_DSN points at a Postgres
+// database the tests may create and drop schemas in, e.g.
+//
+//	E2E_DATABASE_DSN="host=localhost user=postgres password=postgres dbname=postgres port=5432" go test ./e2e
+//
+// or make test-e2e, which starts a Postgres container for the run.
+package e2e
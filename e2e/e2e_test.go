@@ -0,0 +1,446 @@
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"review_product_tokopedia_be/app"
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/database"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const fakeModelAPIKey = "e2e-model-key"
+
+type (
+	testEnv struct {
+		t         *testing.T
+		server    *httptest.Server
+		app       *app.App
+		tokopedia *recorder
+		model     *recorder
+		llm       *recorder
+	}
+
+	apiResponse struct {
+		Status  bool            `json:"status"`
+		Message string          `json:"message"`
+		Error   any             `json:"error"`
+		Data    json.RawMessage `json:"data"`
+	}
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// openTestDatabase creates a schema of its own on the E2E_DATABASE_DSN
+// server, migrates it and drops it again when the test ends.
+func openTestDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("E2E_DATABASE_DSN")
+	if dsn == "" {
+		// CI provides a database, so a missing DSN there is a broken setup
+		// rather than a reason to skip.
+		if os.Getenv("CI") != "" {
+			t.Fatal("E2E_DATABASE_DSN is not set")
+		}
+		t.Skip("E2E_DATABASE_DSN is not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to %s: %v", dsn, err)
+	}
+
+	schema := fmt.Sprintf("e2e_%d", time.Now().UnixNano())
+	if err := admin.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`).Error; err != nil {
+		t.Fatalf("create uuid-ossp: %v", err)
+	}
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn+" search_path="+schema+",public"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("connect to schema %s: %v", schema, err)
+	}
+
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+		if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Errorf("drop schema %s: %v", schema, err)
+		}
+		if sqlDB, err := admin.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	if err := database.MigrateUp(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	return db
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	db := openTestDatabase(t)
+
+	tokopedia, tokopediaCalls := newFakeTokopedia(t)
+	model, modelCalls := newFakeModel(t, fakeModelAPIKey)
+	llm, llmCalls := newFakeLLM(t)
+
+	llmProvider, err := service.NewOpenAILLMProvider(llm.URL, "e2e-llm-key")
+	if err != nil {
+		t.Fatalf("llm provider: %v", err)
+	}
+
+	cfg := config.Default()
+	cfg.JWT.Secret = "e2e-jwt-secret"
+	cfg.Endpoints = config.EndpointsConfig{
+		TokopediaURL: tokopedia.URL,
+		ModelURL:     model.URL + "/predict",
+		ModelAPIKey:  fakeModelAPIKey,
+		// The fake answers with batch counts only, like the deployed one
+		ModelPerStatementLimit: 100,
+	}
+
+	application := app.NewApp(app.Options{
+		DB:          db,
+		Config:      cfg,
+		LLMProvider: llmProvider,
+	})
+	application.Start()
+	t.Cleanup(application.Stop)
+
+	server := httptest.NewServer(application.Router)
+	t.Cleanup(server.Close)
+
+	return &testEnv{
+		t:         t,
+		app:       application,
+		server:    server,
+		tokopedia: tokopediaCalls,
+		model:     modelCalls,
+		llm:       llmCalls,
+	}
+}
+
+// do sends a request to the API and decodes the response envelope into
+// res, and its data into data when data is not nil.
+func (e *testEnv) do(method string, path string, token string, body any, data any) (int, apiResponse) {
+	e.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			e.t.Fatalf("encode %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, e.server.URL+path, reader)
+	if err != nil {
+		e.t.Fatalf("build %s %s: %v", method, path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := e.server.Client().Do(req)
+	if err != nil {
+		e.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer res.Body.Close()
+
+	var envelope apiResponse
+	if err := json.NewDecoder(res.Body).Decode(&envelope); err != nil {
+		e.t.Fatalf("%s %s: decode response: %v", method, path, err)
+	}
+	if data != nil && res.StatusCode < http.StatusBadRequest {
+		if err := json.Unmarshal(envelope.Data, data); err != nil {
+			e.t.Fatalf("%s %s: decode data: %v", method, path, err)
+		}
+	}
+
+	return res.StatusCode, envelope
+}
+
+func TestAnalysisFlow(t *testing.T) {
+	env := newTestEnv(t)
+
+	// Register and log in
+	status, res := env.do(http.MethodPost, "/api/user", "", dto.UserCreateRequest{
+		Name:     "Pengguna E2E",
+		Email:    "e2e@example.com",
+		Password: "rahasia123",
+	}, nil)
+	if status != http.StatusOK {
+		t.Fatalf("register: %d %+v", status, res)
+	}
+
+	status, res = env.do(http.MethodPost, "/api/user", "", dto.UserCreateRequest{
+		Name:     "Pengguna E2E",
+		Email:    "e2e@example.com",
+		Password: "rahasia123",
+	}, nil)
+	if status == http.StatusOK {
+		t.Fatalf("registering the same email twice succeeded: %+v", res)
+	}
+
+	var login dto.UserLoginResponse
+	status, res = env.do(http.MethodPost, "/api/user/login", "", dto.UserLoginRequest{
+		Email:    "e2e@example.com",
+		Password: "rahasia123",
+	}, &login)
+	if status != http.StatusOK || login.Token == "" {
+		t.Fatalf("login: %d %+v", status, res)
+	}
+
+	status, _ = env.do(http.MethodGet, "/api/history?page=1&limit=10", "", nil, nil)
+	if status != http.StatusUnauthorized {
+		t.Errorf("history without a token: got %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	// Analyse a product
+	var result dto.MLResult
+	status, res = env.do(http.MethodGet, "/api/ml/analysis?product_url="+url.QueryEscape(fakeProductUrl), login.Token, nil, &result)
+	if status != http.StatusOK {
+		t.Fatalf("analysis: %d %+v", status, res)
+	}
+
+	if result.ProductName != fakeProductName || result.ShopName != "Toko Audio" {
+		t.Errorf("product = %q from %q", result.ProductName, result.ShopName)
+	}
+	if result.Rating != len(fakeReviews) || result.CountPositive != 4 || result.CountNegative != 2 {
+		t.Errorf("counts = %d reviews, %d positive, %d negative", result.Rating, result.CountPositive, result.CountNegative)
+	}
+	if result.Summary == "" || len(result.AspectLabels) != len(fakeReviews) {
+		t.Errorf("summary %q with %d aspect labels", result.Summary, len(result.AspectLabels))
+	}
+	if result.Price != 189000 || result.SoldCount != 1520 {
+		t.Errorf("metadata = %+v", result.ProductMetadata)
+	}
+
+	for operation, calls := range map[string]*recorder{
+		"PDPGetLayoutQuery":         env.tokopedia,
+		"productReviewList":         env.tokopedia,
+		"predict":                   env.model,
+		dto.LLM_OPERATION_ANALYZE:   env.llm,
+		dto.LLM_OPERATION_SUMMARIZE: env.llm,
+	} {
+		if calls.count(operation) == 0 {
+			t.Errorf("%s was never called", operation)
+		}
+	}
+
+	// The analysis shows up in the history
+	var histories dto.HistoriesResponse
+	status, res = env.do(http.MethodGet, "/api/history?page=1&limit=10", login.Token, nil, &histories)
+	if status != http.StatusOK {
+		t.Fatalf("history: %d %+v", status, res)
+	}
+	if histories.Total != 1 || len(histories.Histories) != 1 {
+		t.Fatalf("history has %d entries, want 1", histories.Total)
+	}
+	listed := histories.Histories[0]
+	if listed.ProductName != fakeProductName || listed.ProductID != fakeProductID {
+		t.Errorf("listed history = %q %q", listed.ProductName, listed.ProductID)
+	}
+
+	var history dto.HistoryResponse
+	status, res = env.do(http.MethodGet, "/api/history/"+listed.ID.String(), login.Token, nil, &history)
+	if status != http.StatusOK {
+		t.Fatalf("history detail: %d %+v", status, res)
+	}
+	if history.ID != listed.ID || history.Summary != result.Summary || history.CountNegative != result.CountNegative {
+		t.Errorf("history detail = %+v, want the analysis result", history)
+	}
+
+	// Every stored review carries the label the ML service gave it, even
+	// though the service only answers with batch counts
+	var negative []dto.HistoryReviewResponse
+	status, res = env.do(http.MethodGet, "/api/history/"+listed.ID.String()+"/reviews?sentiment=negative", login.Token, nil, &negative)
+	if status != http.StatusOK {
+		t.Fatalf("negative reviews: %d %+v", status, res)
+	}
+	if len(negative) != result.CountNegative {
+		t.Errorf("sentiment=negative returned %d reviews, want %d", len(negative), result.CountNegative)
+	}
+	for _, review := range negative {
+		if review.Label != "negative" || !strings.Contains(review.Message, "kecewa") && !strings.Contains(review.Message, "rusak") {
+			t.Errorf("review %q labelled %q", review.Message, review.Label)
+		}
+	}
+
+	var all []dto.HistoryReviewResponse
+	env.do(http.MethodGet, "/api/history/"+listed.ID.String()+"/reviews", login.Token, nil, &all)
+	for _, review := range all {
+		if review.Label == "" {
+			t.Errorf("review %q stored without a label", review.Message)
+		}
+	}
+
+	status, _ = env.do(http.MethodGet, "/api/history/00000000-0000-0000-0000-000000000000", login.Token, nil, nil)
+	if status == http.StatusOK {
+		t.Errorf("unknown history id returned %d", status)
+	}
+}
+
+func TestAnalysisUnknownProduct(t *testing.T) {
+	env := newTestEnv(t)
+
+	status, res := env.do(http.MethodGet, "/api/ml/guest/analysis?product_url="+url.QueryEscape("https://www.tokopedia.com/tokoaudio/produk-tidak-ada"), "", nil, nil)
+	if status != http.StatusBadRequest || res.Status {
+		t.Fatalf("analysis of an unknown product: %d %+v", status, res)
+	}
+	if env.model.count("predict") != 0 {
+		t.Errorf("the ML service was called for a product that does not exist")
+	}
+}
+
+func TestReadinessDuringShutdown(t *testing.T) {
+	env := newTestEnv(t)
+
+	status, res := env.do(http.MethodGet, "/readyz", "", nil, nil)
+	if status != http.StatusOK || !res.Status {
+		t.Fatalf("readyz after start: %d %+v", status, res)
+	}
+
+	env.app.Drain()
+
+	status, res = env.do(http.MethodGet, "/readyz", "", nil, nil)
+	if status != http.StatusServiceUnavailable || res.Status {
+		t.Fatalf("readyz while draining: %d %+v", status, res)
+	}
+}
+
+func TestHealthAndAdminStatus(t *testing.T) {
+	env := newTestEnv(t)
+
+	status, res := env.do(http.MethodGet, "/healthz", "", nil, nil)
+	if status != http.StatusOK || !res.Status {
+		t.Fatalf("healthz: %d %+v", status, res)
+	}
+
+	status, _ = env.do(http.MethodGet, "/api/admin/status", "", nil, nil)
+	if status != http.StatusUnauthorized {
+		t.Fatalf("status without a token: %d, want 401", status)
+	}
+
+	user := dto.UserCreateRequest{Name: "Pengguna E2E", Email: "user@example.com", Password: "rahasia123"}
+	if _, err := env.app.Users.RegisterUser(context.Background(), user); err != nil {
+		t.Fatalf("register user: %v", err)
+	}
+	status, _ = env.do(http.MethodGet, "/api/admin/status", env.login(user), nil, nil)
+	if status != http.StatusForbidden {
+		t.Fatalf("status as a regular user: %d, want 403", status)
+	}
+
+	admin := dto.UserCreateRequest{Name: "Admin E2E", Email: "admin@example.com", Password: "rahasia123"}
+	if _, err := env.app.Users.RegisterAdmin(context.Background(), admin); err != nil {
+		t.Fatalf("register admin: %v", err)
+	}
+	var report dto.StatusResponse
+	status, res = env.do(http.MethodGet, "/api/admin/status", env.login(admin), nil, &report)
+	if status != http.StatusOK || !report.Ready {
+		t.Fatalf("status as an admin: %d %+v", status, res)
+	}
+
+	healthy := map[string]bool{}
+	for _, dependency := range report.Dependencies {
+		healthy[dependency.Name] = dependency.Healthy
+		if !dependency.Healthy {
+			t.Errorf("%s unhealthy: %s", dependency.Name, dependency.LastError)
+		}
+	}
+	for _, name := range []string{"database", "tokopedia", "model", "llm"} {
+		if _, ok := healthy[name]; !ok {
+			t.Errorf("status does not report %s", name)
+		}
+	}
+	if env.tokopedia.count("Ping") != 1 {
+		t.Errorf("tokopedia pinged %d times, want 1", env.tokopedia.count("Ping"))
+	}
+
+	// A second request within the cache window reuses the probe
+	env.do(http.MethodGet, "/api/admin/status", env.login(admin), nil, nil)
+	if env.tokopedia.count("Ping") != 1 {
+		t.Errorf("tokopedia pinged again within the cache window")
+	}
+}
+
+// login returns a token for a registered user.
+func (e *testEnv) login(user dto.UserCreateRequest) string {
+	e.t.Helper()
+
+	var login dto.UserLoginResponse
+	status, res := e.do(http.MethodPost, "/api/user/login", "", dto.UserLoginRequest{
+		Email:    user.Email,
+		Password: user.Password,
+	}, &login)
+	if status != http.StatusOK || login.Token == "" {
+		e.t.Fatalf("login %s: %d %+v", user.Email, status, res)
+	}
+	return login.Token
+}
+
+func TestIncrementalReanalysis(t *testing.T) {
+	env := newTestEnv(t)
+
+	user := dto.UserCreateRequest{Name: "Pengguna E2E", Email: "incremental@example.com", Password: "rahasia123"}
+	if _, err := env.app.Users.RegisterUser(context.Background(), user); err != nil {
+		t.Fatalf("register user: %v", err)
+	}
+	token := env.login(user)
+
+	analyse := func(query string) dto.MLResult {
+		t.Helper()
+
+		var result dto.MLResult
+		status, res := env.do(http.MethodGet, "/api/ml/analysis?product_url="+url.QueryEscape(fakeProductUrl)+query, token, nil, &result)
+		if status != http.StatusOK {
+			t.Fatalf("analysis%s: %d %+v", query, status, res)
+		}
+		return result
+	}
+
+	if first := analyse(""); first.Incremental {
+		t.Fatalf("first analysis was incremental")
+	}
+
+	// The stored labels are reused when nothing changed
+	second := analyse("")
+	if !second.Incremental || second.NewReviews != 0 || second.CountNegative != 2 || second.CountPositive != 4 {
+		t.Errorf("second analysis = incremental %t, %d new, %d positive, %d negative", second.Incremental, second.NewReviews, second.CountPositive, second.CountNegative)
+	}
+
+	// Other review options than the stored snapshot's need a full run
+	if third := analyse("&max_reviews=50"); third.Incremental {
+		t.Errorf("analysis with a different max_reviews extended the stored snapshot")
+	}
+}
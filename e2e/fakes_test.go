@@ -0,0 +1,282 @@
+package e2e
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/service"
+)
+
+const (
+	fakeShopDomain  = "tokoaudio"
+	fakeProductKey  = "earphone-bluetooth-tws-pro"
+	fakeProductID   = "2150001234"
+	fakeProductName = "Earphone Bluetooth TWS Pro"
+	fakeProductUrl  = "https://www.tokopedia.com/" + fakeShopDomain + "/" + fakeProductKey
+)
+
+var fakeReviews = []struct {
+	message string
+	rating  int
+}{
+	{"Barang bagus, suara jernih dan mantap", 5},
+	{"Packing rapi pakai bubble wrap, aman sampai tujuan", 5},
+	{"Pengiriman cepat, kurir ramah", 4},
+	{"Admin responsif dan membantu", 5},
+	{"Baterai cepat habis, kecewa", 2},
+	{"Barang rusak saat diterima", 1},
+}
+
+// recorder counts the calls a fake server received, by operation.
+type recorder struct {
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (r *recorder) record(operation string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.calls == nil {
+		r.calls = make(map[string]int)
+	}
+	r.calls[operation]++
+}
+
+func (r *recorder) count(operation string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls[operation]
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// newFakeTokopedia answers the GraphQL operations the Tokopedia client
+// sends for one product, fakeProductUrl.
+func newFakeTokopedia(t *testing.T) (*httptest.Server, *recorder) {
+	calls := &recorder{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			OperationName string         `json:"operationName"`
+			Variables     map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"errors": []any{map[string]string{"message": err.Error()}}})
+			return
+		}
+		calls.record(req.OperationName)
+
+		switch req.OperationName {
+		case "PDPGetLayoutQuery":
+			if req.Variables["productKey"] != fakeProductKey {
+				writeJSON(w, http.StatusOK, map[string]any{
+					"errors": []any{map[string]string{"message": "product not found"}},
+					"data":   map[string]any{"pdpGetLayout": nil},
+				})
+				return
+			}
+			writeJSON(w, http.StatusOK, fakePDPLayout())
+
+		case "productRatingAndTopics":
+			writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+				"productrevGetProductRatingAndTopics": map[string]any{"rating": map[string]any{
+					"ratingScore": "3.7",
+					"totalRating": len(fakeReviews),
+					"detail":      []any{},
+				}},
+			}})
+
+		case "productReviewList":
+			list := make([]map[string]any, 0, len(fakeReviews))
+			for i, review := range fakeReviews {
+				list = append(list, map[string]any{
+					"id":                    fmt.Sprintf("rv-%d", i+1),
+					"variantName":           "Hitam",
+					"message":               review.message,
+					"productRating":         review.rating,
+					"reviewCreateTimestamp": fmt.Sprint(1717200000 - i*3600),
+					"imageAttachments":      []any{},
+					"videoAttachments":      []any{},
+					"user":                  map[string]string{"fullName": "Pembeli"},
+				})
+			}
+			writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+				"productrevGetProductReviewList": map[string]any{
+					"list":         list,
+					"hasNext":      false,
+					"totalReviews": len(list),
+				},
+			}})
+
+		case "ShopInfoCore":
+			writeJSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+				"shopInfoByID": map[string]any{"result": []any{map[string]any{
+					"shopCore":   map[string]string{"shopID": "777", "name": "Toko Audio", "domain": fakeShopDomain},
+					"shopAssets": map[string]string{"avatar": "https://images.tokopedia.net/img/seller/tokoaudio.png"},
+				}}},
+			}})
+
+		case "Ping":
+			writeJSON(w, http.StatusOK, map[string]any{"data": map[string]string{"__typename": "Query"}})
+
+		default:
+			t.Errorf("fake tokopedia: unexpected operation %q", req.OperationName)
+			writeJSON(w, http.StatusOK, map[string]any{"errors": []any{map[string]string{"message": "unknown operation"}}})
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server, calls
+}
+
+func fakePDPLayout() map[string]any {
+	return map[string]any{"data": map[string]any{"pdpGetLayout": map[string]any{
+		"basicInfo": map[string]any{
+			"id":       fakeProductID,
+			"shopName": "Toko Audio",
+			"category": map[string]any{"id": "3712", "name": "Earphone", "detail": []any{
+				map[string]string{"id": "65", "name": "Audio"},
+				map[string]string{"id": "3712", "name": "Earphone"},
+			}},
+			"txStats": map[string]any{"countSold": "1520"},
+			"stats":   map[string]any{"countReview": "6", "rating": 3.7},
+		},
+		"components": []any{
+			map[string]any{"name": "product_content", "data": []any{map[string]any{
+				"__typename": "pdpDataProductContent",
+				"name":       fakeProductName,
+				"price":      map[string]any{"value": 189000, "priceFmt": "Rp189.000", "slashPriceFmt": "", "discPercentage": "0"},
+				"stock":      map[string]any{"value": "320"},
+			}}},
+			map[string]any{"name": "product_media", "data": []any{map[string]any{
+				"__typename": "pdpDataProductMedia",
+				"media":      []any{map[string]string{"type": "image", "urlOriginal": "https://images.tokopedia.net/img/tws-1.jpg"}},
+			}}},
+			map[string]any{"name": "product_detail", "data": []any{map[string]any{
+				"__typename": "pdpDataProductDetail",
+				"content": []any{
+					map[string]string{"title": "Kondisi", "subtitle": "Baru"},
+					map[string]string{"title": "Deskripsi", "subtitle": "Earphone TWS dengan Bluetooth 5.3."},
+				},
+			}}},
+		},
+	}}}
+}
+
+// newFakeModel serves the ML /predict endpoint, labelling a statement
+// negative when it has a complaint keyword.
+func newFakeModel(t *testing.T, apiKey string) (*httptest.Server, *recorder) {
+	calls := &recorder{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/predict" || r.Header.Get("api-key") != apiKey {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"detail": "unauthorized"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"detail": "method not allowed"})
+			return
+		}
+
+		var req dto.PredictRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"detail": err.Error()})
+			return
+		}
+		calls.record("predict")
+
+		// The deployed service only counts the statements of a batch
+		res := map[string]int{"Negative": 0, "Positive": 0}
+		for _, statement := range req.Statements {
+			if strings.Contains(statement, "kecewa") || strings.Contains(statement, "rusak") {
+				res["Negative"]++
+			} else {
+				res["Positive"]++
+			}
+		}
+
+		writeJSON(w, http.StatusOK, res)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, calls
+}
+
+// newFakeLLM serves an OpenAI-compatible chat completions endpoint. The
+// operation is recognised from the system prompt and answered by the
+// deterministic fake provider.
+func newFakeLLM(t *testing.T) (*httptest.Server, *recorder) {
+	calls := &recorder{}
+	provider := service.NewFakeLLMProvider()
+
+	operations := []struct {
+		prompt    string
+		operation string
+	}{
+		{constants.PROMPT_ANALYZE, dto.LLM_OPERATION_ANALYZE},
+		{constants.PROMPT_SUMMARIZE, dto.LLM_OPERATION_SUMMARIZE},
+		{constants.PROMPT_SUMMARIZE_STREAM, dto.LLM_OPERATION_SUMMARIZE},
+		{constants.PROMPT_COMPARE, dto.LLM_OPERATION_COMPARE},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/models" {
+			writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": []any{}})
+			return
+		}
+		if r.URL.Path != "/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+
+		var req dto.OpenAIChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) != 2 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "bad request"})
+			return
+		}
+		if req.Stream {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "streaming is not faked"})
+			return
+		}
+
+		llmReq := dto.LLMRequest{
+			Input: req.Messages[1].Content,
+			JSON:  req.ResponseFormat != nil,
+		}
+		for _, candidate := range operations {
+			if req.Messages[0].Content == candidate.prompt {
+				llmReq.Operation = candidate.operation
+				break
+			}
+		}
+		calls.record(llmReq.Operation)
+
+		content, err := provider.Generate(r.Context(), llmReq)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		writeJSON(w, http.StatusOK, map[string]any{
+			"choices": []any{map[string]any{
+				"message": dto.OpenAIChatMessage{Role: "assistant", Content: content},
+			}},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	return server, calls
+}
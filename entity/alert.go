@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AlertRule notifies a user when a metric of their analyses crosses a
+// threshold or moves by more than it between two runs.
+type AlertRule struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Name      string    `json:"name" gorm:"not null"`
+	Provider  string    `json:"provider"`
+	ProductID string    `json:"product_id" gorm:"index"`
+	Metric    string    `json:"metric" gorm:"not null"`
+	Condition string    `json:"condition" gorm:"not null"`
+	Threshold float64   `json:"threshold" gorm:"not null"`
+	Channel   string    `json:"channel" gorm:"not null"`
+	Target    string    `json:"target" gorm:"not null"`
+	Secret    string    `json:"-"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	UserID    uuid.UUID `json:"user_id" gorm:"type:uuid;not null;index"`
+	User      User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+
+	Timestamp
+}
+
+func (a *AlertRule) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.UserID == uuid.Nil {
+		return gorm.ErrEmptySlice
+	}
+	return nil
+}
+
+// AlertDelivery is one notification sent, or still to be sent, for a
+// triggered rule.
+type AlertDelivery struct {
+	ID            uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Channel       string     `json:"channel" gorm:"not null"`
+	Target        string     `json:"target" gorm:"not null"`
+	Payload       string     `json:"-" gorm:"type:text;not null"`
+	Status        string     `json:"status" gorm:"not null;index"`
+	Attempts      int        `json:"attempts" gorm:"not null;default:0"`
+	LastError     string     `json:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"not null;index"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	AlertRuleID   uuid.UUID  `json:"alert_rule_id" gorm:"type:uuid;not null;index"`
+	AlertRule     AlertRule  `json:"-" gorm:"foreignKey:AlertRuleID;constraint:OnDelete:CASCADE;"`
+	HistoryID     uuid.UUID  `json:"history_id" gorm:"type:uuid;not null"`
+
+	Timestamp
+}
@@ -0,0 +1,44 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AnalysisJob is a queued product or shop analysis. For shop jobs
+// ProductURL holds the shop page and ReviewOptions the encoded shop options.
+type AnalysisJob struct {
+	ID              uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Kind            string     `json:"kind" gorm:"not null;default:product"`
+	ProductURL      string     `json:"product_url" gorm:"not null"`
+	ReviewOptions   string     `json:"-" gorm:"type:text"`
+	Status          string     `json:"status" gorm:"not null;index"`
+	ProductStatus   string     `json:"product_status" gorm:"not null"`
+	ReviewsStatus   string     `json:"reviews_status" gorm:"not null"`
+	PredictStatus   string     `json:"predict_status" gorm:"not null"`
+	AnalyzeStatus   string     `json:"analyze_status" gorm:"not null"`
+	SummarizeStatus string     `json:"summarize_status" gorm:"not null"`
+	Error           string     `json:"error"`
+	Result          string     `json:"-" gorm:"type:text"`
+	Attempts        int        `json:"attempts" gorm:"not null;default:0"`
+	StartedAt       *time.Time `json:"started_at"`
+	// HeartbeatAt is refreshed by the worker running the job, so a job is
+	// only requeued once its worker stopped reporting.
+	HeartbeatAt *time.Time `json:"-" gorm:"index"`
+	FinishedAt  *time.Time `json:"finished_at"`
+	HistoryID   *uuid.UUID `json:"history_id" gorm:"type:uuid"`
+	WatchlistID *uuid.UUID `json:"watchlist_id" gorm:"type:uuid;index"`
+	UserID      uuid.UUID  `json:"user_id" gorm:"not null"`
+	User        User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+
+	Timestamp
+}
+
+func (j *AnalysisJob) BeforeCreate(tx *gorm.DB) (err error) {
+	if j.UserID == uuid.Nil {
+		return gorm.ErrEmptySlice
+	}
+	return nil
+}
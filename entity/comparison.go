@@ -0,0 +1,35 @@
+package entity
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Comparison is a saved side-by-side comparison of several analyses.
+type Comparison struct {
+	ID      uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Verdict string           `json:"verdict" gorm:"type:text;not null"`
+	UserID  uuid.UUID        `json:"user_id" gorm:"type:uuid;not null;index"`
+	User    User             `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+	Items   []ComparisonItem `json:"items" gorm:"foreignKey:ComparisonID"`
+
+	Timestamp
+}
+
+func (c *Comparison) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.UserID == uuid.Nil {
+		return gorm.ErrEmptySlice
+	}
+	return nil
+}
+
+// ComparisonItem links a comparison to the analysis of one of its products,
+// in the order the products were submitted.
+type ComparisonItem struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Position     int        `json:"position" gorm:"not null"`
+	ComparisonID uuid.UUID  `json:"comparison_id" gorm:"type:uuid;not null;index"`
+	Comparison   Comparison `json:"-" gorm:"foreignKey:ComparisonID;constraint:OnDelete:CASCADE;"`
+	HistoryID    uuid.UUID  `json:"history_id" gorm:"type:uuid;not null"`
+	History      History    `json:"-" gorm:"foreignKey:HistoryID;constraint:OnDelete:CASCADE;"`
+}
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Review is a single marketplace review, stored once per provider and
+// product regardless of how many analyses used it.
+type Review struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Provider   string    `json:"provider" gorm:"not null;uniqueIndex:idx_reviews_source"`
+	ProductID  string    `json:"product_id" gorm:"not null;uniqueIndex:idx_reviews_source"`
+	ExternalID string    `json:"external_id" gorm:"not null;uniqueIndex:idx_reviews_source"`
+	Message    string    `json:"message" gorm:"type:text;not null"`
+	Rating     int       `json:"rating" gorm:"not null"`
+	Variant    string    `json:"variant"`
+	HasMedia   bool      `json:"has_media" gorm:"not null;default:false"`
+	Reviewer   string    `json:"reviewer"`
+	ReviewedAt time.Time `json:"reviewed_at" gorm:"index"`
+
+	Timestamp
+}
+
+// ReviewSentiment is the classification of a review produced by one
+// analysis run.
+type ReviewSentiment struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Label           string    `json:"label" gorm:"index"`
+	Confidence      *float32  `json:"confidence"`
+	Aspect          string    `json:"aspect" gorm:"index"`
+	AspectSentiment string    `json:"aspect_sentiment"`
+	ModelVersion    string    `json:"model_version"`
+	ReviewID        uuid.UUID `json:"review_id" gorm:"type:uuid;not null;uniqueIndex:idx_review_sentiments_history_review"`
+	Review          Review    `json:"-" gorm:"foreignKey:ReviewID;constraint:OnDelete:CASCADE;"`
+	HistoryID       uuid.UUID `json:"history_id" gorm:"type:uuid;not null;uniqueIndex:idx_review_sentiments_history_review"`
+	History         History   `json:"-" gorm:"foreignKey:HistoryID;constraint:OnDelete:CASCADE;"`
+
+	Timestamp
+}
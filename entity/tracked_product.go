@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TrackedProduct groups every analysis snapshot a user has taken of one
+// marketplace product.
+type TrackedProduct struct {
+	ID             uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	Provider       string     `json:"provider" gorm:"not null;uniqueIndex:idx_tracked_products_user_product"`
+	ProductID      string     `json:"product_id" gorm:"not null;uniqueIndex:idx_tracked_products_user_product"`
+	URL            string     `json:"url" gorm:"not null"`
+	ProductName    string     `json:"product_name" gorm:"not null"`
+	LastAnalysedAt *time.Time `json:"last_analysed_at"`
+	UserID         uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;uniqueIndex:idx_tracked_products_user_product"`
+	User           User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+
+	Timestamp
+}
@@ -0,0 +1,31 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Watchlist is a product a user wants re-analysed on a cron schedule.
+type Watchlist struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:uuid_generate_v4()"`
+	ProductURL string     `json:"product_url" gorm:"not null"`
+	Schedule   string     `json:"schedule" gorm:"not null"`
+	Active     bool       `json:"active" gorm:"not null;default:true"`
+	NextRunAt  time.Time  `json:"next_run_at" gorm:"not null;index"`
+	LastRunAt  *time.Time `json:"last_run_at"`
+	LastJobID  *uuid.UUID `json:"last_job_id" gorm:"type:uuid"`
+	LastError  string     `json:"last_error"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;not null;index"`
+	User       User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;"`
+
+	Timestamp
+}
+
+func (w *Watchlist) BeforeCreate(tx *gorm.DB) (err error) {
+	if w.UserID == uuid.Nil {
+		return gorm.ErrEmptySlice
+	}
+	return nil
+}
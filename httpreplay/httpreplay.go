@@ -0,0 +1,245 @@
+// Package httpreplay records GraphQL requests and their responses as golden
+// files and plays them back, so clients of third-party gateways can be
+// tested offline. Re-recording against the live gateway and diffing the
+// golden files shows when the upstream schema drifted.
+package httpreplay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+const (
+	ModeReplay Mode = iota
+	ModeRecord
+)
+
+var (
+	ErrNoRecording = errors.New("no recording for request")
+
+	unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+)
+
+type (
+	// Mode selects whether the transport serves golden files or creates them.
+	Mode int
+
+	// Interaction is one request/response pair as stored on disk. Bodies
+	// that are JSON are kept as JSON so the files read and diff well.
+	Interaction struct {
+		Request  RecordedRequest  `json:"request"`
+		Response RecordedResponse `json:"response"`
+	}
+
+	RecordedRequest struct {
+		Method        string          `json:"method"`
+		OperationName string          `json:"operationName,omitempty"`
+		Variables     json.RawMessage `json:"variables,omitempty"`
+	}
+
+	RecordedResponse struct {
+		Status  int             `json:"status"`
+		Body    json.RawMessage `json:"body,omitempty"`
+		RawBody string          `json:"raw_body,omitempty"`
+	}
+
+	transport struct {
+		dir  string
+		mode Mode
+		real http.RoundTripper
+		mu   sync.Mutex
+	}
+
+	graphQLRequest struct {
+		OperationName string          `json:"operationName"`
+		Variables     json.RawMessage `json:"variables"`
+		Query         string          `json:"query"`
+	}
+)
+
+// NewTransport serves requests from the golden files in dir, or, in
+// ModeRecord, sends them through real and writes what comes back to dir.
+// A nil real means http.DefaultTransport.
+func NewTransport(dir string, mode Mode, real http.RoundTripper) http.RoundTripper {
+	if real == nil {
+		real = http.DefaultTransport
+	}
+
+	return &transport{
+		dir:  dir,
+		mode: mode,
+		real: real,
+	}
+}
+
+// ModeFromEnv returns ModeRecord when the variable name is set to a
+// non-empty value, so tests can be re-recorded with e.g. RECORD=1.
+func ModeFromEnv(name string) Mode {
+	if os.Getenv(name) != "" {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	recorded, name := describeRequest(req, body)
+	path := filepath.Join(t.dir, name+".json")
+
+	if t.mode == ModeRecord {
+		return t.record(req, body, recorded, path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s %s (expected %s)", ErrNoRecording, recorded.Method, recorded.OperationName, path)
+		}
+		return nil, err
+	}
+
+	var interaction Interaction
+	if err := json.Unmarshal(data, &interaction); err != nil {
+		return nil, fmt.Errorf("httpreplay: %s: %w", path, err)
+	}
+
+	return newResponse(req, interaction.Response), nil
+}
+
+func (t *transport) record(req *http.Request, body []byte, recorded RecordedRequest, path string) (*http.Response, error) {
+	outgoing := req.Clone(req.Context())
+	outgoing.Body = io.NopCloser(bytes.NewReader(body))
+	outgoing.ContentLength = int64(len(body))
+
+	res, err := t.real.RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	response := RecordedResponse{Status: res.StatusCode}
+	if json.Valid(resBody) {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, resBody, "", "  "); err != nil {
+			return nil, err
+		}
+		response.Body = indented.Bytes()
+	} else {
+		response.RawBody = string(resBody)
+	}
+
+	// HTML error pages from the gateway stay readable in the golden file.
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(Interaction{Request: recorded, Response: response}); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := os.MkdirAll(t.dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(path, data.Bytes(), 0o644); err != nil {
+		return nil, err
+	}
+
+	return newResponse(req, response), nil
+}
+
+// describeRequest names the golden file of a request. GraphQL requests are
+// named after their operation and a hash of the variables and query, so
+// the same operation with different arguments, e.g. another review page,
+// gets its own file. Anything else falls back to method and URL.
+func describeRequest(req *http.Request, body []byte) (RecordedRequest, string) {
+	recorded := RecordedRequest{Method: req.Method}
+
+	var operation graphQLRequest
+	if err := json.Unmarshal(body, &operation); err == nil && operation.OperationName != "" {
+		recorded.OperationName = operation.OperationName
+		recorded.Variables = canonicalJSON(operation.Variables)
+		return recorded, sanitizeName(operation.OperationName) + "-" + shortHash(operation.OperationName, string(recorded.Variables), operation.Query)
+	}
+
+	url := req.URL.String()
+	return recorded, strings.ToLower(req.Method) + "-" + shortHash(url, string(body))
+}
+
+// canonicalJSON re-encodes raw so that key order and whitespace do not
+// change the hash of otherwise equal variables.
+func canonicalJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	var value any
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return raw
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return raw
+	}
+	return encoded
+}
+
+func shortHash(parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:4])
+}
+
+func sanitizeName(name string) string {
+	return unsafeNameChars.ReplaceAllString(name, "_")
+}
+
+func newResponse(req *http.Request, recorded RecordedResponse) *http.Response {
+	body := []byte(recorded.RawBody)
+	if len(recorded.Body) > 0 {
+		body = recorded.Body
+	}
+
+	header := make(http.Header)
+	if len(recorded.Body) > 0 {
+		header.Set("Content-Type", "application/json")
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		StatusCode:    recorded.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,112 @@
+package httpreplay
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func post(t *testing.T, client *http.Client, url string, body string) (int, string, error) {
+	t.Helper()
+
+	res, err := client.Post(url, "application/json", strings.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	defer res.Body.Close()
+
+	data, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	return res.StatusCode, string(data), nil
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"page":2`) {
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte("<html>bad gateway</html>"))
+			return
+		}
+		w.Write([]byte(`{"data":{"page":1}}`))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	recorder := &http.Client{Transport: NewTransport(dir, ModeRecord, nil)}
+	pageOne := `{"operationName":"list","variables":{"page":1,"limit":50},"query":"q"}`
+	pageTwo := `{"operationName":"list","variables":{"page":2,"limit":50},"query":"q"}`
+
+	if _, _, err := post(t, recorder, upstream.URL, pageOne); err != nil {
+		t.Fatalf("record page one: %v", err)
+	}
+	if _, _, err := post(t, recorder, upstream.URL, pageTwo); err != nil {
+		t.Fatalf("record page two: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 2 {
+		t.Fatalf("recorded %d files, err %v; want one per set of variables", len(entries), err)
+	}
+
+	upstream.Close()
+	replayer := &http.Client{Transport: NewTransport(dir, ModeReplay, nil)}
+
+	// Key order in the variables does not matter.
+	status, body, err := post(t, replayer, "http://gateway.invalid/graphql", `{"operationName":"list","variables":{"limit":50,"page":1},"query":"q"}`)
+	if err != nil {
+		t.Fatalf("replay page one: %v", err)
+	}
+	if status != http.StatusOK || !strings.Contains(body, `"page": 1`) {
+		t.Errorf("page one = %d %q", status, body)
+	}
+
+	status, body, err = post(t, replayer, "http://gateway.invalid/graphql", pageTwo)
+	if err != nil {
+		t.Fatalf("replay page two: %v", err)
+	}
+	if status != http.StatusBadGateway || body != "<html>bad gateway</html>" {
+		t.Errorf("page two = %d %q", status, body)
+	}
+
+	if calls.Load() != 2 {
+		t.Errorf("upstream called %d times, want 2", calls.Load())
+	}
+}
+
+func TestReplayMissingRecording(t *testing.T) {
+	client := &http.Client{Transport: NewTransport(t.TempDir(), ModeReplay, nil)}
+
+	_, _, err := post(t, client, "http://gateway.invalid/graphql", `{"operationName":"list","variables":{"page":3},"query":"q"}`)
+	if !errors.Is(err, ErrNoRecording) {
+		t.Fatalf("err = %v, want %v", err, ErrNoRecording)
+	}
+}
+
+func TestChangedQueryNeedsNewRecording(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	recorder := &http.Client{Transport: NewTransport(dir, ModeRecord, nil)}
+	if _, _, err := post(t, recorder, upstream.URL, `{"operationName":"list","variables":{},"query":"q"}`); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	replayer := &http.Client{Transport: NewTransport(dir, ModeReplay, nil)}
+	_, _, err := post(t, replayer, upstream.URL, `{"operationName":"list","variables":{},"query":"q { more }"}`)
+	if !errors.Is(err, ErrNoRecording) {
+		t.Fatalf("err = %v, want %v", err, ErrNoRecording)
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/service"
+	"review_product_tokopedia_be/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets the request through only when the user set by
+// Authenticate has role. The role is read from the database rather than
+// the token, so a demoted user loses access straight away.
+func RequireRole(userService service.UserService, role string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		userId := ctx.MustGet("user_id").(string)
+		user, err := userService.GetUserById(ctx.Request.Context(), userId)
+		if err != nil {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, err.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, response)
+			return
+		}
+		if user.Role != role {
+			response := utils.BuildResponseFailed(dto.MESSAGE_FAILED_DENIED_ACCESS, dto.ErrUserNotAdmin.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusForbidden, response)
+			return
+		}
+		ctx.Next()
+	}
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	AlertRepository interface {
+		CreateRule(ctx context.Context, tx *gorm.DB, rule entity.AlertRule) (entity.AlertRule, error)
+		GetRules(ctx context.Context, tx *gorm.DB, userId string) ([]entity.AlertRule, error)
+		GetRuleById(ctx context.Context, tx *gorm.DB, ruleId string, userId string) (entity.AlertRule, error)
+		UpdateRule(ctx context.Context, tx *gorm.DB, ruleId string, fields map[string]any) error
+		// DeleteRule soft deletes the rule and cancels its pending
+		// deliveries, which would otherwise never be claimed.
+		DeleteRule(ctx context.Context, tx *gorm.DB, ruleId string, userId string) error
+
+		// GetActiveRulesForProduct returns the user's active rules that either
+		// target the product or have no product scope at all.
+		GetActiveRulesForProduct(ctx context.Context, tx *gorm.DB, userId string, provider string, productId string) ([]entity.AlertRule, error)
+
+		CreateDeliveries(ctx context.Context, tx *gorm.DB, deliveries []entity.AlertDelivery) error
+		GetDeliveries(ctx context.Context, tx *gorm.DB, ruleId string, limit int) ([]entity.AlertDelivery, error)
+		UpdateDelivery(ctx context.Context, tx *gorm.DB, deliveryId string, fields map[string]any) error
+
+		// ClaimDueDelivery locks the oldest pending delivery of a rule that
+		// still exists and is due, and pushes its next attempt back by lease,
+		// so other replicas skip it while it is being sent.
+		ClaimDueDelivery(ctx context.Context, tx *gorm.DB, now time.Time, lease time.Duration) (entity.AlertDelivery, error)
+	}
+
+	alertRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewAlertRepository(db *gorm.DB) AlertRepository {
+	return &alertRepository{
+		db: db,
+	}
+}
+
+func (r *alertRepository) CreateRule(ctx context.Context, tx *gorm.DB, rule entity.AlertRule) (entity.AlertRule, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&rule).Error; err != nil {
+		return entity.AlertRule{}, err
+	}
+
+	return rule, nil
+}
+
+func (r *alertRepository) GetRules(ctx context.Context, tx *gorm.DB, userId string) ([]entity.AlertRule, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var rules []entity.AlertRule
+	err := tx.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Order("created_at desc").
+		Find(&rules).Error
+	if err != nil {
+		return []entity.AlertRule{}, err
+	}
+
+	return rules, nil
+}
+
+func (r *alertRepository) GetRuleById(ctx context.Context, tx *gorm.DB, ruleId string, userId string) (entity.AlertRule, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var rule entity.AlertRule
+	err := tx.WithContext(ctx).
+		Where("id = ?", ruleId).
+		Where("user_id = ?", userId).
+		Take(&rule).Error
+	if err != nil {
+		return entity.AlertRule{}, err
+	}
+
+	return rule, nil
+}
+
+func (r *alertRepository) UpdateRule(ctx context.Context, tx *gorm.DB, ruleId string, fields map[string]any) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.AlertRule{}).
+		Where("id = ?", ruleId).
+		Updates(fields).Error
+}
+
+func (r *alertRepository) DeleteRule(ctx context.Context, tx *gorm.DB, ruleId string, userId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&entity.AlertRule{}, "id = ? AND user_id = ?", ruleId, userId)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return tx.Model(&entity.AlertDelivery{}).
+			Where("alert_rule_id = ?", ruleId).
+			Where("status = ?", constants.ENUM_DELIVERY_STATUS_PENDING).
+			Update("status", constants.ENUM_DELIVERY_STATUS_CANCELLED).Error
+	})
+}
+
+func (r *alertRepository) GetActiveRulesForProduct(ctx context.Context, tx *gorm.DB, userId string, provider string, productId string) ([]entity.AlertRule, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var rules []entity.AlertRule
+	err := tx.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Where("active = ?", true).
+		Where("(product_id = '' OR (provider = ? AND product_id = ?))", provider, productId).
+		Find(&rules).Error
+	if err != nil {
+		return []entity.AlertRule{}, err
+	}
+
+	return rules, nil
+}
+
+func (r *alertRepository) CreateDeliveries(ctx context.Context, tx *gorm.DB, deliveries []entity.AlertDelivery) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	return tx.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (r *alertRepository) GetDeliveries(ctx context.Context, tx *gorm.DB, ruleId string, limit int) ([]entity.AlertDelivery, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var deliveries []entity.AlertDelivery
+	err := tx.WithContext(ctx).
+		Where("alert_rule_id = ?", ruleId).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return []entity.AlertDelivery{}, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *alertRepository) UpdateDelivery(ctx context.Context, tx *gorm.DB, deliveryId string, fields map[string]any) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.AlertDelivery{}).
+		Where("id = ?", deliveryId).
+		Updates(fields).Error
+}
+
+func (r *alertRepository) ClaimDueDelivery(ctx context.Context, tx *gorm.DB, now time.Time, lease time.Duration) (entity.AlertDelivery, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var delivery entity.AlertDelivery
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Only the delivery row is locked, the rule is merely read.
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "alert_deliveries"}, Options: "SKIP LOCKED"}).
+			Joins("JOIN alert_rules ON alert_rules.id = alert_deliveries.alert_rule_id AND alert_rules.deleted_at IS NULL").
+			Where("alert_deliveries.status = ?", constants.ENUM_DELIVERY_STATUS_PENDING).
+			Where("alert_deliveries.next_attempt_at <= ?", now).
+			Order("alert_deliveries.next_attempt_at asc").
+			Take(&delivery).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Take(&delivery.AlertRule, "id = ?", delivery.AlertRuleID).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&delivery).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return entity.AlertDelivery{}, err
+	}
+
+	return delivery, nil
+}
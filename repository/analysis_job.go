@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	AnalysisJobRepository interface {
+		CreateJob(ctx context.Context, tx *gorm.DB, job entity.AnalysisJob) (entity.AnalysisJob, error)
+		GetJobById(ctx context.Context, tx *gorm.DB, jobId string, userId string) (entity.AnalysisJob, error)
+		ClaimNextJob(ctx context.Context, tx *gorm.DB) (entity.AnalysisJob, error)
+		UpdateJob(ctx context.Context, tx *gorm.DB, job entity.AnalysisJob, fields map[string]any) error
+		Heartbeat(ctx context.Context, tx *gorm.DB, job entity.AnalysisJob) error
+		RequeueStaleJobs(ctx context.Context, tx *gorm.DB, heartbeatBefore time.Time) (int64, error)
+		CountWatchlistJobsSince(ctx context.Context, tx *gorm.DB, userId string, since time.Time) (int64, error)
+	}
+
+	analysisJobRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewAnalysisJobRepository(db *gorm.DB) AnalysisJobRepository {
+	return &analysisJobRepository{
+		db: db,
+	}
+}
+
+func (r *analysisJobRepository) CreateJob(ctx context.Context, tx *gorm.DB, job entity.AnalysisJob) (entity.AnalysisJob, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&job).Error; err != nil {
+		return entity.AnalysisJob{}, err
+	}
+
+	return job, nil
+}
+
+func (r *analysisJobRepository) GetJobById(ctx context.Context, tx *gorm.DB, jobId string, userId string) (entity.AnalysisJob, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var job entity.AnalysisJob
+	err := tx.WithContext(ctx).
+		Where("id = ?", jobId).
+		Where("user_id = ?", userId).
+		Take(&job).Error
+	if err != nil {
+		return entity.AnalysisJob{}, err
+	}
+
+	return job, nil
+}
+
+// ClaimNextJob marks the oldest queued job as running and returns it. Rows
+// locked by other workers are skipped so several replicas can poll the same
+// table. gorm.ErrRecordNotFound is returned when the queue is empty.
+func (r *analysisJobRepository) ClaimNextJob(ctx context.Context, tx *gorm.DB) (entity.AnalysisJob, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var job entity.AnalysisJob
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", constants.ENUM_JOB_STATUS_QUEUED).
+			Order("created_at asc").
+			Take(&job).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		job.Status = constants.ENUM_JOB_STATUS_RUNNING
+		job.Attempts++
+		job.StartedAt = &now
+		job.HeartbeatAt = &now
+
+		return tx.Model(&job).Updates(map[string]any{
+			"status":       job.Status,
+			"attempts":     job.Attempts,
+			"started_at":   job.StartedAt,
+			"heartbeat_at": job.HeartbeatAt,
+		}).Error
+	})
+	if err != nil {
+		return entity.AnalysisJob{}, err
+	}
+
+	return job, nil
+}
+
+// UpdateJob updates a job claimed by ClaimNextJob. The attempt number
+// identifies the claim, so once the job was requeued and claimed again the
+// earlier worker's updates return gorm.ErrRecordNotFound instead of
+// overwriting the new run.
+func (r *analysisJobRepository) UpdateJob(ctx context.Context, tx *gorm.DB, job entity.AnalysisJob, fields map[string]any) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	res := tx.WithContext(ctx).
+		Model(&entity.AnalysisJob{}).
+		Where("id = ?", job.ID).
+		Where("status = ?", constants.ENUM_JOB_STATUS_RUNNING).
+		Where("attempts = ?", job.Attempts).
+		Updates(fields)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Heartbeat records that the worker holding the claim on job is still
+// running it. It returns gorm.ErrRecordNotFound once the claim is lost.
+func (r *analysisJobRepository) Heartbeat(ctx context.Context, tx *gorm.DB, job entity.AnalysisJob) error {
+	return r.UpdateJob(ctx, tx, job, map[string]any{"heartbeat_at": time.Now()})
+}
+
+// RequeueStaleJobs puts running jobs whose worker has not sent a heartbeat
+// since heartbeatBefore back in the queue, e.g. after the worker died
+// mid-run, and resets their stage progress.
+func (r *analysisJobRepository) RequeueStaleJobs(ctx context.Context, tx *gorm.DB, heartbeatBefore time.Time) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	res := tx.WithContext(ctx).
+		Model(&entity.AnalysisJob{}).
+		Where("status = ?", constants.ENUM_JOB_STATUS_RUNNING).
+		Where("COALESCE(heartbeat_at, started_at) < ?", heartbeatBefore).
+		Updates(map[string]any{
+			"status":           constants.ENUM_JOB_STATUS_QUEUED,
+			"heartbeat_at":     nil,
+			"product_status":   constants.ENUM_STAGE_STATUS_PENDING,
+			"reviews_status":   constants.ENUM_STAGE_STATUS_PENDING,
+			"predict_status":   constants.ENUM_STAGE_STATUS_PENDING,
+			"analyze_status":   constants.ENUM_STAGE_STATUS_PENDING,
+			"summarize_status": constants.ENUM_STAGE_STATUS_PENDING,
+		})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+
+	return res.RowsAffected, nil
+}
+
+// CountWatchlistJobsSince counts the jobs the scheduler enqueued for a user
+// from since onwards.
+func (r *analysisJobRepository) CountWatchlistJobsSince(ctx context.Context, tx *gorm.DB, userId string, since time.Time) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	err := tx.WithContext(ctx).
+		Model(&entity.AnalysisJob{}).
+		Where("user_id = ?", userId).
+		Where("watchlist_id IS NOT NULL").
+		Where("created_at >= ?", since).
+		Count(&count).Error
+
+	return count, err
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+
+	"review_product_tokopedia_be/entity"
+
+	"gorm.io/gorm"
+)
+
+type (
+	ComparisonRepository interface {
+		CreateComparison(ctx context.Context, tx *gorm.DB, comparison entity.Comparison) (entity.Comparison, error)
+		GetComparisons(ctx context.Context, tx *gorm.DB, userId string) ([]entity.Comparison, error)
+		GetComparisonById(ctx context.Context, tx *gorm.DB, comparisonId string, userId string) (entity.Comparison, error)
+	}
+
+	comparisonRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewComparisonRepository(db *gorm.DB) ComparisonRepository {
+	return &comparisonRepository{
+		db: db,
+	}
+}
+
+func (r *comparisonRepository) CreateComparison(ctx context.Context, tx *gorm.DB, comparison entity.Comparison) (entity.Comparison, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&comparison).Error; err != nil {
+		return entity.Comparison{}, err
+	}
+
+	return comparison, nil
+}
+
+func (r *comparisonRepository) GetComparisons(ctx context.Context, tx *gorm.DB, userId string) ([]entity.Comparison, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var comparisons []entity.Comparison
+	err := tx.WithContext(ctx).
+		Preload("Items", preloadComparisonItems).
+		Preload("Items.History").
+		Where("user_id = ?", userId).
+		Order("created_at desc").
+		Find(&comparisons).Error
+	if err != nil {
+		return []entity.Comparison{}, err
+	}
+
+	return comparisons, nil
+}
+
+func (r *comparisonRepository) GetComparisonById(ctx context.Context, tx *gorm.DB, comparisonId string, userId string) (entity.Comparison, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var comparison entity.Comparison
+	err := tx.WithContext(ctx).
+		Preload("Items", preloadComparisonItems).
+		Preload("Items.History").
+		Where("id = ?", comparisonId).
+		Where("user_id = ?", userId).
+		Take(&comparison).Error
+	if err != nil {
+		return entity.Comparison{}, err
+	}
+
+	return comparison, nil
+}
+
+func preloadComparisonItems(db *gorm.DB) *gorm.DB {
+	return db.Order("position asc")
+}
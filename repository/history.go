@@ -15,8 +15,9 @@ type (
 		CreateHistory(ctx context.Context, tx *gorm.DB, history entity.History) (entity.History, error)
 		GetHistories(ctx context.Context, tx *gorm.DB, dto dto.HistoriesGetRequest, userId string) ([]entity.History, int64, error)
 		GetHistoryById(ctx context.Context, tx *gorm.DB, historyId string, userId string) (entity.History, error)
-		CheckByProductId(ctx context.Context, tx *gorm.DB, productId string, userId string) bool
-		DeleteByProductId(ctx context.Context, tx *gorm.DB, productId string, userId string) error
+		GetLatestByProductId(ctx context.Context, tx *gorm.DB, provider string, productId string, userId string) (entity.History, error)
+		GetSnapshots(ctx context.Context, tx *gorm.DB, trackedProductId string) ([]entity.History, error)
+		GetPreviousSnapshot(ctx context.Context, tx *gorm.DB, historyId string) (entity.History, error)
 	}
 
 	historyRepository struct {
@@ -98,36 +99,62 @@ func (r *historyRepository) GetHistoryById(ctx context.Context, tx *gorm.DB, his
 	return history, nil
 }
 
-func (r *historyRepository) CheckByProductId(ctx context.Context, tx *gorm.DB, productId string, userId string) bool {
+func (r *historyRepository) GetLatestByProductId(ctx context.Context, tx *gorm.DB, provider string, productId string, userId string) (entity.History, error) {
 	if tx == nil {
 		tx = r.db
 	}
 
 	var history entity.History
 	err := tx.WithContext(ctx).
+		Where("provider = ?", provider).
 		Where("product_id = ?", productId).
 		Where("user_id = ?", userId).
+		Order("created_at desc").
 		Take(&history).Error
+	if err != nil {
+		return entity.History{}, err
+	}
+
+	return history, nil
+}
+
+// GetSnapshots returns every analysis of a tracked product, oldest first.
+func (r *historyRepository) GetSnapshots(ctx context.Context, tx *gorm.DB, trackedProductId string) ([]entity.History, error) {
+	if tx == nil {
+		tx = r.db
+	}
 
+	var histories []entity.History
+	err := tx.WithContext(ctx).
+		Where("tracked_product_id = ?", trackedProductId).
+		Order("created_at asc").
+		Find(&histories).Error
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return false
-		}
-		return false
+		return []entity.History{}, err
 	}
 
-	return true
+	return histories, nil
 }
 
-func (r *historyRepository) DeleteByProductId(ctx context.Context, tx *gorm.DB, productId string, userId string) error {
+// GetPreviousSnapshot returns the analysis of the same tracked product that
+// was stored right before the given one.
+func (r *historyRepository) GetPreviousSnapshot(ctx context.Context, tx *gorm.DB, historyId string) (entity.History, error) {
 	if tx == nil {
 		tx = r.db
 	}
 
-	err := tx.WithContext(ctx).Delete(&entity.History{}, "product_id = ? AND user_id = ?", productId, userId).Error
+	trackedProductId := tx.Model(&entity.History{}).Select("tracked_product_id").Where("id = ?", historyId)
+	createdAt := tx.Model(&entity.History{}).Select("created_at").Where("id = ?", historyId)
+
+	var history entity.History
+	err := tx.WithContext(ctx).
+		Where("tracked_product_id = (?)", trackedProductId).
+		Where("created_at < (?)", createdAt).
+		Order("created_at desc").
+		Take(&history).Error
 	if err != nil {
-		return err
+		return entity.History{}, err
 	}
 
-	return nil
+	return history, nil
 }
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	ReviewRepository interface {
+		UpsertReviews(ctx context.Context, tx *gorm.DB, reviews []entity.Review) ([]entity.Review, error)
+		CreateReviewSentiments(ctx context.Context, tx *gorm.DB, sentiments []entity.ReviewSentiment) error
+		GetHistoryReviews(ctx context.Context, tx *gorm.DB, dto dto.HistoryReviewsGetRequest, historyId string) ([]dto.HistoryReviewResponse, int64, error)
+		GetReviewSentimentsByHistoryId(ctx context.Context, tx *gorm.DB, historyId string) ([]entity.ReviewSentiment, error)
+	}
+
+	reviewRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{
+		db: db,
+	}
+}
+
+// UpsertReviews inserts reviews that have not been seen before and refreshes
+// the ones that have. The returned reviews carry their stored IDs.
+func (r *reviewRepository) UpsertReviews(ctx context.Context, tx *gorm.DB, reviews []entity.Review) ([]entity.Review, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if len(reviews) == 0 {
+		return reviews, nil
+	}
+
+	err := tx.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "provider"}, {Name: "product_id"}, {Name: "external_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"message", "rating", "variant", "has_media", "reviewer", "reviewed_at", "updated_at"}),
+		}).
+		Create(&reviews).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reviews, nil
+}
+
+func (r *reviewRepository) CreateReviewSentiments(ctx context.Context, tx *gorm.DB, sentiments []entity.ReviewSentiment) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if len(sentiments) == 0 {
+		return nil
+	}
+
+	return tx.WithContext(ctx).Create(&sentiments).Error
+}
+
+func (r *reviewRepository) GetHistoryReviews(ctx context.Context, tx *gorm.DB, req dto.HistoryReviewsGetRequest, historyId string) ([]dto.HistoryReviewResponse, int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var reviews []dto.HistoryReviewResponse
+	var totalCount int64
+
+	limit := req.Limit
+	page := req.Page
+	offset := (page - 1) * limit
+
+	scope := tx.WithContext(ctx).
+		Model(&entity.ReviewSentiment{}).
+		Joins("JOIN reviews ON reviews.id = review_sentiments.review_id AND reviews.deleted_at IS NULL").
+		Where("review_sentiments.history_id = ?", historyId)
+
+	if req.Aspect != "" {
+		scope = scope.Where("review_sentiments.aspect = ?", req.Aspect)
+		if req.Sentiment != "" {
+			scope = scope.Where("review_sentiments.aspect_sentiment = ?", req.Sentiment)
+		}
+	} else if req.Sentiment != "" {
+		scope = scope.Where("review_sentiments.label = ?", req.Sentiment)
+	}
+
+	if req.Query != "" {
+		scope = scope.Where("to_tsvector('simple', reviews.message) @@ plainto_tsquery('simple', ?)", req.Query)
+	}
+
+	// Count the total number of records
+	err := scope.Session(&gorm.Session{}).Count(&totalCount).Error
+	if err != nil {
+		return []dto.HistoryReviewResponse{}, 0, err
+	}
+
+	// Query the paginated records
+	err = scope.
+		Select("reviews.id, reviews.external_id, reviews.message, reviews.rating, reviews.variant, reviews.has_media, reviews.reviewer, reviews.reviewed_at, " +
+			"review_sentiments.label, review_sentiments.confidence, review_sentiments.aspect, review_sentiments.aspect_sentiment").
+		Order("reviews.reviewed_at desc").
+		Limit(limit).Offset(offset).
+		Scan(&reviews).Error
+	if err != nil {
+		return []dto.HistoryReviewResponse{}, 0, err
+	}
+
+	return reviews, totalCount, nil
+}
+
+// GetReviewSentimentsByHistoryId returns every sentiment stored for a
+// history with its review, newest review first.
+func (r *reviewRepository) GetReviewSentimentsByHistoryId(ctx context.Context, tx *gorm.DB, historyId string) ([]entity.ReviewSentiment, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var sentiments []entity.ReviewSentiment
+	err := tx.WithContext(ctx).
+		Joins("Review").
+		Where("review_sentiments.history_id = ?", historyId).
+		Order(clause.OrderByColumn{Column: clause.Column{Table: "Review", Name: "reviewed_at"}, Desc: true}).
+		Find(&sentiments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sentiments, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"review_product_tokopedia_be/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	TrackedProductRepository interface {
+		UpsertTrackedProduct(ctx context.Context, tx *gorm.DB, product entity.TrackedProduct) (entity.TrackedProduct, error)
+		GetTrackedProduct(ctx context.Context, tx *gorm.DB, provider string, productId string, userId string) (entity.TrackedProduct, error)
+	}
+
+	trackedProductRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewTrackedProductRepository(db *gorm.DB) TrackedProductRepository {
+	return &trackedProductRepository{
+		db: db,
+	}
+}
+
+// UpsertTrackedProduct creates the tracked product or refreshes its name,
+// URL and last analysis time. The returned product carries its stored ID.
+func (r *trackedProductRepository) UpsertTrackedProduct(ctx context.Context, tx *gorm.DB, product entity.TrackedProduct) (entity.TrackedProduct, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	err := tx.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "provider"}, {Name: "product_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"url", "product_name", "last_analysed_at", "updated_at"}),
+		}).
+		Create(&product).Error
+	if err != nil {
+		return entity.TrackedProduct{}, err
+	}
+
+	return product, nil
+}
+
+// GetTrackedProduct finds a product the user tracks. An empty provider
+// matches any marketplace.
+func (r *trackedProductRepository) GetTrackedProduct(ctx context.Context, tx *gorm.DB, provider string, productId string, userId string) (entity.TrackedProduct, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	scope := tx.WithContext(ctx)
+	if provider != "" {
+		scope = scope.Where("provider = ?", provider)
+	}
+
+	var product entity.TrackedProduct
+	err := scope.
+		Where("product_id = ?", productId).
+		Where("user_id = ?", userId).
+		Order("last_analysed_at desc").
+		Take(&product).Error
+	if err != nil {
+		return entity.TrackedProduct{}, err
+	}
+
+	return product, nil
+}
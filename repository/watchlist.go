@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"review_product_tokopedia_be/entity"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	WatchlistRepository interface {
+		CreateWatchlist(ctx context.Context, tx *gorm.DB, watchlist entity.Watchlist) (entity.Watchlist, error)
+		GetWatchlists(ctx context.Context, tx *gorm.DB, userId string) ([]entity.Watchlist, error)
+		GetWatchlistById(ctx context.Context, tx *gorm.DB, watchlistId string, userId string) (entity.Watchlist, error)
+		UpdateWatchlist(ctx context.Context, tx *gorm.DB, watchlistId string, fields map[string]any) error
+		DeleteWatchlist(ctx context.Context, tx *gorm.DB, watchlistId string, userId string) error
+		CountActiveWatchlists(ctx context.Context, tx *gorm.DB, userId string) (int64, error)
+
+		// ClaimDueWatchlist locks the most overdue active item and pushes its
+		// next run back by lease, so other replicas skip it while it is being
+		// scheduled. If the claimer dies the item becomes due again once the
+		// lease runs out.
+		ClaimDueWatchlist(ctx context.Context, tx *gorm.DB, now time.Time, lease time.Duration) (entity.Watchlist, error)
+	}
+
+	watchlistRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewWatchlistRepository(db *gorm.DB) WatchlistRepository {
+	return &watchlistRepository{
+		db: db,
+	}
+}
+
+func (r *watchlistRepository) CreateWatchlist(ctx context.Context, tx *gorm.DB, watchlist entity.Watchlist) (entity.Watchlist, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	if err := tx.WithContext(ctx).Create(&watchlist).Error; err != nil {
+		return entity.Watchlist{}, err
+	}
+
+	return watchlist, nil
+}
+
+func (r *watchlistRepository) GetWatchlists(ctx context.Context, tx *gorm.DB, userId string) ([]entity.Watchlist, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var watchlists []entity.Watchlist
+	err := tx.WithContext(ctx).
+		Where("user_id = ?", userId).
+		Order("created_at desc").
+		Find(&watchlists).Error
+	if err != nil {
+		return []entity.Watchlist{}, err
+	}
+
+	return watchlists, nil
+}
+
+func (r *watchlistRepository) GetWatchlistById(ctx context.Context, tx *gorm.DB, watchlistId string, userId string) (entity.Watchlist, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var watchlist entity.Watchlist
+	err := tx.WithContext(ctx).
+		Where("id = ?", watchlistId).
+		Where("user_id = ?", userId).
+		Take(&watchlist).Error
+	if err != nil {
+		return entity.Watchlist{}, err
+	}
+
+	return watchlist, nil
+}
+
+func (r *watchlistRepository) UpdateWatchlist(ctx context.Context, tx *gorm.DB, watchlistId string, fields map[string]any) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	return tx.WithContext(ctx).
+		Model(&entity.Watchlist{}).
+		Where("id = ?", watchlistId).
+		Updates(fields).Error
+}
+
+func (r *watchlistRepository) DeleteWatchlist(ctx context.Context, tx *gorm.DB, watchlistId string, userId string) error {
+	if tx == nil {
+		tx = r.db
+	}
+
+	result := tx.WithContext(ctx).Delete(&entity.Watchlist{}, "id = ? AND user_id = ?", watchlistId, userId)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (r *watchlistRepository) CountActiveWatchlists(ctx context.Context, tx *gorm.DB, userId string) (int64, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var count int64
+	err := tx.WithContext(ctx).
+		Model(&entity.Watchlist{}).
+		Where("user_id = ?", userId).
+		Where("active = ?", true).
+		Count(&count).Error
+
+	return count, err
+}
+
+func (r *watchlistRepository) ClaimDueWatchlist(ctx context.Context, tx *gorm.DB, now time.Time, lease time.Duration) (entity.Watchlist, error) {
+	if tx == nil {
+		tx = r.db
+	}
+
+	var watchlist entity.Watchlist
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("active = ?", true).
+			Where("next_run_at <= ?", now).
+			Order("next_run_at asc").
+			Take(&watchlist).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&watchlist).Update("next_run_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return entity.Watchlist{}, err
+	}
+
+	return watchlist, nil
+}
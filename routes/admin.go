@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/controller"
+	"review_product_tokopedia_be/middleware"
+	"review_product_tokopedia_be/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Admin(route *gin.RouterGroup, healthController controller.HealthController, jwtService service.JWTService, userService service.UserService) {
+	routes := route.Group("/admin", middleware.Authenticate(jwtService), middleware.RequireRole(userService, constants.ENUM_ROLE_ADMIN))
+	{
+		routes.GET("/status", healthController.Status)
+	}
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"review_product_tokopedia_be/controller"
+	"review_product_tokopedia_be/middleware"
+	"review_product_tokopedia_be/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Alert(route *gin.RouterGroup, alertController controller.AlertController, jwtService service.JWTService) {
+	routes := route.Group("/alerts")
+	{
+		routes.POST("", middleware.Authenticate(jwtService), alertController.CreateRule)
+		routes.GET("", middleware.Authenticate(jwtService), alertController.GetRules)
+		routes.GET("/:id", middleware.Authenticate(jwtService), alertController.GetRule)
+		routes.PATCH("/:id", middleware.Authenticate(jwtService), alertController.UpdateRule)
+		routes.DELETE("/:id", middleware.Authenticate(jwtService), alertController.DeleteRule)
+		routes.GET("/:id/deliveries", middleware.Authenticate(jwtService), alertController.GetDeliveries)
+	}
+}
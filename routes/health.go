@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"review_product_tokopedia_be/controller"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Health registers the probes at the root, outside /api, where
+// orchestrators expect them.
+func Health(route *gin.Engine, healthController controller.HealthController) {
+	route.GET("/healthz", healthController.Liveness)
+	route.GET("/readyz", healthController.Readiness)
+}
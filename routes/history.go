@@ -13,5 +13,10 @@ func History(route *gin.RouterGroup, historyController controller.HistoryControl
 	{
 		routes.GET("", middleware.Authenticate(jwtService), historyController.GetHistories)
 		routes.GET("/:id", middleware.Authenticate(jwtService), historyController.GetHistory)
+		routes.GET("/:id/reviews", middleware.Authenticate(jwtService), historyController.GetHistoryReviews)
+		routes.GET("/products/:productId/snapshots", middleware.Authenticate(jwtService), historyController.GetSnapshots)
+		routes.GET("/products/:productId/diff", middleware.Authenticate(jwtService), historyController.GetSnapshotDiff)
+		routes.GET("/comparisons", middleware.Authenticate(jwtService), historyController.GetComparisons)
+		routes.GET("/comparisons/:id", middleware.Authenticate(jwtService), historyController.GetComparison)
 	}
 }
@@ -13,5 +13,11 @@ func ML(route *gin.RouterGroup, mlController controller.MLController, jwtService
 	{
 		routes.GET("/guest/analysis", mlController.GetSentimentAnalysisAndSummarizationAsGuest)
 		routes.GET("/analysis", middleware.Authenticate(jwtService), mlController.GetSentimentAnalysisAndSummarization)
+		routes.GET("/analysis/stream", middleware.Authenticate(jwtService), mlController.StreamSentimentAnalysisAndSummarization)
+		routes.POST("/jobs", middleware.Authenticate(jwtService), mlController.CreateAnalysisJob)
+		routes.GET("/jobs/:id", middleware.Authenticate(jwtService), mlController.GetAnalysisJob)
+		routes.POST("/compare", middleware.Authenticate(jwtService), mlController.CompareProducts)
+		routes.GET("/shop/analysis", middleware.Authenticate(jwtService), mlController.GetShopAnalysis)
+		routes.POST("/shop/jobs", middleware.Authenticate(jwtService), mlController.CreateShopAnalysisJob)
 	}
 }
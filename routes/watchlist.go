@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"review_product_tokopedia_be/controller"
+	"review_product_tokopedia_be/middleware"
+	"review_product_tokopedia_be/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Watchlist(route *gin.RouterGroup, watchlistController controller.WatchlistController, jwtService service.JWTService) {
+	routes := route.Group("/watchlist")
+	{
+		routes.POST("", middleware.Authenticate(jwtService), watchlistController.CreateWatchlist)
+		routes.GET("", middleware.Authenticate(jwtService), watchlistController.GetWatchlists)
+		routes.GET("/:id", middleware.Authenticate(jwtService), watchlistController.GetWatchlist)
+		routes.PATCH("/:id", middleware.Authenticate(jwtService), watchlistController.UpdateWatchlist)
+		routes.DELETE("/:id", middleware.Authenticate(jwtService), watchlistController.DeleteWatchlist)
+	}
+}
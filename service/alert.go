@@ -0,0 +1,518 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+	"review_product_tokopedia_be/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	alertPollInterval     = 10 * time.Second
+	alertClaimLease       = 2 * time.Minute
+	alertMaxAttempts      = 5
+	alertRetryBaseDelay   = time.Minute
+	alertDeliveriesLimit  = 50
+	alertWebhookSecretLen = 32
+)
+
+type (
+	AlertService interface {
+		CreateRule(ctx context.Context, req dto.AlertRuleCreateRequest, userId string) (dto.AlertRuleResponse, error)
+		GetRules(ctx context.Context, userId string) ([]dto.AlertRuleResponse, error)
+		GetRuleById(ctx context.Context, ruleId string, userId string) (dto.AlertRuleResponse, error)
+		UpdateRule(ctx context.Context, req dto.AlertRuleUpdateRequest, ruleId string, userId string) (dto.AlertRuleResponse, error)
+		DeleteRule(ctx context.Context, ruleId string, userId string) error
+		GetDeliveries(ctx context.Context, ruleId string, userId string) ([]dto.AlertDeliveryResponse, error)
+
+		// Evaluate checks the user's rules against a stored analysis and
+		// queues a delivery for every rule that fires. Delivery itself is
+		// left to the worker started by Start.
+		Evaluate(ctx context.Context, historyId string, userId string) error
+
+		// Start launches the delivery worker, which sends queued alerts and
+		// retries failed ones with exponential backoff until Stop is called.
+		Start()
+		Stop()
+	}
+
+	alertService struct {
+		alertRepo   repository.AlertRepository
+		historyRepo repository.HistoryRepository
+		notifiers   map[string]AlertNotifier
+
+		cancel context.CancelFunc
+		wg     sync.WaitGroup
+	}
+)
+
+func NewAlertService(alertRepo repository.AlertRepository, historyRepo repository.HistoryRepository, notifiers map[string]AlertNotifier) AlertService {
+	return &alertService{
+		alertRepo:   alertRepo,
+		historyRepo: historyRepo,
+		notifiers:   notifiers,
+	}
+}
+
+func (s *alertService) CreateRule(ctx context.Context, req dto.AlertRuleCreateRequest, userId string) (dto.AlertRuleResponse, error) {
+	userUUID, err := uuid.Parse(userId)
+	if err != nil {
+		return dto.AlertRuleResponse{}, dto.ErrInvalidUserId
+	}
+
+	if (req.Provider == "") != (req.ProductID == "") {
+		return dto.AlertRuleResponse{}, dto.ErrAlertProductScope
+	}
+	if err := validateAlertRule(req.Metric, req.Condition); err != nil {
+		return dto.AlertRuleResponse{}, err
+	}
+	if err := s.validateAlertTarget(req.Channel, req.Target); err != nil {
+		return dto.AlertRuleResponse{}, err
+	}
+
+	var secret string
+	if req.Channel == constants.ENUM_ALERT_CHANNEL_WEBHOOK {
+		secret, err = newWebhookSecret()
+		if err != nil {
+			return dto.AlertRuleResponse{}, dto.ErrCreateAlertRule
+		}
+	}
+
+	rule, err := s.alertRepo.CreateRule(ctx, nil, entity.AlertRule{
+		Name:      req.Name,
+		Provider:  req.Provider,
+		ProductID: req.ProductID,
+		Metric:    req.Metric,
+		Condition: req.Condition,
+		Threshold: req.Threshold,
+		Channel:   req.Channel,
+		Target:    req.Target,
+		Secret:    secret,
+		Active:    req.Active == nil || *req.Active,
+		UserID:    userUUID,
+	})
+	if err != nil {
+		return dto.AlertRuleResponse{}, dto.ErrCreateAlertRule
+	}
+
+	return toAlertRuleResponse(rule), nil
+}
+
+func (s *alertService) GetRules(ctx context.Context, userId string) ([]dto.AlertRuleResponse, error) {
+	rules, err := s.alertRepo.GetRules(ctx, nil, userId)
+	if err != nil {
+		return nil, dto.ErrGetAlertRules
+	}
+
+	res := make([]dto.AlertRuleResponse, len(rules))
+	for i, rule := range rules {
+		res[i] = toAlertRuleResponse(rule)
+	}
+
+	return res, nil
+}
+
+func (s *alertService) GetRuleById(ctx context.Context, ruleId string, userId string) (dto.AlertRuleResponse, error) {
+	rule, err := s.alertRepo.GetRuleById(ctx, nil, ruleId, userId)
+	if err != nil {
+		return dto.AlertRuleResponse{}, dto.ErrGetAlertRule
+	}
+
+	return toAlertRuleResponse(rule), nil
+}
+
+func (s *alertService) UpdateRule(ctx context.Context, req dto.AlertRuleUpdateRequest, ruleId string, userId string) (dto.AlertRuleResponse, error) {
+	rule, err := s.alertRepo.GetRuleById(ctx, nil, ruleId, userId)
+	if err != nil {
+		return dto.AlertRuleResponse{}, dto.ErrGetAlertRule
+	}
+
+	fields := map[string]any{}
+
+	if req.Name != nil {
+		fields["name"] = *req.Name
+		rule.Name = *req.Name
+	}
+	if req.Metric != nil {
+		fields["metric"] = *req.Metric
+		rule.Metric = *req.Metric
+	}
+	if req.Condition != nil {
+		fields["condition"] = *req.Condition
+		rule.Condition = *req.Condition
+	}
+	if err := validateAlertRule(rule.Metric, rule.Condition); err != nil {
+		return dto.AlertRuleResponse{}, err
+	}
+	if req.Threshold != nil {
+		fields["threshold"] = *req.Threshold
+		rule.Threshold = *req.Threshold
+	}
+	if req.Target != nil {
+		if err := s.validateAlertTarget(rule.Channel, *req.Target); err != nil {
+			return dto.AlertRuleResponse{}, err
+		}
+		fields["target"] = *req.Target
+		rule.Target = *req.Target
+	}
+	if req.Active != nil {
+		fields["active"] = *req.Active
+		rule.Active = *req.Active
+	}
+
+	if len(fields) > 0 {
+		if err := s.alertRepo.UpdateRule(ctx, nil, ruleId, fields); err != nil {
+			return dto.AlertRuleResponse{}, dto.ErrUpdateAlertRule
+		}
+	}
+
+	return toAlertRuleResponse(rule), nil
+}
+
+func (s *alertService) DeleteRule(ctx context.Context, ruleId string, userId string) error {
+	if err := s.alertRepo.DeleteRule(ctx, nil, ruleId, userId); err != nil {
+		return dto.ErrDeleteAlertRule
+	}
+	return nil
+}
+
+func (s *alertService) GetDeliveries(ctx context.Context, ruleId string, userId string) ([]dto.AlertDeliveryResponse, error) {
+	if _, err := s.alertRepo.GetRuleById(ctx, nil, ruleId, userId); err != nil {
+		return nil, dto.ErrGetAlertRule
+	}
+
+	deliveries, err := s.alertRepo.GetDeliveries(ctx, nil, ruleId, alertDeliveriesLimit)
+	if err != nil {
+		return nil, dto.ErrGetAlertDeliveries
+	}
+
+	res := make([]dto.AlertDeliveryResponse, len(deliveries))
+	for i, delivery := range deliveries {
+		res[i] = dto.AlertDeliveryResponse{
+			ID:            delivery.ID,
+			HistoryID:     delivery.HistoryID,
+			Channel:       delivery.Channel,
+			Target:        delivery.Target,
+			Status:        delivery.Status,
+			Attempts:      delivery.Attempts,
+			LastError:     delivery.LastError,
+			NextAttemptAt: delivery.NextAttemptAt,
+			DeliveredAt:   delivery.DeliveredAt,
+			CreatedAt:     delivery.CreatedAt,
+		}
+	}
+
+	return res, nil
+}
+
+func (s *alertService) Evaluate(ctx context.Context, historyId string, userId string) error {
+	history, err := s.historyRepo.GetHistoryById(ctx, nil, historyId, userId)
+	if err != nil {
+		return dto.ErrGetHistory
+	}
+
+	rules, err := s.alertRepo.GetActiveRulesForProduct(ctx, nil, userId, history.Provider, history.ProductID)
+	if err != nil {
+		return dto.ErrGetAlertRules
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var previous *entity.History
+	prev, err := s.historyRepo.GetPreviousSnapshot(ctx, nil, historyId)
+	if err == nil {
+		previous = &prev
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ErrGetSnapshots
+	}
+
+	now := time.Now()
+	var deliveries []entity.AlertDelivery
+	for _, rule := range rules {
+		current := alertMetricValue(rule.Metric, history)
+		var before *float64
+		if previous != nil {
+			value := alertMetricValue(rule.Metric, *previous)
+			before = &value
+		}
+
+		if !alertFires(rule.Condition, rule.Threshold, current, before) {
+			continue
+		}
+
+		payload, err := json.Marshal(dto.AlertPayload{
+			RuleID:    rule.ID,
+			RuleName:  rule.Name,
+			Metric:    rule.Metric,
+			Condition: rule.Condition,
+			Threshold: rule.Threshold,
+			Previous:  before,
+			Current:   current,
+			HistoryID: history.ID,
+			Product: dto.AlertProduct{
+				Provider:    history.Provider,
+				ProductID:   history.ProductID,
+				ProductName: history.ProductName,
+				URL:         history.URL,
+			},
+			TriggeredAt: now,
+		})
+		if err != nil {
+			return dto.ErrMarshallJson
+		}
+
+		deliveries = append(deliveries, entity.AlertDelivery{
+			Channel:       rule.Channel,
+			Target:        rule.Target,
+			Payload:       string(payload),
+			Status:        constants.ENUM_DELIVERY_STATUS_PENDING,
+			NextAttemptAt: now,
+			AlertRuleID:   rule.ID,
+			HistoryID:     history.ID,
+		})
+	}
+
+	if err := s.alertRepo.CreateDeliveries(ctx, nil, deliveries); err != nil {
+		return dto.ErrCreateAlertDelivery
+	}
+
+	return nil
+}
+
+func (s *alertService) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.deliverLoop(ctx)
+	}()
+}
+
+func (s *alertService) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *alertService) deliverLoop(ctx context.Context) {
+	ticker := time.NewTicker(alertPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Send everything that is due before going back to sleep
+		for ctx.Err() == nil {
+			delivery, err := s.alertRepo.ClaimDueDelivery(ctx, nil, time.Now(), alertClaimLease)
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) && ctx.Err() == nil {
+					logrus.WithError(err).Error("failed to claim alert delivery")
+				}
+				break
+			}
+			s.deliver(ctx, delivery)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliver makes one attempt at sending a claimed delivery and records the
+// outcome. Failures are retried after 1, 2, 4 and 8 minutes before the
+// delivery is given up on.
+func (s *alertService) deliver(ctx context.Context, delivery entity.AlertDelivery) {
+	log := logrus.WithField("delivery_id", delivery.ID)
+	attempts := delivery.Attempts + 1
+
+	err := s.send(ctx, delivery)
+
+	fields := map[string]any{"attempts": attempts}
+	switch {
+	case err == nil:
+		now := time.Now()
+		fields["status"] = constants.ENUM_DELIVERY_STATUS_SUCCEEDED
+		fields["last_error"] = ""
+		fields["delivered_at"] = now
+	case attempts >= alertMaxAttempts:
+		fields["status"] = constants.ENUM_DELIVERY_STATUS_FAILED
+		fields["last_error"] = err.Error()
+		log.WithError(err).Warn("alert delivery failed, giving up")
+	default:
+		backoff := alertRetryBaseDelay * time.Duration(math.Pow(2, float64(attempts-1)))
+		fields["last_error"] = err.Error()
+		fields["next_attempt_at"] = time.Now().Add(backoff)
+		log.WithError(err).WithField("retry_in", backoff).Warn("alert delivery failed")
+	}
+
+	// Record the outcome even when shutdown cancelled the attempt
+	if err := s.alertRepo.UpdateDelivery(context.WithoutCancel(ctx), nil, delivery.ID.String(), fields); err != nil {
+		log.WithError(err).Error("failed to update alert delivery")
+	}
+}
+
+func (s *alertService) send(ctx context.Context, delivery entity.AlertDelivery) error {
+	notifier, ok := s.notifiers[delivery.Channel]
+	if !ok {
+		if delivery.Channel == constants.ENUM_ALERT_CHANNEL_EMAIL {
+			return dto.ErrSMTPNotConfigured
+		}
+		return dto.ErrInvalidAlertChannel
+	}
+
+	var payload dto.AlertPayload
+	if err := json.Unmarshal([]byte(delivery.Payload), &payload); err != nil {
+		return err
+	}
+
+	return notifier.Send(ctx, delivery.Target, delivery.AlertRule.Secret, payload)
+}
+
+func (s *alertService) validateAlertTarget(channel string, target string) error {
+	switch channel {
+	case constants.ENUM_ALERT_CHANNEL_WEBHOOK:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return dto.ErrInvalidWebhookTarget
+		}
+		// Names are checked again on every connection, this only rejects
+		// the obvious cases early.
+		host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			return dto.ErrWebhookTargetPrivate
+		}
+		if ip, err := netip.ParseAddr(host); err == nil && !isPublicAddress(ip) {
+			return dto.ErrWebhookTargetPrivate
+		}
+	case constants.ENUM_ALERT_CHANNEL_EMAIL:
+		if _, ok := s.notifiers[channel]; !ok {
+			return dto.ErrSMTPNotConfigured
+		}
+		addr, err := mail.ParseAddress(target)
+		if err != nil || addr.Address != target {
+			return dto.ErrInvalidEmailTarget
+		}
+	default:
+		return dto.ErrInvalidAlertChannel
+	}
+	return nil
+}
+
+func validateAlertRule(metric string, condition string) error {
+	switch metric {
+	case constants.ENUM_ALERT_METRIC_NEGATIVE_SHARE,
+		constants.ENUM_ALERT_METRIC_POSITIVE_SHARE,
+		constants.ENUM_ALERT_METRIC_BINTANG,
+		constants.ENUM_ALERT_METRIC_PACKAGING,
+		constants.ENUM_ALERT_METRIC_DELIVERY,
+		constants.ENUM_ALERT_METRIC_ADMIN_RESPONSE,
+		constants.ENUM_ALERT_METRIC_PRODUCT_CONDITION:
+	default:
+		return dto.ErrInvalidAlertMetric
+	}
+
+	switch condition {
+	case constants.ENUM_ALERT_CONDITION_ABOVE,
+		constants.ENUM_ALERT_CONDITION_BELOW,
+		constants.ENUM_ALERT_CONDITION_RISES_BY,
+		constants.ENUM_ALERT_CONDITION_DROPS_BY:
+	default:
+		return dto.ErrInvalidAlertCond
+	}
+
+	return nil
+}
+
+// alertMetricValue reads a metric off an analysis. Shares are percentages
+// of the reviews that got a positive or negative label.
+func alertMetricValue(metric string, history entity.History) float64 {
+	labelled := float64(history.CountPositive + history.CountNegative)
+
+	switch metric {
+	case constants.ENUM_ALERT_METRIC_NEGATIVE_SHARE:
+		if labelled == 0 {
+			return 0
+		}
+		return float64(history.CountNegative) / labelled * 100
+	case constants.ENUM_ALERT_METRIC_POSITIVE_SHARE:
+		if labelled == 0 {
+			return 0
+		}
+		return float64(history.CountPositive) / labelled * 100
+	case constants.ENUM_ALERT_METRIC_BINTANG:
+		return history.Bintang
+	case constants.ENUM_ALERT_METRIC_PACKAGING:
+		return float64(history.Packaging)
+	case constants.ENUM_ALERT_METRIC_DELIVERY:
+		return float64(history.Delivery)
+	case constants.ENUM_ALERT_METRIC_ADMIN_RESPONSE:
+		return float64(history.AdminResponse)
+	case constants.ENUM_ALERT_METRIC_PRODUCT_CONDITION:
+		return float64(history.ProductCondition)
+	}
+	return 0
+}
+
+// alertFires reports whether a rule is triggered. Above and below only fire
+// when the metric crosses the threshold, so a product that stays bad does
+// not alert on every run. Rises and drops need a previous analysis.
+func alertFires(condition string, threshold float64, current float64, previous *float64) bool {
+	switch condition {
+	case constants.ENUM_ALERT_CONDITION_ABOVE:
+		return current > threshold && (previous == nil || *previous <= threshold)
+	case constants.ENUM_ALERT_CONDITION_BELOW:
+		return current < threshold && (previous == nil || *previous >= threshold)
+	case constants.ENUM_ALERT_CONDITION_RISES_BY:
+		return previous != nil && current-*previous > threshold
+	case constants.ENUM_ALERT_CONDITION_DROPS_BY:
+		return previous != nil && *previous-current > threshold
+	}
+	return false
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, alertWebhookSecretLen)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func toAlertRuleResponse(rule entity.AlertRule) dto.AlertRuleResponse {
+	return dto.AlertRuleResponse{
+		ID:        rule.ID,
+		Name:      rule.Name,
+		Provider:  rule.Provider,
+		ProductID: rule.ProductID,
+		Metric:    rule.Metric,
+		Condition: rule.Condition,
+		Threshold: rule.Threshold,
+		Channel:   rule.Channel,
+		Target:    rule.Target,
+		Secret:    rule.Secret,
+		Active:    rule.Active,
+		CreatedAt: rule.CreatedAt,
+	}
+}
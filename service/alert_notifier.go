@@ -0,0 +1,289 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+const (
+	alertSendTimeout        = 15 * time.Second
+	defaultSMTPPort         = "587"
+	smtpImplicitTLSPort     = "465"
+	webhookSignatureHeader  = "X-Ulascan-Signature"
+	webhookTimestampHeader  = "X-Ulascan-Timestamp"
+	webhookSignaturePrefix  = "sha256="
+	alertEmailSubjectPrefix = "[Ulascan] "
+)
+
+type (
+	// AlertNotifier delivers a triggered alert to a single target. secret is
+	// the rule's signing key and is ignored by channels that do not sign.
+	AlertNotifier interface {
+		Send(ctx context.Context, target string, secret string, payload dto.AlertPayload) error
+	}
+
+	webhookNotifier struct {
+		client *http.Client
+	}
+
+	emailNotifier struct {
+		host     string
+		port     string
+		username string
+		password string
+		from     string
+	}
+)
+
+// NewAlertNotifiers returns the notifier of every channel. Email is only
+// available when the SMTP host and sender are configured.
+func NewAlertNotifiers(cfg config.SMTPConfig) map[string]AlertNotifier {
+	notifiers := map[string]AlertNotifier{
+		constants.ENUM_ALERT_CHANNEL_WEBHOOK: NewWebhookNotifier(newWebhookClient()),
+	}
+
+	if cfg.Host != "" && cfg.From != "" {
+		notifiers[constants.ENUM_ALERT_CHANNEL_EMAIL] = NewEmailNotifier(
+			cfg.Host,
+			cfg.Port,
+			cfg.Username,
+			cfg.Password.Value(),
+			cfg.From,
+		)
+	}
+
+	return notifiers
+}
+
+// newWebhookClient only connects to public addresses. Webhook targets are
+// chosen by users, so without the check any of them could make the server
+// call loopback, private network or cloud metadata endpoints. The check runs
+// on the resolved address of every connection, redirects included, so a
+// name that resolves to a private address later is caught as well.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: alertSendTimeout,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddress(ip) {
+				return fmt.Errorf("%w: %s", dto.ErrWebhookTargetPrivate, host)
+			}
+			return nil
+		},
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialled instead of the target, bypassing the check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   alertSendTimeout,
+		Transport: transport,
+	}
+}
+
+// nonPublicPrefixes are the ranges not covered by the netip predicates that
+// are still not reachable on the public internet.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// isPublicAddress reports whether ip is a global unicast address outside
+// the private, shared and reserved ranges.
+func isPublicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func NewWebhookNotifier(client *http.Client) AlertNotifier {
+	return &webhookNotifier{
+		client: client,
+	}
+}
+
+// Send posts the payload as JSON. The body is signed with HMAC-SHA256 over
+// "<timestamp>.<body>" so receivers can verify it and reject replays.
+func (n *webhookNotifier) Send(ctx context.Context, target string, secret string, payload dto.AlertPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return dto.ErrMarshallJson
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, webhookSignaturePrefix+signWebhook(secret, timestamp, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%w: %d", dto.ErrWebhookStatus, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func signWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewEmailNotifier sends plain text mail through an SMTP relay. Port 465
+// uses implicit TLS, any other port upgrades with STARTTLS when the server
+// offers it, so a local test server without TLS works as well.
+func NewEmailNotifier(host string, port string, username string, password string, from string) AlertNotifier {
+	if port == "" {
+		port = defaultSMTPPort
+	}
+
+	return &emailNotifier{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (n *emailNotifier) Send(ctx context.Context, target string, secret string, payload dto.AlertPayload) error {
+	if n.host == "" || n.from == "" {
+		return dto.ErrSMTPNotConfigured
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, alertSendTimeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(n.host, n.port)
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if n.port == smtpImplicitTLSPort {
+		conn = tls.Client(conn, &tls.Config{ServerName: n.host})
+	}
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && n.port != smtpImplicitTLSPort {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+
+	if n.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", n.username, n.password, n.host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(target); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildAlertEmail(n.from, target, payload)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+func buildAlertEmail(from string, to string, payload dto.AlertPayload) []byte {
+	subject := fmt.Sprintf("%s%s triggered for %s", alertEmailSubjectPrefix, payload.RuleName, payload.Product.ProductName)
+
+	previous := "-"
+	if payload.Previous != nil {
+		previous = strconv.FormatFloat(*payload.Previous, 'f', 2, 64)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", payload.TriggeredAt.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	fmt.Fprintf(&b, "Your alert rule \"%s\" was triggered.\r\n\r\n", payload.RuleName)
+	fmt.Fprintf(&b, "Product: %s\r\n", payload.Product.ProductName)
+	fmt.Fprintf(&b, "Link: %s\r\n", payload.Product.URL)
+	fmt.Fprintf(&b, "Rule: %s %s %.2f\r\n", payload.Metric, payload.Condition, payload.Threshold)
+	fmt.Fprintf(&b, "Previous: %s\r\n", previous)
+	fmt.Fprintf(&b, "Current: %.2f\r\n", payload.Current)
+	fmt.Fprintf(&b, "Analysis: %s\r\n", payload.HistoryID)
+
+	return []byte(b.String())
+}
+
+// sanitizeHeader drops line breaks so scraped product names cannot inject
+// extra mail headers.
+func sanitizeHeader(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
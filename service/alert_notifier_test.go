@@ -0,0 +1,333 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+
+	"github.com/google/uuid"
+)
+
+func TestIsPublicAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "93.184.216.34", want: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "0.0.0.0"},
+		{addr: "::"},
+		{addr: "10.1.2.3"},
+		{addr: "172.16.0.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "fd00::1"},
+		{addr: "100.64.0.1"},
+		{addr: "224.0.0.1"},
+		{addr: "255.255.255.255"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "::ffff:169.254.169.254"},
+		{addr: "64:ff9b::a9fe:a9fe"},
+	}
+
+	for _, tt := range tests {
+		if got := isPublicAddress(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("isPublicAddress(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookClientRefusesLocalTargets(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(newWebhookClient())
+	err := notifier.Send(context.Background(), server.URL, "secret", dto.AlertPayload{})
+	if !errors.Is(err, dto.ErrWebhookTargetPrivate) {
+		t.Errorf("err = %v, want %v", err, dto.ErrWebhookTargetPrivate)
+	}
+	if hits != 0 {
+		t.Errorf("the local server was called %d times", hits)
+	}
+}
+
+func TestValidateWebhookTarget(t *testing.T) {
+	s := &alertService{}
+
+	tests := []struct {
+		target  string
+		wantErr error
+	}{
+		{target: "https://hooks.example.com/alerts"},
+		{target: "http://93.184.216.34:8080/alerts"},
+		{target: "ftp://hooks.example.com/alerts", wantErr: dto.ErrInvalidWebhookTarget},
+		{target: "https:///alerts", wantErr: dto.ErrInvalidWebhookTarget},
+		{target: "http://localhost:8080/alerts", wantErr: dto.ErrWebhookTargetPrivate},
+		{target: "http://LOCALHOST./alerts", wantErr: dto.ErrWebhookTargetPrivate},
+		{target: "http://api.localhost/alerts", wantErr: dto.ErrWebhookTargetPrivate},
+		{target: "http://127.0.0.1/alerts", wantErr: dto.ErrWebhookTargetPrivate},
+		{target: "http://169.254.169.254/latest/meta-data", wantErr: dto.ErrWebhookTargetPrivate},
+		{target: "http://[::1]:8080/alerts", wantErr: dto.ErrWebhookTargetPrivate},
+		{target: "http://10.0.0.5/alerts", wantErr: dto.ErrWebhookTargetPrivate},
+	}
+
+	for _, tt := range tests {
+		if err := s.validateAlertTarget(constants.ENUM_ALERT_CHANNEL_WEBHOOK, tt.target); !errors.Is(err, tt.wantErr) {
+			t.Errorf("validateAlertTarget(%q) = %v, want %v", tt.target, err, tt.wantErr)
+		}
+	}
+}
+
+func testAlertPayload() dto.AlertPayload {
+	previous := 12.5
+	return dto.AlertPayload{
+		RuleID:    uuid.New(),
+		RuleName:  "Too many complaints",
+		Metric:    constants.ENUM_ALERT_METRIC_NEGATIVE_SHARE,
+		Condition: constants.ENUM_ALERT_CONDITION_ABOVE,
+		Threshold: 20,
+		Previous:  &previous,
+		Current:   31.25,
+		HistoryID: uuid.New(),
+		Product: dto.AlertProduct{
+			Provider:    "tokopedia",
+			ProductID:   "2150001234",
+			ProductName: "Earphone\r\nBcc: victim@example.com",
+			URL:         "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro",
+		},
+		TriggeredAt: time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookNotifierSignsBody(t *testing.T) {
+	const secret = "rule-secret"
+
+	var header http.Header
+	var body []byte
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	notifier := NewWebhookNotifier(server.Client())
+	payload := testAlertPayload()
+	if err := notifier.Send(context.Background(), server.URL, secret, payload); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	if got := header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	var sent dto.AlertPayload
+	if err := json.Unmarshal(body, &sent); err != nil {
+		t.Fatalf("body: %v", err)
+	}
+	if sent.RuleID != payload.RuleID || sent.Current != payload.Current || *sent.Previous != *payload.Previous || sent.Product != payload.Product {
+		t.Errorf("body = %+v, want %+v", sent, payload)
+	}
+
+	timestamp := header.Get(webhookTimestampHeader)
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(unix, 0)) > time.Minute {
+		t.Errorf("timestamp = %q", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + string(body)))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); header.Get(webhookSignatureHeader) != want {
+		t.Errorf("signature = %q, want %q", header.Get(webhookSignatureHeader), want)
+	}
+
+	status = http.StatusInternalServerError
+	if err := notifier.Send(context.Background(), server.URL, secret, payload); !errors.Is(err, dto.ErrWebhookStatus) {
+		t.Errorf("err = %v, want %v", err, dto.ErrWebhookStatus)
+	}
+}
+
+// fakeSMTPServer accepts one session at a time and records the envelope
+// and message of every mail. It offers AUTH PLAIN but no STARTTLS.
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	auth  []string
+	mails []fakeMail
+}
+
+type fakeMail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTPServer{listener: listener}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+	reply("220 fake ESMTP")
+	var mail fakeMail
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+
+		switch verb {
+		case "EHLO":
+			reply("250-fake")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = append(s.auth, line)
+			s.mu.Unlock()
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			mail = fakeMail{from: line}
+			reply("250 OK")
+		case "RCPT":
+			mail.to = append(mail.to, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			mail.data = data.String()
+			s.mu.Lock()
+			s.mails = append(s.mails, mail)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestEmailNotifierSendsThroughSMTP(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port, err := net.SplitHostPort(server.listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	notifier := NewEmailNotifier(host, port, "mailer", "mail-password", "alerts@ulascan.example")
+	payload := testAlertPayload()
+	if err := notifier.Send(context.Background(), "seller@example.com", "", payload); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	wantAuth := "AUTH PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00mailer\x00mail-password"))
+	if len(server.auth) != 1 || server.auth[0] != wantAuth {
+		t.Errorf("auth = %q, want %q", server.auth, wantAuth)
+	}
+	if len(server.mails) != 1 {
+		t.Fatalf("mails = %d, want 1", len(server.mails))
+	}
+	mail := server.mails[0]
+	if mail.from != "MAIL FROM:<alerts@ulascan.example> BODY=8BITMIME" && mail.from != "MAIL FROM:<alerts@ulascan.example>" {
+		t.Errorf("from = %q", mail.from)
+	}
+	if len(mail.to) != 1 || mail.to[0] != "RCPT TO:<seller@example.com>" {
+		t.Errorf("to = %q", mail.to)
+	}
+
+	headers, body, _ := strings.Cut(mail.data, "\r\n\r\n")
+	for _, want := range []string{
+		"To: seller@example.com",
+		"Subject: [Ulascan] Too many complaints triggered for Earphone  Bcc: victim@example.com",
+		"Content-Type: text/plain; charset=UTF-8",
+	} {
+		if !strings.Contains(headers+"\r\n", want+"\r\n") {
+			t.Errorf("headers do not contain %q:\n%s", want, headers)
+		}
+	}
+	if strings.Contains(headers, "\r\nBcc:") {
+		t.Errorf("product name injected a header:\n%s", headers)
+	}
+	for _, want := range []string{"Previous: 12.50", "Current: 31.25", "Rule: negative_share above 20.00", payload.HistoryID.String()} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body does not contain %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestEmailNotifierUnreachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	notifier := NewEmailNotifier(host, port, "", "", "alerts@ulascan.example")
+	if err := notifier.Send(context.Background(), "seller@example.com", "", testAlertPayload()); err == nil {
+		t.Error("Send: want error")
+	}
+}
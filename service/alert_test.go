@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+	"review_product_tokopedia_be/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeAlertRepository serves a fixed set of rules and a queue of due
+// deliveries, and records what the service writes.
+type fakeAlertRepository struct {
+	repository.AlertRepository
+
+	mu         sync.Mutex
+	rules      []entity.AlertRule
+	queue      []entity.AlertDelivery
+	created    []entity.AlertDelivery
+	updates    map[uuid.UUID]map[string]any
+	updateDone chan struct{}
+}
+
+func (r *fakeAlertRepository) GetActiveRulesForProduct(ctx context.Context, tx *gorm.DB, userId string, provider string, productId string) ([]entity.AlertRule, error) {
+	return r.rules, nil
+}
+
+func (r *fakeAlertRepository) CreateDeliveries(ctx context.Context, tx *gorm.DB, deliveries []entity.AlertDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.created = append(r.created, deliveries...)
+	return nil
+}
+
+func (r *fakeAlertRepository) ClaimDueDelivery(ctx context.Context, tx *gorm.DB, now time.Time, lease time.Duration) (entity.AlertDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.queue) == 0 {
+		return entity.AlertDelivery{}, gorm.ErrRecordNotFound
+	}
+	delivery := r.queue[0]
+	r.queue = r.queue[1:]
+	return delivery, nil
+}
+
+func (r *fakeAlertRepository) UpdateDelivery(ctx context.Context, tx *gorm.DB, deliveryId string, fields map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.updates == nil {
+		r.updates = make(map[uuid.UUID]map[string]any)
+	}
+	r.updates[uuid.MustParse(deliveryId)] = fields
+	if r.updateDone != nil {
+		r.updateDone <- struct{}{}
+	}
+	return nil
+}
+
+// fakeHistoryRepository links each snapshot to the one before it.
+type fakeHistoryRepository struct {
+	repository.HistoryRepository
+
+	histories map[string]entity.History
+	previous  map[string]string
+}
+
+func (r *fakeHistoryRepository) GetHistoryById(ctx context.Context, tx *gorm.DB, historyId string, userId string) (entity.History, error) {
+	history, ok := r.histories[historyId]
+	if !ok {
+		return entity.History{}, gorm.ErrRecordNotFound
+	}
+	return history, nil
+}
+
+func (r *fakeHistoryRepository) GetPreviousSnapshot(ctx context.Context, tx *gorm.DB, historyId string) (entity.History, error) {
+	previousId, ok := r.previous[historyId]
+	if !ok {
+		return entity.History{}, gorm.ErrRecordNotFound
+	}
+	return r.histories[previousId], nil
+}
+
+// notifierFunc adapts a function to AlertNotifier.
+type notifierFunc func(ctx context.Context, target string, secret string, payload dto.AlertPayload) error
+
+func (f notifierFunc) Send(ctx context.Context, target string, secret string, payload dto.AlertPayload) error {
+	return f(ctx, target, secret, payload)
+}
+
+func TestAlertFires(t *testing.T) {
+	value := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		condition string
+		threshold float64
+		current   float64
+		previous  *float64
+		want      bool
+	}{
+		{name: "above on the first analysis", condition: constants.ENUM_ALERT_CONDITION_ABOVE, threshold: 20, current: 30, want: true},
+		{name: "above when crossing", condition: constants.ENUM_ALERT_CONDITION_ABOVE, threshold: 20, current: 30, previous: value(10), want: true},
+		{name: "above from exactly the threshold", condition: constants.ENUM_ALERT_CONDITION_ABOVE, threshold: 20, current: 21, previous: value(20), want: true},
+		{name: "above while staying above", condition: constants.ENUM_ALERT_CONDITION_ABOVE, threshold: 20, current: 35, previous: value(30)},
+		{name: "above at the threshold", condition: constants.ENUM_ALERT_CONDITION_ABOVE, threshold: 20, current: 20, previous: value(10)},
+		{name: "below when crossing", condition: constants.ENUM_ALERT_CONDITION_BELOW, threshold: 4, current: 3.5, previous: value(4.5), want: true},
+		{name: "below while staying below", condition: constants.ENUM_ALERT_CONDITION_BELOW, threshold: 4, current: 3, previous: value(3.5)},
+		{name: "below when recovering", condition: constants.ENUM_ALERT_CONDITION_BELOW, threshold: 4, current: 4.5, previous: value(3.5)},
+		{name: "rises by more", condition: constants.ENUM_ALERT_CONDITION_RISES_BY, threshold: 10, current: 25, previous: value(10), want: true},
+		{name: "rises by exactly", condition: constants.ENUM_ALERT_CONDITION_RISES_BY, threshold: 10, current: 20, previous: value(10)},
+		{name: "rises without a previous analysis", condition: constants.ENUM_ALERT_CONDITION_RISES_BY, threshold: 10, current: 90},
+		{name: "drops by more", condition: constants.ENUM_ALERT_CONDITION_DROPS_BY, threshold: 10, current: 60, previous: value(80), want: true},
+		{name: "drops but rises", condition: constants.ENUM_ALERT_CONDITION_DROPS_BY, threshold: 10, current: 80, previous: value(60)},
+		{name: "unknown condition", condition: "equals", threshold: 10, current: 10, previous: value(10)},
+	}
+
+	for _, tt := range tests {
+		if got := alertFires(tt.condition, tt.threshold, tt.current, tt.previous); got != tt.want {
+			t.Errorf("%s: alertFires = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestAlertEvaluateFiresOnceWhileConditionHolds(t *testing.T) {
+	userId := uuid.New()
+	rule := entity.AlertRule{
+		ID:        uuid.New(),
+		Name:      "Too many complaints",
+		Metric:    constants.ENUM_ALERT_METRIC_NEGATIVE_SHARE,
+		Condition: constants.ENUM_ALERT_CONDITION_ABOVE,
+		Threshold: 20,
+		Channel:   constants.ENUM_ALERT_CHANNEL_WEBHOOK,
+		Target:    "https://hooks.example.com/alerts",
+		Active:    true,
+		UserID:    userId,
+	}
+	alertRepo := &fakeAlertRepository{rules: []entity.AlertRule{rule}}
+	historyRepo := &fakeHistoryRepository{
+		histories: make(map[string]entity.History),
+		previous:  make(map[string]string),
+	}
+	s := NewAlertService(alertRepo, historyRepo, nil)
+
+	// Negative shares of consecutive analyses and whether each one alerts
+	runs := []struct {
+		negative int
+		fires    bool
+	}{
+		{negative: 10},
+		{negative: 30, fires: true},
+		{negative: 35},
+		{negative: 40},
+		{negative: 15},
+		{negative: 25, fires: true},
+	}
+
+	var previousId string
+	for i, run := range runs {
+		history := entity.History{
+			ID:            uuid.New(),
+			Provider:      "tokopedia",
+			ProductID:     "2150001234",
+			ProductName:   "Earphone Bluetooth TWS Pro",
+			CountNegative: run.negative,
+			CountPositive: 100 - run.negative,
+			UserID:        userId,
+		}
+		historyRepo.histories[history.ID.String()] = history
+		if previousId != "" {
+			historyRepo.previous[history.ID.String()] = previousId
+		}
+
+		before := len(alertRepo.created)
+		if err := s.Evaluate(context.Background(), history.ID.String(), userId.String()); err != nil {
+			t.Fatalf("run %d: Evaluate: %v", i, err)
+		}
+		if fired := len(alertRepo.created) > before; fired != run.fires {
+			t.Errorf("run %d at %d%% negative: fired = %v, want %v", i, run.negative, fired, run.fires)
+		}
+
+		if run.fires && len(alertRepo.created) > before {
+			delivery := alertRepo.created[len(alertRepo.created)-1]
+			if delivery.Status != constants.ENUM_DELIVERY_STATUS_PENDING || delivery.AlertRuleID != rule.ID || delivery.Target != rule.Target {
+				t.Errorf("run %d: delivery = %+v", i, delivery)
+			}
+			var payload dto.AlertPayload
+			if err := json.Unmarshal([]byte(delivery.Payload), &payload); err != nil {
+				t.Fatalf("run %d: payload: %v", i, err)
+			}
+			if payload.Current != float64(run.negative) || payload.Previous == nil || *payload.Previous != float64(runs[i-1].negative) {
+				t.Errorf("run %d: payload values = %v, %v", i, payload.Previous, payload.Current)
+			}
+			if payload.HistoryID != history.ID || payload.Product.ProductID != history.ProductID {
+				t.Errorf("run %d: payload = %+v", i, payload)
+			}
+		}
+
+		previousId = history.ID.String()
+	}
+}
+
+func TestAlertDeliverRetries(t *testing.T) {
+	sendErr := errors.New("connection refused")
+
+	tests := []struct {
+		name        string
+		attempts    int
+		err         error
+		wantStatus  string
+		wantBackoff time.Duration
+	}{
+		{name: "first failure", attempts: 0, err: sendErr, wantBackoff: time.Minute},
+		{name: "third failure", attempts: 2, err: sendErr, wantBackoff: 4 * time.Minute},
+		{name: "fourth failure", attempts: 3, err: sendErr, wantBackoff: 8 * time.Minute},
+		{name: "last attempt", attempts: alertMaxAttempts - 1, err: sendErr, wantStatus: constants.ENUM_DELIVERY_STATUS_FAILED},
+		{name: "success after failures", attempts: 2, wantStatus: constants.ENUM_DELIVERY_STATUS_SUCCEEDED},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAlertRepository{}
+			s := NewAlertService(repo, nil, map[string]AlertNotifier{
+				constants.ENUM_ALERT_CHANNEL_WEBHOOK: notifierFunc(func(ctx context.Context, target string, secret string, payload dto.AlertPayload) error {
+					return tt.err
+				}),
+			}).(*alertService)
+
+			delivery := entity.AlertDelivery{
+				ID:       uuid.New(),
+				Channel:  constants.ENUM_ALERT_CHANNEL_WEBHOOK,
+				Target:   "https://hooks.example.com/alerts",
+				Payload:  "{}",
+				Status:   constants.ENUM_DELIVERY_STATUS_PENDING,
+				Attempts: tt.attempts,
+			}
+			start := time.Now()
+			s.deliver(context.Background(), delivery)
+
+			fields := repo.updates[delivery.ID]
+			if fields["attempts"] != tt.attempts+1 {
+				t.Errorf("attempts = %v, want %d", fields["attempts"], tt.attempts+1)
+			}
+			if status, _ := fields["status"].(string); status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status, tt.wantStatus)
+			}
+
+			next, retried := fields["next_attempt_at"].(time.Time)
+			if tt.wantBackoff == 0 {
+				if retried {
+					t.Errorf("next_attempt_at set to %v, want no retry", next)
+				}
+			} else if !retried || next.Sub(start) < tt.wantBackoff || next.Sub(start) > tt.wantBackoff+time.Second {
+				t.Errorf("next_attempt_at = %v after start, want %v", next.Sub(start), tt.wantBackoff)
+			}
+
+			if tt.err != nil && fields["last_error"] != tt.err.Error() {
+				t.Errorf("last_error = %v", fields["last_error"])
+			}
+			if tt.err == nil && fields["delivered_at"] == nil {
+				t.Error("delivered_at not set")
+			}
+		})
+	}
+}
+
+func TestAlertDeliverEmailWithoutSMTP(t *testing.T) {
+	repo := &fakeAlertRepository{}
+	s := NewAlertService(repo, nil, map[string]AlertNotifier{}).(*alertService)
+
+	delivery := entity.AlertDelivery{ID: uuid.New(), Channel: constants.ENUM_ALERT_CHANNEL_EMAIL, Payload: "{}"}
+	s.deliver(context.Background(), delivery)
+
+	if fields := repo.updates[delivery.ID]; fields["last_error"] != dto.ErrSMTPNotConfigured.Error() {
+		t.Errorf("last_error = %v, want %v", fields["last_error"], dto.ErrSMTPNotConfigured)
+	}
+}
+
+// The worker sends every queued delivery to a local webhook sink as soon
+// as it starts.
+func TestAlertWorkerDeliversQueue(t *testing.T) {
+	var mu sync.Mutex
+	var received []dto.AlertPayload
+	sink := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload dto.AlertPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+	}))
+	defer sink.Close()
+
+	repo := &fakeAlertRepository{updateDone: make(chan struct{}, 2)}
+	for _, name := range []string{"first", "second"} {
+		payload, _ := json.Marshal(dto.AlertPayload{RuleName: name})
+		repo.queue = append(repo.queue, entity.AlertDelivery{
+			ID:        uuid.New(),
+			Channel:   constants.ENUM_ALERT_CHANNEL_WEBHOOK,
+			Target:    sink.URL,
+			Payload:   string(payload),
+			Status:    constants.ENUM_DELIVERY_STATUS_PENDING,
+			AlertRule: entity.AlertRule{Secret: "secret"},
+		})
+	}
+
+	s := NewAlertService(repo, nil, map[string]AlertNotifier{
+		constants.ENUM_ALERT_CHANNEL_WEBHOOK: NewWebhookNotifier(sink.Client()),
+	})
+	s.Start()
+	defer s.Stop()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-repo.updateDone:
+		case <-time.After(5 * time.Second):
+			t.Fatal("deliveries were not sent")
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || received[0].RuleName != "first" || received[1].RuleName != "second" {
+		t.Errorf("received = %+v", received)
+	}
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	for id, fields := range repo.updates {
+		if fields["status"] != constants.ENUM_DELIVERY_STATUS_SUCCEEDED {
+			t.Errorf("delivery %s: fields = %v", id, fields)
+		}
+	}
+}
@@ -0,0 +1,515 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	AnalysisService interface {
+		Analyze(ctx context.Context, productUrl string, opts dto.AnalysisOptions) (dto.AnalysisResult, error)
+	}
+
+	analysisService struct {
+		marketplaceRegistry MarketplaceRegistry
+		modelService        ModelService
+		geminiService       GeminiService
+		historyService      HistoryService
+		reviewService       ReviewService
+		alertService        AlertService
+	}
+)
+
+func NewAnalysisService(
+	mr MarketplaceRegistry,
+	ms ModelService,
+	gs GeminiService,
+	hs HistoryService,
+	rs ReviewService,
+	as AlertService,
+) AnalysisService {
+	return &analysisService{
+		marketplaceRegistry: mr,
+		modelService:        ms,
+		geminiService:       gs,
+		historyService:      hs,
+		reviewService:       rs,
+		alertService:        as,
+	}
+}
+
+func (s *analysisService) Analyze(ctx context.Context, productUrl string, opts dto.AnalysisOptions) (dto.AnalysisResult, error) {
+	var userUUID uuid.UUID
+	if opts.UserID != "" {
+		parsed, err := uuid.Parse(opts.UserID)
+		if err != nil {
+			return dto.AnalysisResult{}, analysisError(dto.ANALYSIS_STAGE_HISTORY, dto.MESSAGE_FAILED_CREATE_HISTORY, dto.ErrInvalidUserId)
+		}
+		userUUID = parsed
+	}
+
+	ctx, tracker := withCacheTracker(ctx, opts.Refresh)
+
+	reviewOpts, err := normalizeReviewOptions(opts.Reviews)
+	if err != nil {
+		return dto.AnalysisResult{}, analysisError(dto.ANALYSIS_STAGE_REVIEWS, dto.MESSAGE_FAILED_GET_REVIEWS, err)
+	}
+
+	provider, parsedUrl, err := s.marketplaceRegistry.Resolve(productUrl)
+	if err != nil {
+		return dto.AnalysisResult{}, analysisError(dto.ANALYSIS_STAGE_URL, dto.MESSAGE_FAILED_PARSE_URL, err)
+	}
+
+	reportProgress(opts, dto.ANALYSIS_STAGE_PRODUCT, constants.ENUM_STAGE_STATUS_RUNNING)
+	product, err := provider.ResolveProduct(ctx, parsedUrl)
+	if err != nil {
+		reportProgress(opts, dto.ANALYSIS_STAGE_PRODUCT, constants.ENUM_STAGE_STATUS_FAILED)
+		return dto.AnalysisResult{}, analysisError(dto.ANALYSIS_STAGE_PRODUCT, dto.MESSAGE_FAILED_GET_PRODUCT_ID, err)
+	}
+	tracker.setScope(provider.Name() + ":" + product.ProductId)
+	reportEvent(opts, dto.ANALYSIS_STAGE_PRODUCT, constants.ENUM_STAGE_STATUS_SUCCEEDED, dto.AnalysisProductData{
+		ProductID:          product.ProductId,
+		ProductName:        product.ProductName,
+		ProductDescription: product.ProductDescription,
+		ShopName:           product.ShopName,
+		ImageUrls:          product.ImageUrls,
+	})
+
+	reviewsReq := dto.GetReviewsRequest{
+		ProductUrl: product.ProductUrl,
+		ProductId:  product.ProductId,
+		ShopId:     product.ShopId,
+		Options:    reviewOpts,
+	}
+
+	// Re-analysing a product the user already analysed only fetches the
+	// reviews written since the newest stored one and reuses the stored
+	// labels for the rest.
+	var baseline []dto.AnalysedReview
+	var baselineSummary string
+	if userUUID != uuid.Nil && incrementalEligible(opts, reviewOpts) {
+		baseline, baselineSummary = s.loadBaseline(ctx, provider.Name(), product.ProductId, opts.UserID, reviewOpts)
+	}
+	if len(baseline) > 0 {
+		newest := baseline[0].Review.CreatedAt
+		reviewsReq.Options.Since = &newest
+	}
+
+	reportProgress(opts, dto.ANALYSIS_STAGE_REVIEWS, constants.ENUM_STAGE_STATUS_RUNNING)
+	reviews, err := provider.GetReviews(ctx, reviewsReq)
+	if err != nil {
+		reportProgress(opts, dto.ANALYSIS_STAGE_REVIEWS, constants.ENUM_STAGE_STATUS_FAILED)
+		return dto.AnalysisResult{}, analysisError(dto.ANALYSIS_STAGE_REVIEWS, dto.MESSAGE_FAILED_GET_REVIEWS, err)
+	}
+	reviews = excludeAnalysedReviews(uniqueReviews(reviews), baseline)
+
+	statements := make([]string, len(reviews))
+	analyzeReviews := make([]dto.AnalyzeReview, len(reviews))
+	for i, review := range reviews {
+		statements[i] = review.Message
+		analyzeReviews[i] = dto.AnalyzeReview{
+			ID:      reviewExternalId(review),
+			Message: review.Message,
+		}
+	}
+
+	// Rating and summary always describe the full set, old and new.
+	allReviews := make([]dto.ReviewResponse, 0, len(reviews)+len(baseline))
+	allReviews = append(allReviews, reviews...)
+	for _, analysed := range baseline {
+		allReviews = append(allReviews, analysed.Review)
+	}
+	if len(baseline) > 0 && len(allReviews) > reviewOpts.MaxReviews {
+		allReviews = allReviews[:reviewOpts.MaxReviews]
+	}
+
+	ratingSum := 0.0
+	var builder strings.Builder
+	for _, review := range allReviews {
+		ratingSum += float64(review.Rating)
+
+		builder.WriteString(review.Message)
+		builder.WriteString("\n")
+	}
+	concatenatedMessage := builder.String()
+
+	var ratingAvg float64
+	if len(allReviews) > 0 {
+		ratingAvg = ratingSum / float64(len(allReviews))
+	}
+
+	reportEvent(opts, dto.ANALYSIS_STAGE_REVIEWS, constants.ENUM_STAGE_STATUS_SUCCEEDED, dto.AnalysisReviewsData{
+		Rating:     len(allReviews),
+		Bintang:    ratingAvg,
+		NewReviews: len(reviews),
+	})
+
+	predictReq := dto.PredictRequest{
+		Statements: statements,
+	}
+
+	// Nothing new to label; the stored labels cover every review.
+	skipLabelling := len(baseline) > 0 && len(reviews) == 0
+
+	var shopInfo dto.ShopInfoResponse
+	var predictResult dto.PredictResponse
+	var analyzeResult dto.AnalyzeResponse
+	var summarizeResult string
+
+	// The result is useless once one stage fails, so the first failure
+	// cancels the other stages instead of waiting for their LLM and ML calls.
+	stageCtx, cancelStages := context.WithCancel(ctx)
+	defer cancelStages()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	fail := func(stage string, message string, err error) {
+		once.Do(func() {
+			firstErr = analysisError(stage, message, err)
+			cancelStages()
+		})
+	}
+
+	wg.Add(4)
+
+	go func() {
+		defer wg.Done()
+		reportProgress(opts, dto.ANALYSIS_STAGE_SHOP_AVATAR, constants.ENUM_STAGE_STATUS_RUNNING)
+		var err error
+		shopInfo, err = provider.GetShopInfo(stageCtx, product)
+		if err != nil {
+			fail(dto.ANALYSIS_STAGE_SHOP_AVATAR, dto.MESSAGE_FAILED_GET_SHOP_AVATAR, err)
+		}
+		reportStageResult(opts, dto.ANALYSIS_STAGE_SHOP_AVATAR, err, shopInfo.Avatar)
+	}()
+
+	go func() {
+		defer wg.Done()
+		reportProgress(opts, dto.ANALYSIS_STAGE_PREDICT, constants.ENUM_STAGE_STATUS_RUNNING)
+		var err error
+		if !skipLabelling {
+			predictResult, err = s.modelService.Predict(stageCtx, predictReq)
+		}
+		if err != nil {
+			fail(dto.ANALYSIS_STAGE_PREDICT, dto.MESSAGE_FAILED_PREDICT, err)
+		}
+		reportStageResult(opts, dto.ANALYSIS_STAGE_PREDICT, err, predictResult)
+	}()
+
+	go func() {
+		defer wg.Done()
+		reportProgress(opts, dto.ANALYSIS_STAGE_ANALYZE, constants.ENUM_STAGE_STATUS_RUNNING)
+		var err error
+		if !skipLabelling {
+			analyzeResult, err = s.geminiService.Analyze(stageCtx, analyzeReviews)
+		}
+		if err != nil {
+			fail(dto.ANALYSIS_STAGE_ANALYZE, dto.MESSAGE_FAILED_ANALYZE, err)
+		}
+		if err == nil && len(baseline) > 0 {
+			analyzeResult = mergeAspectLabels(analyzeResult, baseline, len(allReviews)-len(reviews))
+		}
+		if err == nil {
+			for _, aspect := range aspectScores(analyzeResult) {
+				reportEvent(opts, dto.ANALYSIS_STAGE_ANALYZE, constants.ENUM_STAGE_STATUS_PARTIAL, aspect)
+			}
+		}
+		reportStageResult(opts, dto.ANALYSIS_STAGE_ANALYZE, err, analyzeResult)
+	}()
+
+	go func() {
+		defer wg.Done()
+		reportProgress(opts, dto.ANALYSIS_STAGE_SUMMARIZE, constants.ENUM_STAGE_STATUS_RUNNING)
+		var err error
+		switch {
+		case skipLabelling && baselineSummary != "":
+			summarizeResult = baselineSummary
+		case opts.StreamSummary:
+			summarizeResult, err = s.geminiService.SummarizeStream(stageCtx, concatenatedMessage, func(chunk string) {
+				reportEvent(opts, dto.ANALYSIS_STAGE_SUMMARIZE, constants.ENUM_STAGE_STATUS_PARTIAL, dto.AnalysisSummaryData{Text: chunk})
+			})
+		default:
+			summarizeResult, err = s.geminiService.Summarize(stageCtx, concatenatedMessage)
+		}
+		if err != nil {
+			fail(dto.ANALYSIS_STAGE_SUMMARIZE, dto.MESSAGE_FAILED_ANALYZE, err)
+		}
+		reportStageResult(opts, dto.ANALYSIS_STAGE_SUMMARIZE, err, dto.AnalysisSummaryData{Text: summarizeResult})
+	}()
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return dto.AnalysisResult{}, firstErr
+	}
+
+	// The ML service may only report batch counts, leaving the new reviews
+	// without a sentiment label.
+	unlabelled := 0
+	if !skipLabelling && len(predictResult.Predictions) != len(statements) {
+		unlabelled = len(statements)
+		logrus.WithFields(logrus.Fields{
+			"product_id":  product.ProductId,
+			"predictions": len(predictResult.Predictions),
+			"reviews":     len(statements),
+		}).Warn("prediction count does not match the reviews, storing them without a sentiment label")
+	}
+
+	if len(baseline) > 0 {
+		predictResult = mergePredictions(predictResult, len(reviews), baseline, len(allReviews)-len(reviews))
+	}
+
+	result := dto.AnalysisResult{
+		Provider:   provider.Name(),
+		ProductID:  product.ProductId,
+		ProductURL: product.ProductUrl,
+		Result: dto.MLResult{
+			Provider:           provider.Name(),
+			ProductName:        product.ProductName,
+			ProductDescription: product.ProductDescription,
+			Rating:             len(allReviews),
+			Ulasan:             predictResult.CountNegative + predictResult.CountPositive,
+			Bintang:            ratingAvg,
+			ImageUrls:          product.ImageUrls,
+			ShopName:           shopInfo.Name,
+			ShopAvatar:         shopInfo.Avatar,
+			CountNegative:      predictResult.CountNegative,
+			CountPositive:      predictResult.CountPositive,
+			Packaging:          analyzeResult.Packaging,
+			Delivery:           analyzeResult.Delivery,
+			AdminResponse:      analyzeResult.AdminResponse,
+			ProductCondition:   analyzeResult.ProductCondition,
+			Summary:            summarizeResult,
+			ProductMetadata:    product.ProductMetadata,
+			AspectLabels:       analyzeResult.Labels,
+			Incremental:        len(baseline) > 0,
+			NewReviews:         len(reviews),
+			UnlabelledReviews:  unlabelled,
+			Cache:              tracker.status(),
+		},
+	}
+
+	if userUUID == uuid.Nil {
+		return result, nil
+	}
+
+	reportProgress(opts, dto.ANALYSIS_STAGE_HISTORY, constants.ENUM_STAGE_STATUS_RUNNING)
+	history, err := s.historyService.CreateHistory(ctx, dto.HistoryCreateRequest{
+		UserID:           userUUID,
+		Provider:         result.Provider,
+		ProductID:        result.ProductID,
+		URL:              result.ProductURL,
+		Rating:           result.Result.Rating,
+		Ulasan:           result.Result.Ulasan,
+		Bintang:          result.Result.Bintang,
+		ProductName:      result.Result.ProductName,
+		CountPositive:    result.Result.CountPositive,
+		CountNegative:    result.Result.CountNegative,
+		Packaging:        result.Result.Packaging,
+		Delivery:         result.Result.Delivery,
+		AdminResponse:    result.Result.AdminResponse,
+		ProductCondition: result.Result.ProductCondition,
+		Summary:          result.Result.Summary,
+		ReviewOptions:    reviewOpts,
+		Reviews: dto.ReviewsSaveRequest{
+			Provider:     result.Provider,
+			ProductID:    result.ProductID,
+			Reviews:      allReviews,
+			Predictions:  predictResult.Predictions,
+			ModelVersion: predictResult.ModelVersion,
+			AspectLabels: analyzeResult.Labels,
+		},
+	})
+	if err != nil {
+		reportProgress(opts, dto.ANALYSIS_STAGE_HISTORY, constants.ENUM_STAGE_STATUS_FAILED)
+		return dto.AnalysisResult{}, analysisError(dto.ANALYSIS_STAGE_HISTORY, dto.MESSAGE_FAILED_CREATE_HISTORY, err)
+	}
+	result.HistoryID = history.ID
+
+	// Alerts are a side effect of the stored analysis and never fail it
+	if err := s.alertService.Evaluate(ctx, history.ID.String(), opts.UserID); err != nil {
+		logrus.WithError(err).WithField("history_id", history.ID).Warn("failed to evaluate alert rules")
+	}
+	reportEvent(opts, dto.ANALYSIS_STAGE_HISTORY, constants.ENUM_STAGE_STATUS_SUCCEEDED, nil)
+
+	return result, nil
+}
+
+// incrementalEligible reports whether a stored analysis can be extended
+// instead of recomputed. Filtered runs cover a different set of reviews,
+// so only the default newest-first harvest qualifies.
+func incrementalEligible(opts dto.AnalysisOptions, reviewOpts dto.ReviewOptions) bool {
+	return !opts.Refresh &&
+		reviewOpts.SortBy == dto.REVIEW_SORT_NEWEST &&
+		len(reviewOpts.Ratings) == 0 &&
+		reviewOpts.Since == nil &&
+		reviewOpts.Until == nil &&
+		!reviewOpts.WithMedia
+}
+
+// loadBaseline returns the labelled reviews and summary of the user's last
+// analysis of the product. It returns nothing when there is none, when it
+// was run with other review options, such as a lower max_reviews, or when
+// some of its reviews were never fully labelled.
+func (s *analysisService) loadBaseline(ctx context.Context, provider string, productId string, userId string, reviewOpts dto.ReviewOptions) ([]dto.AnalysedReview, string) {
+	history, err := s.historyService.GetLatestHistory(ctx, provider, productId, userId)
+	if err != nil {
+		return nil, ""
+	}
+	if history.ReviewOptions == nil || !reflect.DeepEqual(*history.ReviewOptions, reviewOpts) {
+		return nil, ""
+	}
+
+	analysed, err := s.reviewService.GetAnalysedReviews(ctx, history.ID.String())
+	if err != nil {
+		logrus.WithError(err).WithField("history_id", history.ID).Warn("failed to load analysed reviews")
+		return nil, ""
+	}
+
+	for _, review := range analysed {
+		if review.Prediction.Label == "" || review.AspectLabel.Sentiment == "" {
+			return nil, ""
+		}
+	}
+
+	return analysed, history.Summary
+}
+
+// uniqueReviews drops every review whose external id was already seen, so
+// the labels and the saved rows stay one per review.
+func uniqueReviews(reviews []dto.ReviewResponse) []dto.ReviewResponse {
+	seen := make(map[string]bool, len(reviews))
+	unique := make([]dto.ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		id := reviewExternalId(review)
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, review)
+		}
+	}
+	return unique
+}
+
+func excludeAnalysedReviews(reviews []dto.ReviewResponse, baseline []dto.AnalysedReview) []dto.ReviewResponse {
+	if len(baseline) == 0 {
+		return reviews
+	}
+
+	analysed := make(map[string]bool, len(baseline))
+	for _, review := range baseline {
+		analysed[review.Review.ID] = true
+	}
+
+	fresh := make([]dto.ReviewResponse, 0, len(reviews))
+	for _, review := range reviews {
+		if !analysed[reviewExternalId(review)] {
+			fresh = append(fresh, review)
+		}
+	}
+	return fresh
+}
+
+// mergeAspectLabels rescores the aspects over the new labels plus the
+// labels of the first keep baseline reviews.
+func mergeAspectLabels(res dto.AnalyzeResponse, baseline []dto.AnalysedReview, keep int) dto.AnalyzeResponse {
+	labels := make([]dto.ReviewAspectLabel, 0, len(res.Labels)+keep)
+	labels = append(labels, res.Labels...)
+	for _, review := range baseline[:keep] {
+		labels = append(labels, review.AspectLabel)
+	}
+	return scoreAspects(labels)
+}
+
+// mergePredictions recounts the sentiments over the newCount new reviews
+// plus the first keep baseline reviews, with the per-review predictions
+// aligned to the merged review list.
+func mergePredictions(res dto.PredictResponse, newCount int, baseline []dto.AnalysedReview, keep int) dto.PredictResponse {
+	predictions := make([]dto.StatementPrediction, newCount, newCount+keep)
+	countPositive, countNegative := 0, 0
+
+	// Without per-review output the new reviews can only be counted in
+	// aggregate and are stored unlabelled.
+	if len(res.Predictions) == newCount {
+		copy(predictions, res.Predictions)
+		for _, prediction := range res.Predictions {
+			switch strings.ToLower(prediction.Label) {
+			case constants.ENUM_SENTIMENT_POSITIVE:
+				countPositive++
+			case constants.ENUM_SENTIMENT_NEGATIVE:
+				countNegative++
+			}
+		}
+	} else {
+		countPositive, countNegative = res.CountPositive, res.CountNegative
+	}
+
+	for _, review := range baseline[:keep] {
+		predictions = append(predictions, review.Prediction)
+		switch review.Prediction.Label {
+		case constants.ENUM_SENTIMENT_POSITIVE:
+			countPositive++
+		case constants.ENUM_SENTIMENT_NEGATIVE:
+			countNegative++
+		}
+	}
+
+	modelVersion := res.ModelVersion
+	if modelVersion == "" && keep > 0 {
+		modelVersion = baseline[0].ModelVersion
+	}
+
+	return dto.PredictResponse{
+		CountPositive: countPositive,
+		CountNegative: countNegative,
+		Predictions:   predictions,
+		ModelVersion:  modelVersion,
+	}
+}
+
+func reportProgress(opts dto.AnalysisOptions, stage string, status string) {
+	reportEvent(opts, stage, status, nil)
+}
+
+func reportEvent(opts dto.AnalysisOptions, stage string, status string, data any) {
+	if opts.OnEvent != nil {
+		opts.OnEvent(dto.AnalysisEvent{
+			Stage:  stage,
+			Status: status,
+			Data:   data,
+		})
+	}
+}
+
+func reportStageResult(opts dto.AnalysisOptions, stage string, err error, data any) {
+	if err != nil {
+		reportProgress(opts, stage, constants.ENUM_STAGE_STATUS_FAILED)
+		return
+	}
+	reportEvent(opts, stage, constants.ENUM_STAGE_STATUS_SUCCEEDED, data)
+}
+
+func aspectScores(res dto.AnalyzeResponse) []dto.AnalysisAspectData {
+	return []dto.AnalysisAspectData{
+		{Aspect: dto.ASPECT_PACKAGING, Score: res.Packaging},
+		{Aspect: dto.ASPECT_DELIVERY, Score: res.Delivery},
+		{Aspect: dto.ASPECT_ADMIN_RESPONSE, Score: res.AdminResponse},
+		{Aspect: dto.ASPECT_PRODUCT_CONDITION, Score: res.ProductCondition},
+	}
+}
+
+func analysisError(stage string, message string, err error) error {
+	return &dto.AnalysisError{
+		Stage:   stage,
+		Message: message,
+		Err:     err,
+	}
+}
@@ -0,0 +1,472 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+	"review_product_tokopedia_be/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAnalysisJobAttempts   = 3
+	analysisJobPollInterval      = 2 * time.Second
+	analysisJobHeartbeatInterval = 30 * time.Second
+	// analysisJobStaleAfter allows a few missed heartbeats before a running
+	// job is handed to another worker.
+	analysisJobStaleAfter       = 2 * time.Minute
+	analysisJobStaleCheckPeriod = time.Minute
+)
+
+type (
+	AnalysisJobService interface {
+		CreateJob(ctx context.Context, req dto.AnalysisJobCreateRequest, userId string) (dto.AnalysisJobResponse, error)
+		CreateShopJob(ctx context.Context, req dto.ShopAnalysisRequest, userId string) (dto.AnalysisJobResponse, error)
+		GetJobById(ctx context.Context, jobId string, userId string) (dto.AnalysisJobResponse, error)
+
+		// Start launches the worker pool. Workers keep polling the queue
+		// until Stop or Shutdown is called.
+		Start()
+		// Stop cancels the running jobs and waits for the workers to exit.
+		// Cancelled jobs are picked up again once they become stale.
+		Stop()
+		// Shutdown stops claiming jobs and lets the running ones finish,
+		// cancelling them once ctx is done.
+		Shutdown(ctx context.Context) error
+	}
+
+	analysisJobService struct {
+		analysisJobRepo     repository.AnalysisJobRepository
+		analysisService     AnalysisService
+		shopAnalysisService ShopAnalysisService
+		workers             int
+		maxAttempts         int
+		heartbeatInterval   time.Duration
+
+		// cancel stops claiming jobs, abort cancels the jobs being run.
+		cancel context.CancelFunc
+		abort  context.CancelFunc
+		wg     sync.WaitGroup
+	}
+)
+
+func NewAnalysisJobService(analysisJobRepo repository.AnalysisJobRepository, analysisService AnalysisService, shopAnalysisService ShopAnalysisService, cfg config.AnalysisJobConfig) AnalysisJobService {
+	return &analysisJobService{
+		analysisJobRepo:     analysisJobRepo,
+		analysisService:     analysisService,
+		shopAnalysisService: shopAnalysisService,
+		workers:             cfg.Workers,
+		maxAttempts:         defaultAnalysisJobAttempts,
+		heartbeatInterval:   analysisJobHeartbeatInterval,
+	}
+}
+
+func (s *analysisJobService) CreateJob(ctx context.Context, req dto.AnalysisJobCreateRequest, userId string) (dto.AnalysisJobResponse, error) {
+	userUUID, err := uuid.Parse(userId)
+	if err != nil {
+		return dto.AnalysisJobResponse{}, dto.ErrInvalidUserId
+	}
+
+	if _, err := normalizeReviewOptions(req.Reviews); err != nil {
+		return dto.AnalysisJobResponse{}, err
+	}
+
+	reviewOpts, err := json.Marshal(req.Reviews)
+	if err != nil {
+		return dto.AnalysisJobResponse{}, dto.ErrMarshallJson
+	}
+
+	job := entity.AnalysisJob{
+		Kind:            constants.ENUM_JOB_KIND_PRODUCT,
+		ProductURL:      req.ProductUrl,
+		ReviewOptions:   string(reviewOpts),
+		Status:          constants.ENUM_JOB_STATUS_QUEUED,
+		ProductStatus:   constants.ENUM_STAGE_STATUS_PENDING,
+		ReviewsStatus:   constants.ENUM_STAGE_STATUS_PENDING,
+		PredictStatus:   constants.ENUM_STAGE_STATUS_PENDING,
+		AnalyzeStatus:   constants.ENUM_STAGE_STATUS_PENDING,
+		SummarizeStatus: constants.ENUM_STAGE_STATUS_PENDING,
+		UserID:          userUUID,
+		WatchlistID:     req.WatchlistID,
+	}
+
+	jobCreated, err := s.analysisJobRepo.CreateJob(ctx, nil, job)
+	if err != nil {
+		return dto.AnalysisJobResponse{}, dto.ErrCreateAnalysisJob
+	}
+
+	return toAnalysisJobResponse(jobCreated), nil
+}
+
+func (s *analysisJobService) CreateShopJob(ctx context.Context, req dto.ShopAnalysisRequest, userId string) (dto.AnalysisJobResponse, error) {
+	userUUID, err := uuid.Parse(userId)
+	if err != nil {
+		return dto.AnalysisJobResponse{}, dto.ErrInvalidUserId
+	}
+
+	req, err = normalizeShopAnalysisRequest(req)
+	if err != nil {
+		return dto.AnalysisJobResponse{}, err
+	}
+
+	shopOpts, err := json.Marshal(req)
+	if err != nil {
+		return dto.AnalysisJobResponse{}, dto.ErrMarshallJson
+	}
+
+	job := entity.AnalysisJob{
+		Kind:            constants.ENUM_JOB_KIND_SHOP,
+		ProductURL:      "https://www.tokopedia.com/" + req.ShopDomain,
+		ReviewOptions:   string(shopOpts),
+		Status:          constants.ENUM_JOB_STATUS_QUEUED,
+		ProductStatus:   constants.ENUM_STAGE_STATUS_PENDING,
+		ReviewsStatus:   constants.ENUM_STAGE_STATUS_PENDING,
+		PredictStatus:   constants.ENUM_STAGE_STATUS_PENDING,
+		AnalyzeStatus:   constants.ENUM_STAGE_STATUS_PENDING,
+		SummarizeStatus: constants.ENUM_STAGE_STATUS_PENDING,
+		UserID:          userUUID,
+	}
+
+	jobCreated, err := s.analysisJobRepo.CreateJob(ctx, nil, job)
+	if err != nil {
+		return dto.AnalysisJobResponse{}, dto.ErrCreateAnalysisJob
+	}
+
+	return toAnalysisJobResponse(jobCreated), nil
+}
+
+func (s *analysisJobService) GetJobById(ctx context.Context, jobId string, userId string) (dto.AnalysisJobResponse, error) {
+	job, err := s.analysisJobRepo.GetJobById(ctx, nil, jobId, userId)
+	if err != nil {
+		return dto.AnalysisJobResponse{}, dto.ErrGetAnalysisJob
+	}
+
+	return toAnalysisJobResponse(job), nil
+}
+
+func (s *analysisJobService) Start() {
+	runCtx, abort := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(runCtx)
+	s.cancel = cancel
+	s.abort = abort
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.requeueStaleJobs(ctx)
+	}()
+
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.work(ctx, runCtx)
+		}()
+	}
+}
+
+func (s *analysisJobService) Stop() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Shutdown(ctx)
+}
+
+func (s *analysisJobService) Shutdown(ctx context.Context) error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.abort()
+		return nil
+	case <-ctx.Done():
+		s.abort()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// work claims jobs until ctx is done and runs them under runCtx, so a
+// shutdown lets the current job finish.
+func (s *analysisJobService) work(ctx context.Context, runCtx context.Context) {
+	ticker := time.NewTicker(analysisJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before going back to sleep
+		for ctx.Err() == nil {
+			job, err := s.analysisJobRepo.ClaimNextJob(ctx, nil)
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) && ctx.Err() == nil {
+					logrus.WithError(err).Error("failed to claim analysis job")
+				}
+				break
+			}
+			s.run(runCtx, job)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *analysisJobService) run(ctx context.Context, job entity.AnalysisJob) {
+	jobId := job.ID.String()
+	log := logrus.WithField("job_id", jobId)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go s.heartbeat(ctx, cancel, job)
+
+	if job.Attempts > s.maxAttempts {
+		s.finish(job, map[string]any{
+			"status": constants.ENUM_JOB_STATUS_FAILED,
+			"error":  "maximum attempts exceeded",
+		})
+		return
+	}
+
+	if job.Kind == constants.ENUM_JOB_KIND_SHOP {
+		s.runShop(ctx, job)
+		return
+	}
+
+	var reviewOpts dto.ReviewOptions
+	if job.ReviewOptions != "" {
+		if err := json.Unmarshal([]byte(job.ReviewOptions), &reviewOpts); err != nil {
+			log.WithError(err).Warn("failed to decode analysis job review options")
+		}
+	}
+
+	result, err := s.analysisService.Analyze(ctx, job.ProductURL, dto.AnalysisOptions{
+		UserID:  job.UserID.String(),
+		Reviews: reviewOpts,
+		OnEvent: func(event dto.AnalysisEvent) {
+			column, ok := analysisJobStageColumns[event.Stage]
+			if !ok || event.Status == constants.ENUM_STAGE_STATUS_PARTIAL {
+				return
+			}
+			if err := s.analysisJobRepo.UpdateJob(ctx, nil, job, map[string]any{column: event.Status}); err != nil {
+				log.WithError(err).Warn("failed to update analysis job progress")
+			}
+		},
+	})
+	if err != nil {
+		// Leave the job running when the worker is shutting down or lost the
+		// job, so it is picked up again once it becomes stale.
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Warn("analysis job failed")
+		s.finish(job, map[string]any{
+			"status": constants.ENUM_JOB_STATUS_FAILED,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	encoded, err := json.Marshal(result.Result)
+	if err != nil {
+		log.WithError(err).Error("failed to encode analysis job result")
+	}
+
+	s.finish(job, map[string]any{
+		"status":     constants.ENUM_JOB_STATUS_SUCCEEDED,
+		"error":      "",
+		"result":     string(encoded),
+		"history_id": result.HistoryID,
+	})
+}
+
+// runShop runs a shop analysis job. The catalogue stage is reported as the
+// product stage, the per-product analyses as the reviews, predict and
+// analyze stages, and the shop summary as the summarize stage.
+func (s *analysisJobService) runShop(ctx context.Context, job entity.AnalysisJob) {
+	jobId := job.ID.String()
+	log := logrus.WithField("job_id", jobId)
+
+	var req dto.ShopAnalysisRequest
+	if err := json.Unmarshal([]byte(job.ReviewOptions), &req); err != nil {
+		s.finish(job, map[string]any{
+			"status": constants.ENUM_JOB_STATUS_FAILED,
+			"error":  "invalid shop analysis options",
+		})
+		return
+	}
+
+	result, err := s.shopAnalysisService.Analyze(ctx, req, func(event dto.AnalysisEvent) {
+		columns, ok := shopJobStageColumns[event.Stage]
+		if !ok || event.Status == constants.ENUM_STAGE_STATUS_PARTIAL {
+			return
+		}
+		fields := make(map[string]any, len(columns))
+		for _, column := range columns {
+			fields[column] = event.Status
+		}
+		if err := s.analysisJobRepo.UpdateJob(ctx, nil, job, fields); err != nil {
+			log.WithError(err).Warn("failed to update analysis job progress")
+		}
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithError(err).Warn("shop analysis job failed")
+		s.finish(job, map[string]any{
+			"status": constants.ENUM_JOB_STATUS_FAILED,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		log.WithError(err).Error("failed to encode shop analysis job result")
+	}
+
+	s.finish(job, map[string]any{
+		"status": constants.ENUM_JOB_STATUS_SUCCEEDED,
+		"error":  "",
+		"result": string(encoded),
+	})
+}
+
+// finish records the final state of a job. It uses its own context so the
+// outcome is persisted even if the worker is being stopped. The outcome is
+// dropped when the job was requeued in the meantime.
+func (s *analysisJobService) finish(job entity.AnalysisJob, fields map[string]any) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	log := logrus.WithField("job_id", job.ID.String())
+	fields["finished_at"] = time.Now()
+	fields["heartbeat_at"] = nil
+	err := s.analysisJobRepo.UpdateJob(ctx, nil, job, fields)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		log.Warn("analysis job was requeued while running, dropping its outcome")
+	case err != nil:
+		log.WithError(err).Error("failed to finish analysis job")
+	}
+}
+
+// heartbeat keeps the claim on job alive until ctx is done. When the job
+// was requeued anyway, e.g. after the database was unreachable for a
+// while, cancel stops this run so it does not race the new one.
+func (s *analysisJobService) heartbeat(ctx context.Context, cancel context.CancelFunc, job entity.AnalysisJob) {
+	ticker := time.NewTicker(s.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := s.analysisJobRepo.Heartbeat(ctx, nil, job)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			logrus.WithField("job_id", job.ID.String()).Warn("analysis job was requeued while running, cancelling it")
+			cancel()
+			return
+		case err != nil && ctx.Err() == nil:
+			logrus.WithError(err).WithField("job_id", job.ID.String()).Warn("failed to send analysis job heartbeat")
+		}
+	}
+}
+
+func (s *analysisJobService) requeueStaleJobs(ctx context.Context) {
+	ticker := time.NewTicker(analysisJobStaleCheckPeriod)
+	defer ticker.Stop()
+
+	for {
+		count, err := s.analysisJobRepo.RequeueStaleJobs(ctx, nil, time.Now().Add(-analysisJobStaleAfter))
+		if err != nil && ctx.Err() == nil {
+			logrus.WithError(err).Error("failed to requeue stale analysis jobs")
+		}
+		if count > 0 {
+			logrus.WithField("count", count).Info("requeued stale analysis jobs")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+var analysisJobStageColumns = map[string]string{
+	dto.ANALYSIS_STAGE_PRODUCT:   "product_status",
+	dto.ANALYSIS_STAGE_REVIEWS:   "reviews_status",
+	dto.ANALYSIS_STAGE_PREDICT:   "predict_status",
+	dto.ANALYSIS_STAGE_ANALYZE:   "analyze_status",
+	dto.ANALYSIS_STAGE_SUMMARIZE: "summarize_status",
+}
+
+var shopJobStageColumns = map[string][]string{
+	dto.SHOP_ANALYSIS_STAGE_CATALOGUE: {"product_status"},
+	dto.SHOP_ANALYSIS_STAGE_PRODUCTS:  {"reviews_status", "predict_status", "analyze_status"},
+	dto.SHOP_ANALYSIS_STAGE_SUMMARIZE: {"summarize_status"},
+}
+
+func toAnalysisJobResponse(job entity.AnalysisJob) dto.AnalysisJobResponse {
+	res := dto.AnalysisJobResponse{
+		ID:         job.ID,
+		Kind:       job.Kind,
+		ProductURL: job.ProductURL,
+		Status:     job.Status,
+		Stages: dto.AnalysisJobStages{
+			Product:   job.ProductStatus,
+			Reviews:   job.ReviewsStatus,
+			Predict:   job.PredictStatus,
+			Analyze:   job.AnalyzeStatus,
+			Summarize: job.SummarizeStatus,
+		},
+		Error:      job.Error,
+		Attempts:   job.Attempts,
+		HistoryID:  job.HistoryID,
+		CreatedAt:  job.CreatedAt,
+		StartedAt:  job.StartedAt,
+		FinishedAt: job.FinishedAt,
+	}
+
+	if job.Status == constants.ENUM_JOB_STATUS_SUCCEEDED && job.Result != "" {
+		switch job.Kind {
+		case constants.ENUM_JOB_KIND_SHOP:
+			var result dto.ShopAnalysisResult
+			if err := json.Unmarshal([]byte(job.Result), &result); err == nil {
+				res.ShopResult = &result
+			}
+		default:
+			var result dto.MLResult
+			if err := json.Unmarshal([]byte(job.Result), &result); err == nil {
+				res.Result = &result
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+	"review_product_tokopedia_be/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeAnalysisJobRepository holds a single claimed job. Once requeued is
+// set, every update of the claim fails like it does in the database.
+type fakeAnalysisJobRepository struct {
+	repository.AnalysisJobRepository
+
+	mu       sync.Mutex
+	requeued bool
+	updates  []map[string]any
+}
+
+func (r *fakeAnalysisJobRepository) UpdateJob(ctx context.Context, tx *gorm.DB, job entity.AnalysisJob, fields map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.requeued {
+		return gorm.ErrRecordNotFound
+	}
+	r.updates = append(r.updates, fields)
+	return nil
+}
+
+func (r *fakeAnalysisJobRepository) Heartbeat(ctx context.Context, tx *gorm.DB, job entity.AnalysisJob) error {
+	return r.UpdateJob(ctx, tx, job, map[string]any{"heartbeat_at": time.Now()})
+}
+
+func (r *fakeAnalysisJobRepository) finished() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, fields := range r.updates {
+		if _, ok := fields["finished_at"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+type analyzeFunc func(ctx context.Context, productUrl string, opts dto.AnalysisOptions) (dto.AnalysisResult, error)
+
+func (f analyzeFunc) Analyze(ctx context.Context, productUrl string, opts dto.AnalysisOptions) (dto.AnalysisResult, error) {
+	return f(ctx, productUrl, opts)
+}
+
+func newTestAnalysisJobService(repo repository.AnalysisJobRepository, analyze analyzeFunc) *analysisJobService {
+	return &analysisJobService{
+		analysisJobRepo:   repo,
+		analysisService:   analyze,
+		maxAttempts:       defaultAnalysisJobAttempts,
+		heartbeatInterval: 10 * time.Millisecond,
+	}
+}
+
+func TestAnalysisJobHeartbeat(t *testing.T) {
+	repo := &fakeAnalysisJobRepository{}
+	var heartbeats int
+	s := newTestAnalysisJobService(repo, func(ctx context.Context, productUrl string, opts dto.AnalysisOptions) (dto.AnalysisResult, error) {
+		time.Sleep(50 * time.Millisecond)
+		repo.mu.Lock()
+		heartbeats = len(repo.updates)
+		repo.mu.Unlock()
+		return dto.AnalysisResult{}, nil
+	})
+
+	s.run(context.Background(), entity.AnalysisJob{ID: uuid.New(), Status: constants.ENUM_JOB_STATUS_RUNNING, Attempts: 1})
+
+	if heartbeats == 0 {
+		t.Errorf("no heartbeat sent while the job ran")
+	}
+	if !repo.finished() {
+		t.Errorf("job was not finished")
+	}
+}
+
+func TestAnalysisJobRequeuedWhileRunning(t *testing.T) {
+	repo := &fakeAnalysisJobRepository{requeued: true}
+	s := newTestAnalysisJobService(repo, func(ctx context.Context, productUrl string, opts dto.AnalysisOptions) (dto.AnalysisResult, error) {
+		select {
+		case <-ctx.Done():
+			return dto.AnalysisResult{}, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return dto.AnalysisResult{}, nil
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		s.run(context.Background(), entity.AnalysisJob{ID: uuid.New(), Status: constants.ENUM_JOB_STATUS_RUNNING, Attempts: 1})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the run was not cancelled after its claim was lost")
+	}
+	if repo.finished() {
+		t.Errorf("a run that lost its claim recorded an outcome")
+	}
+}
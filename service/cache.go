@@ -0,0 +1,268 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"sync"
+	"time"
+
+	"review_product_tokopedia_be/cache"
+	"review_product_tokopedia_be/dto"
+
+	"github.com/sirupsen/logrus"
+)
+
+type (
+	// cacheTracker travels in the context of one analysis. It carries the
+	// refresh flag and the product the cached steps belong to, and records
+	// which stages were answered from cache.
+	cacheTracker struct {
+		mu      sync.Mutex
+		refresh bool
+		scope   string
+		stages  map[string]bool
+	}
+
+	cacheTrackerKey struct{}
+)
+
+func withCacheTracker(ctx context.Context, refresh bool) (context.Context, *cacheTracker) {
+	tracker := &cacheTracker{
+		refresh: refresh,
+		stages:  make(map[string]bool),
+	}
+	return context.WithValue(ctx, cacheTrackerKey{}, tracker), tracker
+}
+
+func cacheTrackerFrom(ctx context.Context) *cacheTracker {
+	tracker, _ := ctx.Value(cacheTrackerKey{}).(*cacheTracker)
+	return tracker
+}
+
+func (t *cacheTracker) setScope(scope string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.scope = scope
+}
+
+func (t *cacheTracker) snapshot() (refresh bool, scope string) {
+	if t == nil {
+		return false, ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.refresh, t.scope
+}
+
+// record marks a stage as a hit only if every lookup made for it hit.
+func (t *cacheTracker) record(stage string, hit bool) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if prev, ok := t.stages[stage]; ok {
+		hit = hit && prev
+	}
+	t.stages[stage] = hit
+}
+
+// status returns nil when nothing went through the cache, which is the
+// case when caching is disabled.
+func (t *cacheTracker) status() *dto.CacheStatus {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.stages) == 0 {
+		return nil
+	}
+
+	status := &dto.CacheStatus{
+		Hit:    true,
+		Stages: make(map[string]bool, len(t.stages)),
+	}
+	for stage, hit := range t.stages {
+		status.Stages[stage] = hit
+		status.Hit = status.Hit && hit
+	}
+	return status
+}
+
+// cached returns the value stored under key, or computes and stores it.
+// Cache failures are logged and fall through to compute so a broken cache
+// never fails a request.
+func cached[T any](ctx context.Context, c cache.Cache, ttl time.Duration, stage string, key string, compute func() (T, error)) (T, error) {
+	tracker := cacheTrackerFrom(ctx)
+	log := logrus.WithField("cache_key", key)
+
+	if refresh, _ := tracker.snapshot(); !refresh {
+		raw, ok, err := c.Get(ctx, key)
+		if err != nil {
+			log.WithError(err).Warn("failed to read from cache")
+		}
+		if ok {
+			var value T
+			if err := json.Unmarshal(raw, &value); err == nil {
+				tracker.record(stage, true)
+				return value, nil
+			}
+		}
+	}
+
+	value, err := compute()
+	if err != nil {
+		return value, err
+	}
+	tracker.record(stage, false)
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return value, nil
+	}
+	if err := c.Set(ctx, key, raw, ttl); err != nil {
+		log.WithError(err).Warn("failed to write to cache")
+	}
+
+	return value, nil
+}
+
+// cacheKey namespaces a hash of the inputs by operation and, when known,
+// by the product being analysed.
+func cacheKey(ctx context.Context, operation string, inputs ...any) string {
+	_, scope := cacheTrackerFrom(ctx).snapshot()
+
+	hash := sha256.New()
+	for _, input := range inputs {
+		encoded, _ := json.Marshal(input)
+		hash.Write(encoded)
+	}
+
+	key := "analysis:" + operation + ":"
+	if scope != "" {
+		key += scope + ":"
+	}
+	return key + hex.EncodeToString(hash.Sum(nil))
+}
+
+type cachedMarketplaceProvider struct {
+	MarketplaceProvider
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedMarketplaceProvider caches scraped products, reviews and shop
+// details. It returns provider unchanged when c is nil.
+func NewCachedMarketplaceProvider(provider MarketplaceProvider, c cache.Cache, ttl time.Duration) MarketplaceProvider {
+	if c == nil {
+		return provider
+	}
+	return &cachedMarketplaceProvider{
+		MarketplaceProvider: provider,
+		cache:               c,
+		ttl:                 ttl,
+	}
+}
+
+func (p *cachedMarketplaceProvider) ResolveProduct(ctx context.Context, productUrl *url.URL) (dto.GetProductResponse, error) {
+	key := cacheKey(ctx, "product", p.Name(), productUrl.String())
+	return cached(ctx, p.cache, p.ttl, dto.ANALYSIS_STAGE_PRODUCT, key, func() (dto.GetProductResponse, error) {
+		return p.MarketplaceProvider.ResolveProduct(ctx, productUrl)
+	})
+}
+
+func (p *cachedMarketplaceProvider) GetReviews(ctx context.Context, req dto.GetReviewsRequest) ([]dto.ReviewResponse, error) {
+	key := cacheKey(ctx, "reviews", p.Name(), req.ProductId, req.Options)
+	return cached(ctx, p.cache, p.ttl, dto.ANALYSIS_STAGE_REVIEWS, key, func() ([]dto.ReviewResponse, error) {
+		return p.MarketplaceProvider.GetReviews(ctx, req)
+	})
+}
+
+func (p *cachedMarketplaceProvider) GetShopInfo(ctx context.Context, product dto.GetProductResponse) (dto.ShopInfoResponse, error) {
+	key := cacheKey(ctx, "shop", p.Name(), product.ShopId, product.ShopDomain)
+	return cached(ctx, p.cache, p.ttl, dto.ANALYSIS_STAGE_SHOP_AVATAR, key, func() (dto.ShopInfoResponse, error) {
+		return p.MarketplaceProvider.GetShopInfo(ctx, product)
+	})
+}
+
+type cachedModelService struct {
+	ModelService
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedModelService caches predictions by the statements sent. It
+// returns modelService unchanged when c is nil.
+func NewCachedModelService(modelService ModelService, c cache.Cache, ttl time.Duration) ModelService {
+	if c == nil {
+		return modelService
+	}
+	return &cachedModelService{
+		ModelService: modelService,
+		cache:        c,
+		ttl:          ttl,
+	}
+}
+
+func (s *cachedModelService) Predict(ctx context.Context, req dto.PredictRequest) (dto.PredictResponse, error) {
+	key := cacheKey(ctx, "predict", req)
+	return cached(ctx, s.cache, s.ttl, dto.ANALYSIS_STAGE_PREDICT, key, func() (dto.PredictResponse, error) {
+		return s.ModelService.Predict(ctx, req)
+	})
+}
+
+type cachedGeminiService struct {
+	GeminiService
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedGeminiService caches aspect labels and summaries by the reviews
+// sent. It returns geminiService unchanged when c is nil.
+func NewCachedGeminiService(geminiService GeminiService, c cache.Cache, ttl time.Duration) GeminiService {
+	if c == nil {
+		return geminiService
+	}
+	return &cachedGeminiService{
+		GeminiService: geminiService,
+		cache:         c,
+		ttl:           ttl,
+	}
+}
+
+func (s *cachedGeminiService) Analyze(ctx context.Context, reviews []dto.AnalyzeReview) (dto.AnalyzeResponse, error) {
+	key := cacheKey(ctx, "analyze", reviews)
+	return cached(ctx, s.cache, s.ttl, dto.ANALYSIS_STAGE_ANALYZE, key, func() (dto.AnalyzeResponse, error) {
+		return s.GeminiService.Analyze(ctx, reviews)
+	})
+}
+
+func (s *cachedGeminiService) Summarize(ctx context.Context, summarizeReq string) (string, error) {
+	key := cacheKey(ctx, "summarize", summarizeReq)
+	return cached(ctx, s.cache, s.ttl, dto.ANALYSIS_STAGE_SUMMARIZE, key, func() (string, error) {
+		return s.GeminiService.Summarize(ctx, summarizeReq)
+	})
+}
+
+// SummarizeStream keeps its entries apart from Summarize, since streamed
+// text is not validated like a summary is. A cached summary is delivered
+// as a single chunk.
+func (s *cachedGeminiService) SummarizeStream(ctx context.Context, summarizeReq string, onChunk func(chunk string)) (string, error) {
+	key := cacheKey(ctx, "summarize_stream", summarizeReq)
+	streamed := false
+	summary, err := cached(ctx, s.cache, s.ttl, dto.ANALYSIS_STAGE_SUMMARIZE, key, func() (string, error) {
+		streamed = true
+		return s.GeminiService.SummarizeStream(ctx, summarizeReq, onChunk)
+	})
+	if err == nil && !streamed {
+		onChunk(summary)
+	}
+	return summary, err
+}
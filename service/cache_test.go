@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"review_product_tokopedia_be/cache"
+	"review_product_tokopedia_be/dto"
+)
+
+func TestCacheKeyStable(t *testing.T) {
+	ctx := context.Background()
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	// The key format is part of what is stored, so a change here orphans
+	// every existing entry. Update the constant only on purpose.
+	const want = "analysis:reviews:1dd47cf82d5b73a5e3e4a9d3e8abd65df91802a377dfbe2a74a5f159b1a48a3e"
+	got := cacheKey(ctx, "reviews", "tokopedia", "2150001234", dto.ReviewOptions{MaxReviews: 100, SortBy: dto.REVIEW_SORT_NEWEST})
+	if got != want {
+		t.Errorf("cacheKey = %q, want %q", got, want)
+	}
+
+	options := []dto.ReviewOptions{
+		{},
+		{MaxReviews: 100},
+		{MaxReviews: 200},
+		{SortBy: dto.REVIEW_SORT_NEWEST},
+		{SortBy: dto.REVIEW_SORT_LOWEST_RATING},
+		{Ratings: []int{1}},
+		{Ratings: []int{1, 2}},
+		{Since: &since},
+		{Until: &since},
+		{Since: &since, Until: &until},
+		{WithMedia: true},
+		{MaxReviews: 100, SortBy: dto.REVIEW_SORT_MOST_HELPFUL, Ratings: []int{4, 5}, Since: &since, Until: &until, WithMedia: true},
+	}
+
+	seen := make(map[string]int, len(options))
+	for i, opts := range options {
+		key := cacheKey(ctx, "reviews", "tokopedia", "2150001234", opts)
+		if again := cacheKey(ctx, "reviews", "tokopedia", "2150001234", opts); again != key {
+			t.Errorf("options %d: key changed between calls: %q, %q", i, key, again)
+		}
+		if j, ok := seen[key]; ok {
+			t.Errorf("options %d and %d share key %q", j, i, key)
+		}
+		seen[key] = i
+	}
+}
+
+func TestCacheKeyNormalizedOptions(t *testing.T) {
+	ctx := context.Background()
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	since := time.Date(2024, 1, 1, 7, 0, 0, 0, jakarta)
+	sinceUTC := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	equivalent := [][2]dto.ReviewOptions{
+		{{}, {MaxReviews: dto.DEFAULT_MAX_REVIEWS, SortBy: dto.REVIEW_SORT_NEWEST}},
+		{{Ratings: []int{5, 4}}, {Ratings: []int{4, 5}}},
+		{{Ratings: []int{4, 5, 4}}, {Ratings: []int{4, 5}}},
+		{{Since: &since}, {Since: &sinceUTC}},
+	}
+
+	for i, pair := range equivalent {
+		a, err := normalizeReviewOptions(pair[0])
+		if err != nil {
+			t.Fatalf("pair %d: normalizeReviewOptions: %v", i, err)
+		}
+		b, err := normalizeReviewOptions(pair[1])
+		if err != nil {
+			t.Fatalf("pair %d: normalizeReviewOptions: %v", i, err)
+		}
+		if ka, kb := cacheKey(ctx, "reviews", a), cacheKey(ctx, "reviews", b); ka != kb {
+			t.Errorf("pair %d: keys differ: %q, %q", i, ka, kb)
+		}
+	}
+
+	ratings := []int{5, 4}
+	if _, err := normalizeReviewOptions(dto.ReviewOptions{Ratings: ratings}); err != nil {
+		t.Fatalf("normalizeReviewOptions: %v", err)
+	}
+	if ratings[0] != 5 {
+		t.Errorf("normalizeReviewOptions reordered the caller's ratings: %v", ratings)
+	}
+}
+
+func TestCacheKeyNamespaces(t *testing.T) {
+	ctx := context.Background()
+	scoped, tracker := withCacheTracker(ctx, false)
+	tracker.setScope("tokopedia:2150001234")
+
+	keys := []string{
+		cacheKey(ctx, "summarize", "reviews"),
+		cacheKey(ctx, "analyze", "reviews"),
+		cacheKey(scoped, "summarize", "reviews"),
+		cacheKey(ctx, "summarize", "review", "s"),
+	}
+	for i := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			if keys[i] == keys[j] {
+				t.Errorf("keys %d and %d collide: %q", i, j, keys[i])
+			}
+		}
+	}
+	if want := "analysis:summarize:tokopedia:2150001234:"; keys[2][:len(want)] != want {
+		t.Errorf("scoped key = %q, want prefix %q", keys[2], want)
+	}
+}
+
+type countingSummarizer struct {
+	GeminiService
+
+	summarized, streamed int
+}
+
+func (s *countingSummarizer) Summarize(ctx context.Context, summarizeReq string) (string, error) {
+	s.summarized++
+	return "Bagus.", nil
+}
+
+func (s *countingSummarizer) SummarizeStream(ctx context.Context, summarizeReq string, onChunk func(chunk string)) (string, error) {
+	s.streamed++
+	onChunk("Bagus, ")
+	onChunk("tidak divalidasi")
+	return "Bagus, tidak divalidasi", nil
+}
+
+func TestCachedSummarizeStreamKeepsOwnEntries(t *testing.T) {
+	ctx := context.Background()
+	inner := &countingSummarizer{}
+	s := NewCachedGeminiService(inner, cache.NewLRUCache(10), time.Hour)
+
+	var chunks []string
+	onChunk := func(chunk string) { chunks = append(chunks, chunk) }
+
+	if _, err := s.SummarizeStream(ctx, "reviews", onChunk); err != nil {
+		t.Fatalf("SummarizeStream: %v", err)
+	}
+	summary, err := s.Summarize(ctx, "reviews")
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	if summary != "Bagus." || inner.summarized != 1 {
+		t.Errorf("Summarize = %q after %d calls, want the validated summary", summary, inner.summarized)
+	}
+
+	chunks = nil
+	streamed, err := s.SummarizeStream(ctx, "reviews", onChunk)
+	if err != nil {
+		t.Fatalf("SummarizeStream: %v", err)
+	}
+	if inner.streamed != 1 || streamed != "Bagus, tidak divalidasi" || len(chunks) != 1 || chunks[0] != streamed {
+		t.Errorf("cached stream = %q in chunks %q after %d calls", streamed, chunks, inner.streamed)
+	}
+}
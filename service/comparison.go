@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+	"review_product_tokopedia_be/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	minCompareProducts = 2
+	maxCompareProducts = 5
+	compareConcurrency = 2
+)
+
+// comparisonMetrics are the columns of the comparison table. Every column
+// favours the higher value except the negative ratio.
+var comparisonMetrics = []struct {
+	name         string
+	higherBetter bool
+	value        func(dto.ComparisonProduct) float64
+}{
+	{dto.COMPARE_METRIC_BINTANG, true, func(p dto.ComparisonProduct) float64 { return p.Bintang }},
+	{dto.COMPARE_METRIC_POSITIVE_RATIO, true, func(p dto.ComparisonProduct) float64 { return p.PositiveRatio }},
+	{dto.COMPARE_METRIC_NEGATIVE_RATIO, false, func(p dto.ComparisonProduct) float64 { return p.NegativeRatio }},
+	{dto.COMPARE_METRIC_PACKAGING, true, func(p dto.ComparisonProduct) float64 { return float64(p.Packaging) }},
+	{dto.COMPARE_METRIC_DELIVERY, true, func(p dto.ComparisonProduct) float64 { return float64(p.Delivery) }},
+	{dto.COMPARE_METRIC_ADMIN_RESPONSE, true, func(p dto.ComparisonProduct) float64 { return float64(p.AdminResponse) }},
+	{dto.COMPARE_METRIC_PRODUCT_CONDITION, true, func(p dto.ComparisonProduct) float64 { return float64(p.ProductCondition) }},
+}
+
+type (
+	ComparisonService interface {
+		// Compare analyses every product, at most compareConcurrency at a
+		// time, and builds a comparison table with a written verdict.
+		Compare(ctx context.Context, req dto.ComparisonCreateRequest, userId string) (dto.ComparisonResponse, error)
+		GetComparisons(ctx context.Context, userId string) ([]dto.ComparisonResponse, error)
+		GetComparisonById(ctx context.Context, comparisonId string, userId string) (dto.ComparisonResponse, error)
+	}
+
+	comparisonService struct {
+		comparisonRepo  repository.ComparisonRepository
+		analysisService AnalysisService
+		geminiService   GeminiService
+	}
+)
+
+func NewComparisonService(comparisonRepo repository.ComparisonRepository, analysisService AnalysisService, geminiService GeminiService) ComparisonService {
+	return &comparisonService{
+		comparisonRepo:  comparisonRepo,
+		analysisService: analysisService,
+		geminiService:   geminiService,
+	}
+}
+
+func (s *comparisonService) Compare(ctx context.Context, req dto.ComparisonCreateRequest, userId string) (dto.ComparisonResponse, error) {
+	userUUID, err := uuid.Parse(userId)
+	if err != nil {
+		return dto.ComparisonResponse{}, analysisError(dto.ANALYSIS_STAGE_HISTORY, dto.MESSAGE_FAILED_COMPARE_PRODUCTS, dto.ErrInvalidUserId)
+	}
+
+	if len(req.ProductUrls) < minCompareProducts || len(req.ProductUrls) > maxCompareProducts {
+		return dto.ComparisonResponse{}, analysisError(dto.ANALYSIS_STAGE_URL, dto.MESSAGE_FAILED_COMPARE_PRODUCTS, dto.ErrCompareProductCount)
+	}
+	seen := make(map[string]bool, len(req.ProductUrls))
+	for _, productUrl := range req.ProductUrls {
+		if seen[productUrl] {
+			return dto.ComparisonResponse{}, analysisError(dto.ANALYSIS_STAGE_URL, dto.MESSAGE_FAILED_COMPARE_PRODUCTS, dto.ErrCompareDuplicateUrl)
+		}
+		seen[productUrl] = true
+	}
+
+	products, err := s.analyzeAll(ctx, req.ProductUrls, userId)
+	if err != nil {
+		return dto.ComparisonResponse{}, err
+	}
+
+	verdict, err := s.geminiService.Compare(ctx, products)
+	if err != nil {
+		return dto.ComparisonResponse{}, analysisError(dto.ANALYSIS_STAGE_COMPARE, dto.MESSAGE_FAILED_COMPARE_PRODUCTS, err)
+	}
+
+	res := dto.ComparisonResponse{
+		Products: products,
+		Winners:  comparisonWinners(products),
+		Verdict:  verdict,
+	}
+
+	if !req.Save {
+		return res, nil
+	}
+
+	items := make([]entity.ComparisonItem, len(products))
+	for i, product := range products {
+		items[i] = entity.ComparisonItem{
+			Position:  product.Position,
+			HistoryID: *product.HistoryID,
+		}
+	}
+
+	comparison, err := s.comparisonRepo.CreateComparison(ctx, nil, entity.Comparison{
+		Verdict: verdict,
+		UserID:  userUUID,
+		Items:   items,
+	})
+	if err != nil {
+		return dto.ComparisonResponse{}, analysisError(dto.ANALYSIS_STAGE_HISTORY, dto.MESSAGE_FAILED_CREATE_COMPARISON, dto.ErrCreateComparison)
+	}
+	res.ID = &comparison.ID
+	res.CreatedAt = &comparison.CreatedAt
+
+	return res, nil
+}
+
+// analyzeAll runs the analyses concurrently and cancels the remaining ones
+// as soon as one fails, since the comparison is useless without it.
+func (s *comparisonService) analyzeAll(ctx context.Context, productUrls []string, userId string) ([]dto.ComparisonProduct, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	products := make([]dto.ComparisonProduct, len(productUrls))
+	sem := make(chan struct{}, compareConcurrency)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i, productUrl := range productUrls {
+		wg.Add(1)
+		go func(i int, productUrl string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			result, err := s.analysisService.Analyze(ctx, productUrl, dto.AnalysisOptions{UserID: userId})
+			if err != nil {
+				once.Do(func() {
+					firstErr = compareProductError(i+1, productUrl, err)
+					cancel()
+				})
+				return
+			}
+
+			historyId := result.HistoryID
+			products[i] = toComparisonProduct(i+1, dto.ComparisonProduct{
+				HistoryID:        &historyId,
+				Provider:         result.Provider,
+				ProductID:        result.ProductID,
+				ProductURL:       result.ProductURL,
+				ProductName:      result.Result.ProductName,
+				Rating:           result.Result.Rating,
+				Ulasan:           result.Result.Ulasan,
+				Bintang:          result.Result.Bintang,
+				CountPositive:    result.Result.CountPositive,
+				CountNegative:    result.Result.CountNegative,
+				Packaging:        result.Result.Packaging,
+				Delivery:         result.Result.Delivery,
+				AdminResponse:    result.Result.AdminResponse,
+				ProductCondition: result.Result.ProductCondition,
+			})
+		}(i, productUrl)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	return products, nil
+}
+
+func (s *comparisonService) GetComparisons(ctx context.Context, userId string) ([]dto.ComparisonResponse, error) {
+	comparisons, err := s.comparisonRepo.GetComparisons(ctx, nil, userId)
+	if err != nil {
+		return nil, dto.ErrGetComparisons
+	}
+
+	res := make([]dto.ComparisonResponse, len(comparisons))
+	for i, comparison := range comparisons {
+		res[i] = toComparisonResponse(comparison)
+	}
+
+	return res, nil
+}
+
+func (s *comparisonService) GetComparisonById(ctx context.Context, comparisonId string, userId string) (dto.ComparisonResponse, error) {
+	comparison, err := s.comparisonRepo.GetComparisonById(ctx, nil, comparisonId, userId)
+	if err != nil {
+		return dto.ComparisonResponse{}, dto.ErrGetComparison
+	}
+
+	return toComparisonResponse(comparison), nil
+}
+
+// compareProductError keeps the stage and message of a failed analysis and
+// points out which of the products it was.
+func compareProductError(position int, productUrl string, err error) error {
+	stage, message := dto.ANALYSIS_STAGE_URL, dto.MESSAGE_FAILED_COMPARE_PRODUCTS
+	var analysisErr *dto.AnalysisError
+	if errors.As(err, &analysisErr) {
+		stage, message = analysisErr.Stage, analysisErr.Message
+	}
+	return analysisError(stage, message, fmt.Errorf("product %d (%s): %w", position, productUrl, err))
+}
+
+// toComparisonProduct fills in the position and the sentiment ratios.
+func toComparisonProduct(position int, product dto.ComparisonProduct) dto.ComparisonProduct {
+	product.Position = position
+
+	labelled := float64(product.CountPositive + product.CountNegative)
+	if labelled > 0 {
+		product.PositiveRatio = math.Round(float64(product.CountPositive)/labelled*10000) / 100
+		product.NegativeRatio = math.Round(float64(product.CountNegative)/labelled*10000) / 100
+	}
+
+	return product
+}
+
+func comparisonWinners(products []dto.ComparisonProduct) map[string][]int {
+	winners := make(map[string][]int, len(comparisonMetrics))
+	for _, metric := range comparisonMetrics {
+		best := metric.value(products[0])
+		for _, product := range products[1:] {
+			value := metric.value(product)
+			if (metric.higherBetter && value > best) || (!metric.higherBetter && value < best) {
+				best = value
+			}
+		}
+
+		for _, product := range products {
+			if metric.value(product) == best {
+				winners[metric.name] = append(winners[metric.name], product.Position)
+			}
+		}
+	}
+	return winners
+}
+
+func toComparisonResponse(comparison entity.Comparison) dto.ComparisonResponse {
+	products := make([]dto.ComparisonProduct, len(comparison.Items))
+	for i, item := range comparison.Items {
+		historyId := item.HistoryID
+		history := item.History
+		products[i] = toComparisonProduct(item.Position, dto.ComparisonProduct{
+			HistoryID:        &historyId,
+			Provider:         history.Provider,
+			ProductID:        history.ProductID,
+			ProductURL:       history.URL,
+			ProductName:      history.ProductName,
+			Rating:           history.Rating,
+			Ulasan:           history.Ulasan,
+			Bintang:          history.Bintang,
+			CountPositive:    history.CountPositive,
+			CountNegative:    history.CountNegative,
+			Packaging:        history.Packaging,
+			Delivery:         history.Delivery,
+			AdminResponse:    history.AdminResponse,
+			ProductCondition: history.ProductCondition,
+		})
+	}
+
+	var winners map[string][]int
+	if len(products) > 0 {
+		winners = comparisonWinners(products)
+	}
+
+	id := comparison.ID
+	createdAt := comparison.CreatedAt
+	return dto.ComparisonResponse{
+		ID:        &id,
+		Products:  products,
+		Winners:   winners,
+		Verdict:   comparison.Verdict,
+		CreatedAt: &createdAt,
+	}
+}
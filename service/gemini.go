@@ -3,100 +3,400 @@ package service
 import (
 	"context"
 	"encoding/json"
-	"log"
-	"os"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
 
+	"review_product_tokopedia_be/config"
 	"review_product_tokopedia_be/constants"
 	"review_product_tokopedia_be/dto"
 
-	"github.com/google/generative-ai-go/genai"
-	"google.golang.org/api/option"
+	"github.com/sirupsen/logrus"
 )
 
 type (
 	GeminiService interface {
-		Analyze(ctx context.Context, analyzeReq string) (dto.AnalyzeResponse, error)
+		// Analyze labels every review with an aspect and sentiment and derives
+		// the aspect scores from those labels.
+		Analyze(ctx context.Context, reviews []dto.AnalyzeReview) (dto.AnalyzeResponse, error)
 		Summarize(ctx context.Context, summarizeReq string) (string, error)
+		SummarizeStream(ctx context.Context, summarizeReq string, onChunk func(chunk string)) (string, error)
+		// Compare writes a verdict on which of the products wins on which
+		// aspect.
+		Compare(ctx context.Context, products []dto.ComparisonProduct) (string, error)
 		CloseClient() error
 	}
 
+	// geminiService runs the review prompts on whichever LLMProvider it is
+	// given; the name predates support for other backends.
 	geminiService struct {
-		client *genai.Client
-		model  *genai.GenerativeModel
+		llm             LLMProvider
+		analyzeConfig   dto.LLMOperationConfig
+		summarizeConfig dto.LLMOperationConfig
+		compareConfig   dto.LLMOperationConfig
+		maxRetries      int
 	}
 )
 
-func NewGeminiService() GeminiService {
-	ctx := context.Background()
-	client, err := genai.NewClient(ctx, option.WithAPIKey(os.Getenv("GEMINI_API_KEY")))
-	if err != nil {
-		log.Fatal(err)
+const (
+	geminiAnalyzeBatchSize   = 40
+	geminiAnalyzeConcurrency = 4
+	maxSummarySentences      = 5
+	maxVerdictSentences      = 8
+)
+
+var (
+	aspectLabelSchema = &dto.LLMSchema{
+		Type: dto.LLM_SCHEMA_OBJECT,
+		Properties: map[string]*dto.LLMSchema{
+			"id":     {Type: dto.LLM_SCHEMA_STRING},
+			"aspect": {Type: dto.LLM_SCHEMA_STRING, Enum: []string{dto.ASPECT_PACKAGING, dto.ASPECT_DELIVERY, dto.ASPECT_ADMIN_RESPONSE, dto.ASPECT_PRODUCT_CONDITION, "none"}},
+			"sentiment": {Type: dto.LLM_SCHEMA_STRING, Enum: []string{
+				constants.ENUM_SENTIMENT_POSITIVE, constants.ENUM_SENTIMENT_NEGATIVE, constants.ENUM_SENTIMENT_NEUTRAL,
+			}},
+		},
+		Required: []string{"id", "aspect", "sentiment"},
+	}
+
+	analyzeResponseSchema = &dto.LLMSchema{
+		Type: dto.LLM_SCHEMA_OBJECT,
+		Properties: map[string]*dto.LLMSchema{
+			"labels": {Type: dto.LLM_SCHEMA_ARRAY, Items: aspectLabelSchema},
+		},
+		Required: []string{"labels"},
+	}
+
+	summaryResponseSchema = &dto.LLMSchema{
+		Type: dto.LLM_SCHEMA_OBJECT,
+		Properties: map[string]*dto.LLMSchema{
+			"summary": {Type: dto.LLM_SCHEMA_STRING},
+		},
+		Required: []string{"summary"},
+	}
+
+	verdictResponseSchema = &dto.LLMSchema{
+		Type: dto.LLM_SCHEMA_OBJECT,
+		Properties: map[string]*dto.LLMSchema{
+			"verdict": {Type: dto.LLM_SCHEMA_STRING},
+		},
+		Required: []string{"verdict"},
 	}
+)
 
-	model := client.GenerativeModel("gemini-1.5-pro-latest")
-	model.ResponseMIMEType = "application/json"
+func NewGeminiService(llm LLMProvider, cfg config.LLMConfig) GeminiService {
+	// Classification should give the same labels for the same reviews.
+	analyzeTemperature := float32(0)
 
 	return &geminiService{
-		client: client,
-		model:  model,
+		llm:             llm,
+		maxRetries:      cfg.MaxRetries,
+		analyzeConfig:   llmOperationConfig(cfg, dto.LLM_OPERATION_ANALYZE, dto.LLMOperationConfig{Temperature: &analyzeTemperature}),
+		summarizeConfig: llmOperationConfig(cfg, dto.LLM_OPERATION_SUMMARIZE, dto.LLMOperationConfig{}),
+		compareConfig:   llmOperationConfig(cfg, dto.LLM_OPERATION_COMPARE, dto.LLMOperationConfig{}),
 	}
 }
 
-func (s *geminiService) Analyze(ctx context.Context, analyzeReq string) (dto.AnalyzeResponse, error) {
-	prompt := constants.PROMPT_ANALYZE + "\n" + analyzeReq
+func (s *geminiService) Analyze(ctx context.Context, reviews []dto.AnalyzeReview) (dto.AnalyzeResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	batches := (len(reviews) + geminiAnalyzeBatchSize - 1) / geminiAnalyzeBatchSize
+	results := make([][]dto.ReviewAspectLabel, batches)
+	sem := make(chan struct{}, geminiAnalyzeConcurrency)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for i := 0; i < batches; i++ {
+		end := min((i+1)*geminiAnalyzeBatchSize, len(reviews))
+		batch := reviews[i*geminiAnalyzeBatchSize : end]
+
+		wg.Add(1)
+		go func(i int, batch []dto.AnalyzeReview) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			labels, err := s.classifyReviews(ctx, batch)
+			if err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			results[i] = labels
+		}(i, batch)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return dto.AnalyzeResponse{}, firstErr
+	}
+
+	labels := make([]dto.ReviewAspectLabel, 0, len(reviews))
+	for _, batch := range results {
+		labels = append(labels, batch...)
+	}
+
+	return scoreAspects(labels), nil
+}
 
-	resp, err := s.model.GenerateContent(ctx, genai.Text(prompt))
+func (s *geminiService) classifyReviews(ctx context.Context, reviews []dto.AnalyzeReview) ([]dto.ReviewAspectLabel, error) {
+	input, err := json.Marshal(reviews)
 	if err != nil {
-		return dto.AnalyzeResponse{}, err
+		return nil, dto.ErrMarshallJson
 	}
-	return parseAnalyzeResponse(resp)
+
+	var labels []dto.ReviewAspectLabel
+	err = s.generateValidated(ctx, dto.LLMRequest{
+		Operation:   dto.LLM_OPERATION_ANALYZE,
+		Instruction: constants.PROMPT_ANALYZE,
+		Input:       string(input),
+		JSON:        true,
+		Schema:      analyzeResponseSchema,
+		Config:      s.analyzeConfig,
+	}, func(resp string) error {
+		labels, err = parseAnalyzeResponse(resp, reviews)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return labels, nil
 }
 
 func (s *geminiService) Summarize(ctx context.Context, summarizeReq string) (string, error) {
-	prompt := constants.PROMPT_SUMMARIZE + "\n" + summarizeReq
+	var summary string
+	err := s.generateValidated(ctx, dto.LLMRequest{
+		Operation:   dto.LLM_OPERATION_SUMMARIZE,
+		Instruction: constants.PROMPT_SUMMARIZE,
+		Input:       summarizeReq,
+		JSON:        true,
+		Schema:      summaryResponseSchema,
+		Config:      s.summarizeConfig,
+	}, func(resp string) error {
+		var err error
+		summary, err = parseSummaryResponse(resp)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return summary, nil
+}
 
-	resp, err := s.model.GenerateContent(ctx, genai.Text(prompt))
+// SummarizeStream requests plain text because streamed output is shown to
+// users as it arrives.
+func (s *geminiService) SummarizeStream(ctx context.Context, summarizeReq string, onChunk func(chunk string)) (string, error) {
+	return s.llm.GenerateStream(ctx, dto.LLMRequest{
+		Operation:   dto.LLM_OPERATION_SUMMARIZE,
+		Instruction: constants.PROMPT_SUMMARIZE_STREAM,
+		Input:       summarizeReq,
+		Config:      s.summarizeConfig,
+	}, onChunk)
+}
+
+func (s *geminiService) Compare(ctx context.Context, products []dto.ComparisonProduct) (string, error) {
+	input, err := json.Marshal(products)
+	if err != nil {
+		return "", dto.ErrMarshallJson
+	}
+
+	var verdict string
+	err = s.generateValidated(ctx, dto.LLMRequest{
+		Operation:   dto.LLM_OPERATION_COMPARE,
+		Instruction: constants.PROMPT_COMPARE,
+		Input:       string(input),
+		JSON:        true,
+		Schema:      verdictResponseSchema,
+		Config:      s.compareConfig,
+	}, func(resp string) error {
+		var err error
+		verdict, err = parseVerdictResponse(resp)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
 
-	return parseSummaryResponse(resp)
+	return verdict, nil
 }
 
 func (s *geminiService) CloseClient() error {
-	return s.client.Close()
+	return s.llm.Close()
 }
 
-func parseAnalyzeResponse(resp *genai.GenerateContentResponse) (dto.AnalyzeResponse, error) {
-	var analyzeResp dto.AnalyzeResponse
-	for _, cand := range resp.Candidates {
-		if cand.Content != nil {
-			for _, part := range cand.Content.Parts {
-				if txt, ok := part.(genai.Text); ok {
-					if err := json.Unmarshal([]byte(txt), &analyzeResp); err != nil {
-						return dto.AnalyzeResponse{}, err
-					}
-				}
-			}
+// generateValidated sends req and hands the response to parse. When the
+// response does not parse, the prompt is sent again with the validation
+// error appended, up to maxRetries times. Errors from the provider itself
+// are returned straight away.
+func (s *geminiService) generateValidated(ctx context.Context, req dto.LLMRequest, parse func(resp string) error) error {
+	input := req.Input
+
+	var err error
+	for attempt := 0; attempt <= s.maxRetries; attempt++ {
+		resp, genErr := s.llm.Generate(ctx, req)
+		if genErr != nil {
+			return genErr
+		}
+
+		if err = parse(resp); err == nil {
+			return nil
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"operation": req.Operation,
+			"attempt":   attempt + 1,
+		}).Warn("llm response failed validation")
+
+		req.Input = input + "\n\n" + fmt.Sprintf(constants.PROMPT_RETRY_INVALID_OUTPUT, err.Error())
+	}
+
+	return err
+}
+
+// parseAnalyzeResponse validates the labels returned for a batch: every
+// review that was sent needs exactly one label with a known aspect and
+// sentiment. Labels for IDs that were not sent are ignored.
+func parseAnalyzeResponse(resp string, reviews []dto.AnalyzeReview) ([]dto.ReviewAspectLabel, error) {
+	var analyzeResp struct {
+		Labels []dto.ReviewAspectLabel `json:"labels"`
+	}
+	if err := decodeLLMObject(resp, &analyzeResp, "labels"); err != nil {
+		return nil, err
+	}
+
+	pending := make(map[string]bool, len(reviews))
+	for _, review := range reviews {
+		pending[review.ID] = true
+	}
+
+	labels := make([]dto.ReviewAspectLabel, 0, len(reviews))
+	for i, label := range analyzeResp.Labels {
+		if label.ID == "" {
+			return nil, invalidLLMResponse("label %d is missing \"id\"", i)
+		}
+		if !pending[label.ID] {
+			continue
+		}
+
+		normalized, ok := normalizeAspectLabel(label)
+		if !ok {
+			return nil, invalidLLMResponse("label %q has aspect %q and sentiment %q", label.ID, label.Aspect, label.Sentiment)
+		}
+		pending[label.ID] = false
+		labels = append(labels, normalized)
+	}
+
+	var missing []string
+	for _, review := range reviews {
+		if pending[review.ID] {
+			missing = append(missing, review.ID)
+			pending[review.ID] = false
 		}
 	}
-	return analyzeResp, nil
+	if len(missing) > 0 {
+		return nil, invalidLLMResponse("missing labels for ids %s", strings.Join(missing, ", "))
+	}
+
+	return labels, nil
+}
+
+func normalizeAspectLabel(label dto.ReviewAspectLabel) (dto.ReviewAspectLabel, bool) {
+	label.Aspect = strings.ToLower(strings.TrimSpace(label.Aspect))
+	label.Sentiment = strings.ToLower(strings.TrimSpace(label.Sentiment))
+
+	switch label.Sentiment {
+	case constants.ENUM_SENTIMENT_POSITIVE, constants.ENUM_SENTIMENT_NEGATIVE, constants.ENUM_SENTIMENT_NEUTRAL:
+	default:
+		return dto.ReviewAspectLabel{}, false
+	}
+
+	switch label.Aspect {
+	case dto.ASPECT_PACKAGING, dto.ASPECT_DELIVERY, dto.ASPECT_ADMIN_RESPONSE, dto.ASPECT_PRODUCT_CONDITION:
+	case "", "none":
+		label.Aspect = ""
+	default:
+		return dto.ReviewAspectLabel{}, false
+	}
+
+	return label, true
 }
 
-func parseSummaryResponse(resp *genai.GenerateContentResponse) (string, error) {
+// scoreAspects computes each aspect score as the share of positive reviews
+// among the positive and negative reviews of that aspect, in percent.
+func scoreAspects(labels []dto.ReviewAspectLabel) dto.AnalyzeResponse {
+	positive := make(map[string]int)
+	negative := make(map[string]int)
+	for _, label := range labels {
+		switch label.Sentiment {
+		case constants.ENUM_SENTIMENT_POSITIVE:
+			positive[label.Aspect]++
+		case constants.ENUM_SENTIMENT_NEGATIVE:
+			negative[label.Aspect]++
+		}
+	}
+
+	score := func(aspect string) float32 {
+		total := positive[aspect] + negative[aspect]
+		if total == 0 {
+			return 0
+		}
+		return float32(math.Round(float64(positive[aspect])/float64(total)*10000) / 100)
+	}
+
+	return dto.AnalyzeResponse{
+		Packaging:        score(dto.ASPECT_PACKAGING),
+		Delivery:         score(dto.ASPECT_DELIVERY),
+		AdminResponse:    score(dto.ASPECT_ADMIN_RESPONSE),
+		ProductCondition: score(dto.ASPECT_PRODUCT_CONDITION),
+		Labels:           labels,
+	}
+}
+
+func parseSummaryResponse(resp string) (string, error) {
 	var summaryResp struct {
 		Summary string `json:"summary"`
 	}
-	for _, cand := range resp.Candidates {
-		if cand.Content != nil {
-			for _, part := range cand.Content.Parts {
-				if txt, ok := part.(genai.Text); ok {
-					if err := json.Unmarshal([]byte(txt), &summaryResp); err != nil {
-						return "", err
-					}
-				}
-			}
-		}
+	if err := decodeLLMObject(resp, &summaryResp, "summary"); err != nil {
+		return "", err
+	}
+
+	summary := strings.TrimSpace(summaryResp.Summary)
+	if summary == "" {
+		return "", invalidLLMResponse("summary is empty")
 	}
-	return summaryResp.Summary, nil
+	if sentences := countSentences(summary); sentences > maxSummarySentences {
+		return "", invalidLLMResponse("summary has %d sentences, at most %d are allowed", sentences, maxSummarySentences)
+	}
+
+	return summary, nil
+}
+
+func parseVerdictResponse(resp string) (string, error) {
+	var verdictResp struct {
+		Verdict string `json:"verdict"`
+	}
+	if err := decodeLLMObject(resp, &verdictResp, "verdict"); err != nil {
+		return "", err
+	}
+
+	verdict := strings.TrimSpace(verdictResp.Verdict)
+	if verdict == "" {
+		return "", invalidLLMResponse("verdict is empty")
+	}
+	if sentences := countSentences(verdict); sentences > maxVerdictSentences {
+		return "", invalidLLMResponse("verdict has %d sentences, at most %d are allowed", sentences, maxVerdictSentences)
+	}
+
+	return verdict, nil
 }
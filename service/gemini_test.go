@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+// scriptedLLMProvider answers Generate with responses in order, repeating
+// the last one once the script runs out, and records every request.
+type scriptedLLMProvider struct {
+	responses []string
+	err       error
+	requests  []dto.LLMRequest
+}
+
+func (p *scriptedLLMProvider) Name() string { return "scripted" }
+
+func (p *scriptedLLMProvider) Generate(ctx context.Context, req dto.LLMRequest) (string, error) {
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return "", p.err
+	}
+	i := len(p.requests) - 1
+	if i >= len(p.responses) {
+		i = len(p.responses) - 1
+	}
+	return p.responses[i], nil
+}
+
+func (p *scriptedLLMProvider) GenerateStream(ctx context.Context, req dto.LLMRequest, onChunk func(chunk string)) (string, error) {
+	resp, err := p.Generate(ctx, req)
+	if err == nil {
+		onChunk(resp)
+	}
+	return resp, err
+}
+
+func (p *scriptedLLMProvider) Ping(ctx context.Context) error { return nil }
+
+func (p *scriptedLLMProvider) Close() error { return nil }
+
+func TestParseAnalyzeResponse(t *testing.T) {
+	reviews := []dto.AnalyzeReview{
+		{ID: "1", Message: "Packing rapi"},
+		{ID: "2", Message: "Kurir lama"},
+	}
+
+	tests := []struct {
+		name    string
+		resp    string
+		want    []dto.ReviewAspectLabel
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			resp: `{"labels":[{"id":"1","aspect":"packaging","sentiment":"positive"},{"id":"2","aspect":"delivery","sentiment":"negative"}]}`,
+			want: []dto.ReviewAspectLabel{
+				{ID: "1", Aspect: dto.ASPECT_PACKAGING, Sentiment: constants.ENUM_SENTIMENT_POSITIVE},
+				{ID: "2", Aspect: dto.ASPECT_DELIVERY, Sentiment: constants.ENUM_SENTIMENT_NEGATIVE},
+			},
+		},
+		{
+			name: "fenced with prose and normalized case",
+			resp: "Berikut hasilnya:\n```json\n{\"labels\":[{\"id\":\"1\",\"aspect\":\" Packaging \",\"sentiment\":\"POSITIVE\"},{\"id\":\"2\",\"aspect\":\"none\",\"sentiment\":\"neutral\"}]}\n```",
+			want: []dto.ReviewAspectLabel{
+				{ID: "1", Aspect: dto.ASPECT_PACKAGING, Sentiment: constants.ENUM_SENTIMENT_POSITIVE},
+				{ID: "2", Aspect: "", Sentiment: constants.ENUM_SENTIMENT_NEUTRAL},
+			},
+		},
+		{
+			name: "unknown id ignored",
+			resp: `{"labels":[{"id":"1","aspect":"packaging","sentiment":"positive"},{"id":"9","aspect":"bogus","sentiment":"bogus"},{"id":"2","aspect":"delivery","sentiment":"negative"}]}`,
+			want: []dto.ReviewAspectLabel{
+				{ID: "1", Aspect: dto.ASPECT_PACKAGING, Sentiment: constants.ENUM_SENTIMENT_POSITIVE},
+				{ID: "2", Aspect: dto.ASPECT_DELIVERY, Sentiment: constants.ENUM_SENTIMENT_NEGATIVE},
+			},
+		},
+		{
+			name: "duplicate id keeps the first label",
+			resp: `{"labels":[{"id":"1","aspect":"packaging","sentiment":"positive"},{"id":"1","aspect":"delivery","sentiment":"negative"},{"id":"2","aspect":"delivery","sentiment":"negative"}]}`,
+			want: []dto.ReviewAspectLabel{
+				{ID: "1", Aspect: dto.ASPECT_PACKAGING, Sentiment: constants.ENUM_SENTIMENT_POSITIVE},
+				{ID: "2", Aspect: dto.ASPECT_DELIVERY, Sentiment: constants.ENUM_SENTIMENT_NEGATIVE},
+			},
+		},
+		{
+			name:    "duplicate id does not cover a missing one",
+			resp:    `{"labels":[{"id":"1","aspect":"packaging","sentiment":"positive"},{"id":"1","aspect":"packaging","sentiment":"positive"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing id",
+			resp:    `{"labels":[{"id":"1","aspect":"packaging","sentiment":"positive"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "empty id",
+			resp:    `{"labels":[{"id":"","aspect":"packaging","sentiment":"positive"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown aspect",
+			resp:    `{"labels":[{"id":"1","aspect":"price","sentiment":"positive"},{"id":"2","aspect":"delivery","sentiment":"negative"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "unknown sentiment",
+			resp:    `{"labels":[{"id":"1","aspect":"packaging","sentiment":"mixed"},{"id":"2","aspect":"delivery","sentiment":"negative"}]}`,
+			wantErr: true,
+		},
+		{
+			name:    "missing labels key",
+			resp:    `{"results":[]}`,
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			resp:    `{"labels":[{"id":"1","aspect":"packaging","sentiment":"positive"},{"id":"2","asp`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			labels, err := parseAnalyzeResponse(tt.resp, reviews)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("labels = %v, want error", labels)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAnalyzeResponse: %v", err)
+			}
+			if !reflect.DeepEqual(labels, tt.want) {
+				t.Errorf("labels = %v, want %v", labels, tt.want)
+			}
+		})
+	}
+
+	t.Run("duplicate review id is labelled once", func(t *testing.T) {
+		duplicated := append(reviews, dto.AnalyzeReview{ID: "1", Message: "Packing rapi"})
+		resp := `{"labels":[{"id":"1","aspect":"packaging","sentiment":"positive"},{"id":"2","aspect":"delivery","sentiment":"negative"}]}`
+
+		labels, err := parseAnalyzeResponse(resp, duplicated)
+		if err != nil {
+			t.Fatalf("parseAnalyzeResponse: %v", err)
+		}
+		if len(labels) != 2 {
+			t.Errorf("labels = %v, want one per id", labels)
+		}
+	})
+
+	t.Run("missing ids are listed once", func(t *testing.T) {
+		duplicated := append(reviews, dto.AnalyzeReview{ID: "2", Message: "Kurir lama"})
+		resp := `{"labels":[{"id":"1","aspect":"packaging","sentiment":"positive"}]}`
+
+		_, err := parseAnalyzeResponse(resp, duplicated)
+		if err == nil || !strings.HasSuffix(err.Error(), "missing labels for ids 2") {
+			t.Errorf("err = %v", err)
+		}
+	})
+}
+
+func TestParseSummaryResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    string
+		want    string
+		wantErr bool
+	}{
+		{name: "valid", resp: `{"summary":" Barang bagus. Pengiriman cepat. "}`, want: "Barang bagus. Pengiriman cepat."},
+		{name: "at the limit", resp: `{"summary":"Satu. Dua. Tiga. Empat. Lima."}`, want: "Satu. Dua. Tiga. Empat. Lima."},
+		{name: "over the limit", resp: `{"summary":"Satu. Dua. Tiga. Empat. Lima. Enam."}`, wantErr: true},
+		{name: "empty", resp: `{"summary":"  "}`, wantErr: true},
+		{name: "no json", resp: "Barang bagus.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			summary, err := parseSummaryResponse(tt.resp)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("summary = %q, want error", summary)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseSummaryResponse: %v", err)
+			}
+			if summary != tt.want {
+				t.Errorf("summary = %q, want %q", summary, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseVerdictResponse(t *testing.T) {
+	if _, err := parseVerdictResponse(`{"verdict":"` + strings.Repeat("Kalimat. ", maxVerdictSentences) + `"}`); err != nil {
+		t.Errorf("verdict at the limit: %v", err)
+	}
+	if _, err := parseVerdictResponse(`{"verdict":"` + strings.Repeat("Kalimat. ", maxVerdictSentences+1) + `"}`); !errors.Is(err, dto.ErrLLMInvalidResponse) {
+		t.Errorf("verdict over the limit: err = %v", err)
+	}
+}
+
+func TestGenerateValidated(t *testing.T) {
+	const valid = `{"summary":"Bagus."}`
+	req := dto.LLMRequest{Operation: dto.LLM_OPERATION_SUMMARIZE, Input: "reviews"}
+	parse := func(resp string) error {
+		_, err := parseSummaryResponse(resp)
+		return err
+	}
+
+	t.Run("valid on the first attempt", func(t *testing.T) {
+		llm := &scriptedLLMProvider{responses: []string{valid}}
+		s := &geminiService{llm: llm, maxRetries: 2}
+
+		if err := s.generateValidated(context.Background(), req, parse); err != nil {
+			t.Fatalf("generateValidated: %v", err)
+		}
+		if len(llm.requests) != 1 {
+			t.Errorf("requests = %d, want 1", len(llm.requests))
+		}
+	})
+
+	t.Run("re-prompts with the validation error", func(t *testing.T) {
+		llm := &scriptedLLMProvider{responses: []string{"Bagus sekali", valid}}
+		s := &geminiService{llm: llm, maxRetries: 2}
+
+		if err := s.generateValidated(context.Background(), req, parse); err != nil {
+			t.Fatalf("generateValidated: %v", err)
+		}
+		if len(llm.requests) != 2 {
+			t.Fatalf("requests = %d, want 2", len(llm.requests))
+		}
+		retry := llm.requests[1].Input
+		if !strings.HasPrefix(retry, req.Input) || !strings.Contains(retry, dto.ErrLLMNoJSON.Error()) {
+			t.Errorf("retry input = %q", retry)
+		}
+	})
+
+	t.Run("retry budget exhausted", func(t *testing.T) {
+		llm := &scriptedLLMProvider{responses: []string{`{"summary":"Satu. Dua. Tiga. Empat. Lima. Enam."}`}}
+		s := &geminiService{llm: llm, maxRetries: 2}
+
+		err := s.generateValidated(context.Background(), req, parse)
+		if !errors.Is(err, dto.ErrLLMInvalidResponse) {
+			t.Fatalf("err = %v, want %v", err, dto.ErrLLMInvalidResponse)
+		}
+		if len(llm.requests) != 3 {
+			t.Errorf("requests = %d, want 3", len(llm.requests))
+		}
+		for _, sent := range llm.requests[1:] {
+			if strings.Count(sent.Input, "6 sentences") != 1 {
+				t.Errorf("retry input does not carry exactly the last error: %q", sent.Input)
+			}
+		}
+	})
+
+	t.Run("no retries configured", func(t *testing.T) {
+		llm := &scriptedLLMProvider{responses: []string{"no json"}}
+		s := &geminiService{llm: llm}
+
+		if err := s.generateValidated(context.Background(), req, parse); !errors.Is(err, dto.ErrLLMNoJSON) {
+			t.Fatalf("err = %v, want %v", err, dto.ErrLLMNoJSON)
+		}
+		if len(llm.requests) != 1 {
+			t.Errorf("requests = %d, want 1", len(llm.requests))
+		}
+	})
+
+	t.Run("provider error is not retried", func(t *testing.T) {
+		providerErr := errors.New("quota exceeded")
+		llm := &scriptedLLMProvider{err: providerErr}
+		s := &geminiService{llm: llm, maxRetries: 2}
+
+		if err := s.generateValidated(context.Background(), req, parse); !errors.Is(err, providerErr) {
+			t.Fatalf("err = %v, want %v", err, providerErr)
+		}
+		if len(llm.requests) != 1 {
+			t.Errorf("requests = %d, want 1", len(llm.requests))
+		}
+	})
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"review_product_tokopedia_be/dto"
+)
+
+const (
+	// readinessCacheTTL keeps frequent probes from pinging the database on
+	// every request.
+	readinessCacheTTL = time.Second
+	readinessTimeout  = 2 * time.Second
+	// statusCacheTTL keeps the status page from turning into load on the
+	// external services when it is refreshed or polled.
+	statusCacheTTL = 5 * time.Second
+	statusTimeout  = 5 * time.Second
+)
+
+type (
+	HealthService interface {
+		// Ready returns nil when the instance should receive traffic.
+		Ready(ctx context.Context) error
+		// SetReady is flipped on once the workers run and off again when
+		// shutdown starts, so load balancers stop routing before the
+		// listener closes.
+		SetReady(ready bool)
+		// Status probes every dependency, reusing the results of a probe
+		// made in the last few seconds.
+		Status(ctx context.Context) dto.StatusResponse
+	}
+
+	// HealthCheck is a named probe that returns nil when healthy.
+	HealthCheck struct {
+		Name  string
+		Check func(ctx context.Context) error
+	}
+
+	healthService struct {
+		ready           atomic.Bool
+		readinessChecks []HealthCheck
+		dependencies    []HealthCheck
+
+		readinessMu        sync.Mutex
+		readinessErr       error
+		readinessCheckedAt time.Time
+
+		statusMu        sync.Mutex
+		statuses        []dto.DependencyStatus
+		statusCheckedAt time.Time
+	}
+)
+
+// NewHealthService builds the health service. readinessChecks gate /readyz,
+// so they should only cover what every request needs; dependencies are
+// only probed for the status page.
+func NewHealthService(readinessChecks []HealthCheck, dependencies []HealthCheck) HealthService {
+	return &healthService{
+		readinessChecks: readinessChecks,
+		dependencies:    dependencies,
+	}
+}
+
+func (s *healthService) Ready(ctx context.Context) error {
+	if !s.ready.Load() {
+		return dto.ErrNotReady
+	}
+
+	s.readinessMu.Lock()
+	defer s.readinessMu.Unlock()
+
+	if time.Since(s.readinessCheckedAt) < readinessCacheTTL {
+		return s.readinessErr
+	}
+
+	// The result is shared with other callers, so a client hanging up must
+	// not make it fail.
+	checkCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), readinessTimeout)
+	defer cancel()
+
+	s.readinessErr = nil
+	for _, check := range s.readinessChecks {
+		if err := check.Check(checkCtx); err != nil {
+			s.readinessErr = fmt.Errorf("%s: %w", check.Name, err)
+			break
+		}
+	}
+	s.readinessCheckedAt = time.Now()
+
+	return s.readinessErr
+}
+
+func (s *healthService) SetReady(ready bool) {
+	s.ready.Store(ready)
+}
+
+func (s *healthService) Status(ctx context.Context) dto.StatusResponse {
+	res := dto.StatusResponse{
+		Ready:        true,
+		Dependencies: s.probeDependencies(ctx),
+	}
+	if err := s.Ready(ctx); err != nil {
+		res.Ready = false
+		res.ReadyError = err.Error()
+	}
+	return res
+}
+
+// probeDependencies runs every dependency check in parallel. Concurrent
+// callers wait for the probe in flight instead of starting their own.
+func (s *healthService) probeDependencies(ctx context.Context) []dto.DependencyStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	if s.statuses != nil && time.Since(s.statusCheckedAt) < statusCacheTTL {
+		return append([]dto.DependencyStatus(nil), s.statuses...)
+	}
+
+	probeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), statusTimeout)
+	defer cancel()
+
+	statuses := make([]dto.DependencyStatus, len(s.dependencies))
+	var wg sync.WaitGroup
+	for i, dependency := range s.dependencies {
+		wg.Add(1)
+		go func(i int, dependency HealthCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := dependency.Check(probeCtx)
+			checkedAt := time.Now()
+
+			status := dto.DependencyStatus{
+				Name:      dependency.Name,
+				Healthy:   err == nil,
+				LatencyMs: checkedAt.Sub(start).Milliseconds(),
+				CheckedAt: checkedAt,
+			}
+			if err != nil {
+				status.LastError = err.Error()
+				status.LastErrorAt = &checkedAt
+			} else if i < len(s.statuses) {
+				status.LastError = s.statuses[i].LastError
+				status.LastErrorAt = s.statuses[i].LastErrorAt
+			}
+			statuses[i] = status
+		}(i, dependency)
+	}
+	wg.Wait()
+
+	s.statuses = statuses
+	s.statusCheckedAt = time.Now()
+
+	return append([]dto.DependencyStatus(nil), statuses...)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHealthServiceReady(t *testing.T) {
+	errDown := errors.New("connection refused")
+	var calls atomic.Int32
+	s := NewHealthService([]HealthCheck{{Name: "database", Check: func(ctx context.Context) error {
+		calls.Add(1)
+		return errDown
+	}}}, nil)
+
+	if err := s.Ready(context.Background()); err == nil {
+		t.Fatal("ready before SetReady(true)")
+	}
+	if calls.Load() != 0 {
+		t.Errorf("checks ran while the flag was off")
+	}
+
+	s.SetReady(true)
+	err := s.Ready(context.Background())
+	if !errors.Is(err, errDown) || err.Error() != "database: connection refused" {
+		t.Fatalf("err = %v, want the failing check named", err)
+	}
+	s.Ready(context.Background())
+	if calls.Load() != 1 {
+		t.Errorf("checks ran %d times, want the result reused", calls.Load())
+	}
+}
+
+func TestHealthServiceStatus(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var calls atomic.Int32
+	s := NewHealthService(nil, []HealthCheck{
+		{Name: "model", Check: func(ctx context.Context) error {
+			calls.Add(1)
+			if failing.Load() {
+				return errors.New("503 Service Unavailable")
+			}
+			return nil
+		}},
+		{Name: "llm", Check: func(ctx context.Context) error { return nil }},
+	}).(*healthService)
+	s.SetReady(true)
+
+	status := s.Status(context.Background())
+	if !status.Ready || len(status.Dependencies) != 2 {
+		t.Fatalf("status = %+v", status)
+	}
+	model := status.Dependencies[0]
+	if model.Name != "model" || model.Healthy || model.LastError == "" || model.LastErrorAt == nil {
+		t.Errorf("model = %+v, want unhealthy with its error", model)
+	}
+	if !status.Dependencies[1].Healthy {
+		t.Errorf("llm = %+v, want healthy", status.Dependencies[1])
+	}
+
+	s.Status(context.Background())
+	if calls.Load() != 1 {
+		t.Errorf("model probed %d times, want the result reused", calls.Load())
+	}
+
+	// Once the cache expires the recovered dependency still shows its last error
+	failing.Store(false)
+	s.statusCheckedAt = s.statusCheckedAt.Add(-statusCacheTTL)
+	model = s.Status(context.Background()).Dependencies[0]
+	if !model.Healthy || model.LastError == "" {
+		t.Errorf("model = %+v, want healthy keeping the last error", model)
+	}
+}
@@ -2,11 +2,17 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"math"
+	"strings"
+	"time"
 
+	"review_product_tokopedia_be/constants"
 	"review_product_tokopedia_be/dto"
 	"review_product_tokopedia_be/entity"
 	"review_product_tokopedia_be/repository"
+
+	"gorm.io/gorm"
 )
 
 type (
@@ -14,52 +20,93 @@ type (
 		CreateHistory(ctx context.Context, req dto.HistoryCreateRequest) (dto.HistoryResponse, error)
 		GetHistories(ctx context.Context, req dto.HistoriesGetRequest, userId string) (dto.HistoriesResponse, error)
 		GetHistoryById(ctx context.Context, historyId string, userId string) (dto.HistoryResponse, error)
+		GetLatestHistory(ctx context.Context, provider string, productId string, userId string) (dto.HistoryResponse, error)
+		GetHistoryReviews(ctx context.Context, req dto.HistoryReviewsGetRequest, historyId string, userId string) (dto.HistoryReviewsResponse, error)
+		GetSnapshots(ctx context.Context, provider string, productId string, userId string) (dto.TrackedProductSnapshotsResponse, error)
+		GetSnapshotDiff(ctx context.Context, req dto.HistorySnapshotDiffRequest, productId string, userId string) (dto.HistorySnapshotDiffResponse, error)
 	}
 
 	historyService struct {
-		historyRepo repository.HistoryRepository
+		db                 *gorm.DB
+		historyRepo        repository.HistoryRepository
+		reviewRepo         repository.ReviewRepository
+		trackedProductRepo repository.TrackedProductRepository
 	}
 )
 
-func NewHistoryService(historyRepo repository.HistoryRepository) HistoryService {
+func NewHistoryService(db *gorm.DB, historyRepo repository.HistoryRepository, reviewRepo repository.ReviewRepository, trackedProductRepo repository.TrackedProductRepository) HistoryService {
 	return &historyService{
-		historyRepo: historyRepo,
+		db:                 db,
+		historyRepo:        historyRepo,
+		reviewRepo:         reviewRepo,
+		trackedProductRepo: trackedProductRepo,
 	}
 }
 
+// CreateHistory stores a new snapshot of the product together with its
+// analysed reviews. Earlier snapshots are kept so the product's timeline
+// can be compared. Either everything is stored or nothing is.
 func (s *historyService) CreateHistory(ctx context.Context, req dto.HistoryCreateRequest) (dto.HistoryResponse, error) {
-	isExist := s.historyRepo.CheckByProductId(ctx, nil, req.ProductID, req.UserID.String())
-	if isExist {
-		err := s.historyRepo.DeleteByProductId(ctx, nil, req.ProductID, req.UserID.String())
+	reviewOpts, err := json.Marshal(req.ReviewOptions)
+	if err != nil {
+		return dto.HistoryResponse{}, dto.ErrMarshallJson
+	}
+
+	var historyCreated entity.History
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		trackedProduct, err := s.trackedProductRepo.UpsertTrackedProduct(ctx, tx, entity.TrackedProduct{
+			Provider:       req.Provider,
+			ProductID:      req.ProductID,
+			URL:            req.URL,
+			ProductName:    req.ProductName,
+			LastAnalysedAt: &now,
+			UserID:         req.UserID,
+		})
 		if err != nil {
-			return dto.HistoryResponse{}, dto.ErrDeleteHistory
+			return dto.ErrTrackProduct
+		}
+
+		history := entity.History{
+			Provider:         req.Provider,
+			URL:              req.URL,
+			ProductID:        req.ProductID,
+			ProductName:      req.ProductName,
+			Rating:           req.Rating,
+			Ulasan:           req.Ulasan,
+			Bintang:          req.Bintang,
+			CountPositive:    req.CountPositive,
+			CountNegative:    req.CountNegative,
+			Packaging:        req.Packaging,
+			Delivery:         req.Delivery,
+			AdminResponse:    req.AdminResponse,
+			ProductCondition: req.ProductCondition,
+			Summary:          req.Summary,
+			UserID:           req.UserID,
+			TrackedProductID: trackedProduct.ID,
+			ReviewOptions:    string(reviewOpts),
 		}
-	}
 
-	history := entity.History{
-		URL:              req.URL,
-		ProductID:        req.ProductID,
-		ProductName:      req.ProductName,
-		Rating:           req.Rating,
-		Ulasan:           req.Ulasan,
-		Bintang:          req.Bintang,
-		CountPositive:    req.CountPositive,
-		CountNegative:    req.CountNegative,
-		Packaging:        req.Packaging,
-		Delivery:         req.Delivery,
-		AdminResponse:    req.AdminResponse,
-		ProductCondition: req.ProductCondition,
-		Summary:          req.Summary,
-		UserID:           req.UserID,
-	}
-
-	historyCreated, err := s.historyRepo.CreateHistory(ctx, nil, history)
+		historyCreated, err = s.historyRepo.CreateHistory(ctx, tx, history)
+		if err != nil {
+			return dto.ErrCreateHistory
+		}
+
+		if len(req.Reviews.Reviews) == 0 {
+			return nil
+		}
+		reviews := req.Reviews
+		reviews.HistoryID = historyCreated.ID
+		return s.saveReviews(ctx, tx, reviews)
+	})
 	if err != nil {
-		return dto.HistoryResponse{}, dto.ErrCreateHistory
+		return dto.HistoryResponse{}, err
 	}
 
 	return dto.HistoryResponse{
+		ID:               historyCreated.ID,
 		UserID:           historyCreated.UserID,
+		Provider:         historyCreated.Provider,
 		URL:              historyCreated.URL,
 		ProductID:        historyCreated.ProductID,
 		Rating:           historyCreated.Rating,
@@ -73,9 +120,74 @@ func (s *historyService) CreateHistory(ctx context.Context, req dto.HistoryCreat
 		AdminResponse:    historyCreated.AdminResponse,
 		ProductCondition: historyCreated.ProductCondition,
 		Summary:          historyCreated.Summary,
+		ReviewOptions:    decodeHistoryReviewOptions(historyCreated.ReviewOptions),
 	}, nil
 }
 
+// saveReviews upserts the analysed reviews and records the labels they got
+// in this history.
+func (s *historyService) saveReviews(ctx context.Context, tx *gorm.DB, req dto.ReviewsSaveRequest) error {
+	// The same review can show up twice when pages shift between requests,
+	// and a single upsert statement cannot touch the same row twice.
+	seen := make(map[string]bool, len(req.Reviews))
+	reviews := make([]entity.Review, 0, len(req.Reviews))
+	indexes := make([]int, 0, len(req.Reviews))
+	for i, review := range req.Reviews {
+		externalId := reviewExternalId(review)
+		if seen[externalId] {
+			continue
+		}
+		seen[externalId] = true
+
+		reviews = append(reviews, entity.Review{
+			Provider:   req.Provider,
+			ProductID:  req.ProductID,
+			ExternalID: externalId,
+			Message:    review.Message,
+			Rating:     review.Rating,
+			Variant:    review.Variant,
+			HasMedia:   review.HasMedia,
+			Reviewer:   review.Reviewer,
+			ReviewedAt: review.CreatedAt,
+		})
+		indexes = append(indexes, i)
+	}
+
+	stored, err := s.reviewRepo.UpsertReviews(ctx, tx, reviews)
+	if err != nil {
+		return dto.ErrSaveReviews
+	}
+
+	aspects := make(map[string]dto.ReviewAspectLabel, len(req.AspectLabels))
+	for _, label := range req.AspectLabels {
+		aspects[label.ID] = label
+	}
+
+	sentiments := make([]entity.ReviewSentiment, len(stored))
+	for i, review := range stored {
+		sentiment := entity.ReviewSentiment{
+			ReviewID:     review.ID,
+			HistoryID:    req.HistoryID,
+			ModelVersion: req.ModelVersion,
+		}
+		if idx := indexes[i]; idx < len(req.Predictions) {
+			sentiment.Label = strings.ToLower(req.Predictions[idx].Label)
+			sentiment.Confidence = req.Predictions[idx].Confidence
+		}
+		if label, ok := aspects[review.ExternalID]; ok {
+			sentiment.Aspect = label.Aspect
+			sentiment.AspectSentiment = label.Sentiment
+		}
+		sentiments[i] = sentiment
+	}
+
+	if err := s.reviewRepo.CreateReviewSentiments(ctx, tx, sentiments); err != nil {
+		return dto.ErrSaveReviews
+	}
+
+	return nil
+}
+
 func (s *historyService) GetHistories(ctx context.Context, req dto.HistoriesGetRequest, userId string) (dto.HistoriesResponse, error) {
 	histories, total, err := s.historyRepo.GetHistories(ctx, nil, req, userId)
 	if err != nil {
@@ -101,6 +213,7 @@ func (s *historyService) GetHistoryById(ctx context.Context, historyId string, u
 
 	return dto.HistoryResponse{
 		UserID:           history.UserID,
+		Provider:         history.Provider,
 		ProductID:        history.ProductID,
 		ID:               history.ID,
 		URL:              history.URL,
@@ -118,3 +231,187 @@ func (s *historyService) GetHistoryById(ctx context.Context, historyId string, u
 	}, nil
 }
 
+func (s *historyService) GetLatestHistory(ctx context.Context, provider string, productId string, userId string) (dto.HistoryResponse, error) {
+	history, err := s.historyRepo.GetLatestByProductId(ctx, nil, provider, productId, userId)
+	if err != nil {
+		return dto.HistoryResponse{}, dto.ErrGetHistory
+	}
+
+	return dto.HistoryResponse{
+		UserID:           history.UserID,
+		Provider:         history.Provider,
+		ProductID:        history.ProductID,
+		ID:               history.ID,
+		URL:              history.URL,
+		Rating:           history.Rating,
+		Ulasan:           history.Ulasan,
+		Bintang:          history.Bintang,
+		ProductName:      history.ProductName,
+		CountPositive:    history.CountPositive,
+		CountNegative:    history.CountNegative,
+		Packaging:        history.Packaging,
+		Delivery:         history.Delivery,
+		AdminResponse:    history.AdminResponse,
+		ProductCondition: history.ProductCondition,
+		Summary:          history.Summary,
+		ReviewOptions:    decodeHistoryReviewOptions(history.ReviewOptions),
+	}, nil
+}
+
+// decodeHistoryReviewOptions returns nil for snapshots stored without
+// their review options.
+func decodeHistoryReviewOptions(encoded string) *dto.ReviewOptions {
+	if encoded == "" {
+		return nil
+	}
+
+	var reviewOpts dto.ReviewOptions
+	if err := json.Unmarshal([]byte(encoded), &reviewOpts); err != nil {
+		return nil
+	}
+	return &reviewOpts
+}
+
+func (s *historyService) GetHistoryReviews(ctx context.Context, req dto.HistoryReviewsGetRequest, historyId string, userId string) (dto.HistoryReviewsResponse, error) {
+	switch req.Sentiment {
+	case "", constants.ENUM_SENTIMENT_POSITIVE, constants.ENUM_SENTIMENT_NEGATIVE, constants.ENUM_SENTIMENT_NEUTRAL:
+	default:
+		return dto.HistoryReviewsResponse{}, dto.ErrInvalidSentiment
+	}
+
+	switch req.Aspect {
+	case "", dto.ASPECT_PACKAGING, dto.ASPECT_DELIVERY, dto.ASPECT_ADMIN_RESPONSE, dto.ASPECT_PRODUCT_CONDITION:
+	default:
+		return dto.HistoryReviewsResponse{}, dto.ErrInvalidAspect
+	}
+
+	// Make sure the history belongs to the user before exposing its reviews
+	if _, err := s.historyRepo.GetHistoryById(ctx, nil, historyId, userId); err != nil {
+		return dto.HistoryReviewsResponse{}, dto.ErrGetHistory
+	}
+
+	reviews, total, err := s.reviewRepo.GetHistoryReviews(ctx, nil, req, historyId)
+	if err != nil {
+		return dto.HistoryReviewsResponse{}, dto.ErrGetHistoryReviews
+	}
+
+	pages := int(math.Ceil(float64(total) / float64(req.Limit)))
+
+	return dto.HistoryReviewsResponse{
+		Reviews: reviews,
+		Meta: dto.PaginationMeta{
+			Page:  req.Page,
+			Limit: req.Limit,
+			Total: total,
+			Pages: pages,
+		},
+	}, nil
+}
+
+func (s *historyService) GetSnapshots(ctx context.Context, provider string, productId string, userId string) (dto.TrackedProductSnapshotsResponse, error) {
+	trackedProduct, err := s.trackedProductRepo.GetTrackedProduct(ctx, nil, provider, productId, userId)
+	if err != nil {
+		return dto.TrackedProductSnapshotsResponse{}, dto.ErrProductNotTracked
+	}
+
+	histories, err := s.historyRepo.GetSnapshots(ctx, nil, trackedProduct.ID.String())
+	if err != nil {
+		return dto.TrackedProductSnapshotsResponse{}, dto.ErrGetSnapshots
+	}
+
+	snapshots := make([]dto.HistorySnapshotResponse, len(histories))
+	for i, history := range histories {
+		snapshots[i] = toSnapshotResponse(history)
+	}
+
+	return dto.TrackedProductSnapshotsResponse{
+		ID:             trackedProduct.ID,
+		Provider:       trackedProduct.Provider,
+		ProductID:      trackedProduct.ProductID,
+		URL:            trackedProduct.URL,
+		ProductName:    trackedProduct.ProductName,
+		LastAnalysedAt: trackedProduct.LastAnalysedAt,
+		Snapshots:      snapshots,
+	}, nil
+}
+
+// GetSnapshotDiff compares two snapshots of a product. Without from and to
+// it compares the latest snapshot with the one before it.
+func (s *historyService) GetSnapshotDiff(ctx context.Context, req dto.HistorySnapshotDiffRequest, productId string, userId string) (dto.HistorySnapshotDiffResponse, error) {
+	timeline, err := s.GetSnapshots(ctx, req.Provider, productId, userId)
+	if err != nil {
+		return dto.HistorySnapshotDiffResponse{}, err
+	}
+
+	snapshots := timeline.Snapshots
+	if len(snapshots) < 2 && (req.From == "" || req.To == "") {
+		return dto.HistorySnapshotDiffResponse{}, dto.ErrNotEnoughSnapshot
+	}
+
+	toIdx := len(snapshots) - 1
+	if req.To != "" {
+		if toIdx = findSnapshot(snapshots, req.To); toIdx < 0 {
+			return dto.HistorySnapshotDiffResponse{}, dto.ErrSnapshotNotFound
+		}
+	}
+
+	fromIdx := toIdx - 1
+	if req.From != "" {
+		if fromIdx = findSnapshot(snapshots, req.From); fromIdx < 0 {
+			return dto.HistorySnapshotDiffResponse{}, dto.ErrSnapshotNotFound
+		}
+	}
+	if fromIdx < 0 {
+		return dto.HistorySnapshotDiffResponse{}, dto.ErrNotEnoughSnapshot
+	}
+
+	from, to := snapshots[fromIdx], snapshots[toIdx]
+
+	return dto.HistorySnapshotDiffResponse{
+		Provider:  timeline.Provider,
+		ProductID: timeline.ProductID,
+		From:      from,
+		To:        to,
+		Delta: dto.HistorySnapshotDelta{
+			Rating:           to.Rating - from.Rating,
+			Ulasan:           to.Ulasan - from.Ulasan,
+			Bintang:          to.Bintang - from.Bintang,
+			CountPositive:    to.CountPositive - from.CountPositive,
+			CountNegative:    to.CountNegative - from.CountNegative,
+			Packaging:        to.Packaging - from.Packaging,
+			Delivery:         to.Delivery - from.Delivery,
+			AdminResponse:    to.AdminResponse - from.AdminResponse,
+			ProductCondition: to.ProductCondition - from.ProductCondition,
+		},
+		Summaries: dto.HistorySnapshotSummaries{
+			From: from.Summary,
+			To:   to.Summary,
+		},
+	}, nil
+}
+
+func findSnapshot(snapshots []dto.HistorySnapshotResponse, id string) int {
+	for i, snapshot := range snapshots {
+		if snapshot.ID.String() == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func toSnapshotResponse(history entity.History) dto.HistorySnapshotResponse {
+	return dto.HistorySnapshotResponse{
+		ID:               history.ID,
+		CreatedAt:        history.CreatedAt,
+		Rating:           history.Rating,
+		Ulasan:           history.Ulasan,
+		Bintang:          history.Bintang,
+		CountPositive:    history.CountPositive,
+		CountNegative:    history.CountNegative,
+		Packaging:        history.Packaging,
+		Delivery:         history.Delivery,
+		AdminResponse:    history.AdminResponse,
+		ProductCondition: history.ProductCondition,
+		Summary:          history.Summary,
+	}
+}
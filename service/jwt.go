@@ -3,9 +3,10 @@ package service
 import (
 	"fmt"
 	"log"
-	"os"
 	"time"
 
+	"review_product_tokopedia_be/config"
+
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -26,19 +27,11 @@ type jwtService struct {
 	issuer    string
 }
 
-func NewJWTService() JWTService {
+func NewJWTService(cfg config.JWTConfig) JWTService {
 	return &jwtService{
-		secretKey: getSecretKey(),
-		issuer:    "Template",
-	}
-}
-
-func getSecretKey() string {
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		secretKey = "Template"
+		secretKey: cfg.Secret.Value(),
+		issuer:    cfg.Issuer,
 	}
-	return secretKey
 }
 
 func (j *jwtService) GenerateToken(userId string, role string) string {
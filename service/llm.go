@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+type LLMProvider interface {
+	Name() string
+	Generate(ctx context.Context, req dto.LLMRequest) (string, error)
+	GenerateStream(ctx context.Context, req dto.LLMRequest, onChunk func(chunk string)) (string, error)
+	// Ping checks the provider is reachable and accepts the credentials
+	// without generating anything.
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// NewLLMProvider builds the provider selected by cfg.Provider.
+func NewLLMProvider(cfg config.LLMConfig) (LLMProvider, error) {
+	switch cfg.Provider {
+	case constants.ENUM_LLM_PROVIDER_GEMINI:
+		return NewGeminiLLMProvider(cfg.GeminiAPIKey.Value())
+	case constants.ENUM_LLM_PROVIDER_OPENAI:
+		return NewOpenAILLMProvider(cfg.OpenAIBaseURL, cfg.OpenAIAPIKey.Value())
+	case constants.ENUM_LLM_PROVIDER_FAKE:
+		return NewFakeLLMProvider(), nil
+	default:
+		return nil, dto.ErrUnsupportedLLMProvider
+	}
+}
+
+// llmOperationConfig applies the configured overrides of an operation to
+// its defaults, falling back to the shared model when the operation does not
+// name one.
+func llmOperationConfig(cfg config.LLMConfig, operation string, defaults dto.LLMOperationConfig) dto.LLMOperationConfig {
+	opConfig := defaults
+	override := cfg.Operations[operation]
+
+	if override.Model != "" {
+		opConfig.Model = override.Model
+	} else if cfg.Model != "" {
+		opConfig.Model = cfg.Model
+	}
+
+	if override.Temperature != nil {
+		temperature := *override.Temperature
+		opConfig.Temperature = &temperature
+	}
+
+	if override.MaxTokens > 0 {
+		opConfig.MaxTokens = override.MaxTokens
+	}
+
+	return opConfig
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+// fakeLLMProvider answers prompts with simple keyword rules instead of a
+// model, so the same input always gives the same output. It is meant for
+// tests and local development without an API key.
+type fakeLLMProvider struct{}
+
+var (
+	fakeAspectKeywords = []struct {
+		aspect   string
+		keywords []string
+	}{
+		{dto.ASPECT_PACKAGING, []string{"kemas", "packing", "bubble", "kardus", "bungkus"}},
+		{dto.ASPECT_DELIVERY, []string{"kirim", "kurir", "sampai", "ekspedisi", "ongkir"}},
+		{dto.ASPECT_ADMIN_RESPONSE, []string{"admin", "penjual", "seller", "respon", "balas"}},
+		{dto.ASPECT_PRODUCT_CONDITION, []string{"barang", "produk", "kualitas", "original", "ori"}},
+	}
+	fakeNegativeKeywords = []string{"rusak", "jelek", "kecewa", "buruk", "lambat", "lama", "cacat", "tidak sesuai", "pecah"}
+	fakePositiveKeywords = []string{"bagus", "mantap", "puas", "baik", "cepat", "sesuai", "rapi", "aman", "recommended"}
+)
+
+func NewFakeLLMProvider() LLMProvider {
+	return &fakeLLMProvider{}
+}
+
+func (p *fakeLLMProvider) Name() string {
+	return constants.ENUM_LLM_PROVIDER_FAKE
+}
+
+func (p *fakeLLMProvider) Generate(ctx context.Context, req dto.LLMRequest) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	switch req.Operation {
+	case dto.LLM_OPERATION_ANALYZE:
+		return fakeAnalyze(req.Input)
+	case dto.LLM_OPERATION_SUMMARIZE:
+		summary := fakeSummary(req.Input)
+		if !req.JSON {
+			return summary, nil
+		}
+		encoded, err := json.Marshal(map[string]string{"summary": summary})
+		if err != nil {
+			return "", dto.ErrMarshallJson
+		}
+		return string(encoded), nil
+	case dto.LLM_OPERATION_COMPARE:
+		return fakeCompare(req.Input)
+	default:
+		return "", dto.ErrLLMEmptyResponse
+	}
+}
+
+func (p *fakeLLMProvider) GenerateStream(ctx context.Context, req dto.LLMRequest, onChunk func(chunk string)) (string, error) {
+	text, err := p.Generate(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	for _, word := range strings.SplitAfter(text, " ") {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		onChunk(word)
+	}
+
+	return text, nil
+}
+
+func (p *fakeLLMProvider) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (p *fakeLLMProvider) Close() error {
+	return nil
+}
+
+func fakeAnalyze(input string) (string, error) {
+	var reviews []dto.AnalyzeReview
+	if err := json.Unmarshal([]byte(input), &reviews); err != nil {
+		return "", dto.ErrParseJson
+	}
+
+	labels := make([]dto.ReviewAspectLabel, len(reviews))
+	for i, review := range reviews {
+		message := strings.ToLower(review.Message)
+
+		label := dto.ReviewAspectLabel{
+			ID:        review.ID,
+			Aspect:    "none",
+			Sentiment: constants.ENUM_SENTIMENT_NEUTRAL,
+		}
+		for _, candidate := range fakeAspectKeywords {
+			if containsAny(message, candidate.keywords) {
+				label.Aspect = candidate.aspect
+				break
+			}
+		}
+		if containsAny(message, fakeNegativeKeywords) {
+			label.Sentiment = constants.ENUM_SENTIMENT_NEGATIVE
+		} else if containsAny(message, fakePositiveKeywords) {
+			label.Sentiment = constants.ENUM_SENTIMENT_POSITIVE
+		}
+		labels[i] = label
+	}
+
+	encoded, err := json.Marshal(map[string]any{"labels": labels})
+	if err != nil {
+		return "", dto.ErrMarshallJson
+	}
+	return string(encoded), nil
+}
+
+func fakeSummary(input string) string {
+	var lines []string
+	for _, line := range strings.Split(input, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) == 0 {
+		return "Belum ada ulasan untuk diringkas."
+	}
+	summary := fmt.Sprintf("Ringkasan dari %d ulasan. Ulasan pertama: %s", len(lines), lines[0])
+	return truncateSentences(summary, maxSummarySentences)
+}
+
+// truncateSentences cuts text after its max-th sentence, splitting
+// sentences the same way countSentences does.
+func truncateSentences(text string, max int) string {
+	runes := []rune(strings.TrimSpace(text))
+	count := 0
+	inSentence := false
+	for i, r := range runes {
+		switch {
+		case r == '.' || r == '!' || r == '?':
+			if inSentence && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+				count++
+				inSentence = false
+				if count == max {
+					return string(runes[:i+1])
+				}
+			}
+		case !unicode.IsSpace(r):
+			inSentence = true
+		}
+	}
+	return string(runes)
+}
+
+// fakeCompare names the product with the best star average, which is all
+// a verdict needs to be checked against in tests.
+func fakeCompare(input string) (string, error) {
+	var products []dto.ComparisonProduct
+	if err := json.Unmarshal([]byte(input), &products); err != nil || len(products) == 0 {
+		return "", dto.ErrParseJson
+	}
+
+	best := products[0]
+	for _, product := range products[1:] {
+		if product.Bintang > best.Bintang {
+			best = product
+		}
+	}
+
+	encoded, err := json.Marshal(map[string]string{
+		"verdict": fmt.Sprintf("Dari %d produk, %s memiliki rata-rata bintang tertinggi.", len(products), best.ProductName),
+	})
+	if err != nil {
+		return "", dto.ErrMarshallJson
+	}
+	return string(encoded), nil
+}
+
+func containsAny(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
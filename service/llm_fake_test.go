@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"review_product_tokopedia_be/config"
+)
+
+func TestFakeLLMProviderSummaryFitsSentenceLimit(t *testing.T) {
+	gs := NewGeminiService(NewFakeLLMProvider(), config.LLMConfig{})
+
+	reviews := strings.Repeat("Barang bagus. ", 10) + "\nPengiriman cepat."
+	summary, err := gs.Summarize(context.Background(), reviews)
+	if err != nil {
+		t.Fatalf("Summarize: %v", err)
+	}
+	if sentences := countSentences(summary); sentences != maxSummarySentences {
+		t.Errorf("summary has %d sentences, want %d: %q", sentences, maxSummarySentences, summary)
+	}
+}
+
+func TestTruncateSentences(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want string
+	}{
+		{text: "Satu. Dua. Tiga.", max: 2, want: "Satu. Dua."},
+		{text: "Satu. Dua", max: 2, want: "Satu. Dua"},
+		{text: "Harga 2.5 juta. Murah! Mantap?", max: 2, want: "Harga 2.5 juta. Murah!"},
+		{text: "Satu.", max: 5, want: "Satu."},
+	}
+
+	for _, tt := range tests {
+		if got := truncateSentences(tt.text, tt.max); got != tt.want {
+			t.Errorf("truncateSentences(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+		}
+	}
+}
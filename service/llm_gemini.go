@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+
+	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+const defaultGeminiModel = "gemini-1.5-pro-latest"
+
+type geminiLLMProvider struct {
+	client *genai.Client
+}
+
+func NewGeminiLLMProvider(apiKey string) (LLMProvider, error) {
+	if apiKey == "" {
+		return nil, dto.ErrLLMProviderConfig
+	}
+
+	client, err := genai.NewClient(context.Background(), option.WithAPIKey(apiKey))
+	if err != nil {
+		return nil, err
+	}
+
+	return &geminiLLMProvider{
+		client: client,
+	}, nil
+}
+
+func (p *geminiLLMProvider) Name() string {
+	return constants.ENUM_LLM_PROVIDER_GEMINI
+}
+
+func (p *geminiLLMProvider) Generate(ctx context.Context, req dto.LLMRequest) (string, error) {
+	resp, err := p.model(req).GenerateContent(ctx, genai.Text(geminiPrompt(req)))
+	if err != nil {
+		return "", err
+	}
+
+	text := geminiResponseText(resp)
+	if text == "" {
+		return "", dto.ErrLLMEmptyResponse
+	}
+	return text, nil
+}
+
+func (p *geminiLLMProvider) GenerateStream(ctx context.Context, req dto.LLMRequest, onChunk func(chunk string)) (string, error) {
+	var builder strings.Builder
+	iter := p.model(req).GenerateContentStream(ctx, genai.Text(geminiPrompt(req)))
+	for {
+		resp, err := iter.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if chunk := geminiResponseText(resp); chunk != "" {
+			builder.WriteString(chunk)
+			onChunk(chunk)
+		}
+	}
+
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// Ping fetches the first entry of the model list, which costs no tokens.
+func (p *geminiLLMProvider) Ping(ctx context.Context) error {
+	_, err := p.client.ListModels(ctx).Next()
+	if err == iterator.Done {
+		return nil
+	}
+	return err
+}
+
+func (p *geminiLLMProvider) Close() error {
+	return p.client.Close()
+}
+
+// model is cheap to build, so one is configured per request rather than
+// sharing mutable models between operations.
+func (p *geminiLLMProvider) model(req dto.LLMRequest) *genai.GenerativeModel {
+	name := req.Config.Model
+	if name == "" {
+		name = defaultGeminiModel
+	}
+
+	model := p.client.GenerativeModel(name)
+	if req.JSON {
+		model.ResponseMIMEType = "application/json"
+	} else {
+		model.ResponseMIMEType = "text/plain"
+	}
+	if req.Config.Temperature != nil {
+		model.SetTemperature(*req.Config.Temperature)
+	}
+	if req.Config.MaxTokens > 0 {
+		model.SetMaxOutputTokens(int32(req.Config.MaxTokens))
+	}
+	if req.JSON && req.Schema != nil {
+		model.ResponseSchema = geminiSchema(req.Schema)
+	}
+
+	return model
+}
+
+func geminiSchema(schema *dto.LLMSchema) *genai.Schema {
+	if schema == nil {
+		return nil
+	}
+
+	converted := &genai.Schema{
+		Items:    geminiSchema(schema.Items),
+		Required: schema.Required,
+		Enum:     schema.Enum,
+	}
+
+	switch schema.Type {
+	case dto.LLM_SCHEMA_OBJECT:
+		converted.Type = genai.TypeObject
+	case dto.LLM_SCHEMA_ARRAY:
+		converted.Type = genai.TypeArray
+	case dto.LLM_SCHEMA_NUMBER:
+		converted.Type = genai.TypeNumber
+	default:
+		converted.Type = genai.TypeString
+	}
+	if len(schema.Enum) > 0 {
+		converted.Format = "enum"
+	}
+
+	if len(schema.Properties) > 0 {
+		converted.Properties = make(map[string]*genai.Schema, len(schema.Properties))
+		for name, property := range schema.Properties {
+			converted.Properties[name] = geminiSchema(property)
+		}
+	}
+
+	return converted
+}
+
+func geminiPrompt(req dto.LLMRequest) string {
+	return req.Instruction + "\n" + req.Input
+}
+
+func geminiResponseText(resp *genai.GenerateContentResponse) string {
+	var builder strings.Builder
+	for _, cand := range resp.Candidates {
+		if cand.Content == nil {
+			continue
+		}
+		for _, part := range cand.Content.Parts {
+			if txt, ok := part.(genai.Text); ok {
+				builder.WriteString(string(txt))
+			}
+		}
+	}
+	return builder.String()
+}
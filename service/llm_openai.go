@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+// openAILLMProvider talks to any server implementing the OpenAI chat
+// completions API, which includes local Ollama and llama.cpp servers.
+type openAILLMProvider struct {
+	baseUrl string
+	apiKey  string
+	client  *http.Client
+}
+
+func NewOpenAILLMProvider(baseUrl string, apiKey string) (LLMProvider, error) {
+	if baseUrl == "" {
+		return nil, dto.ErrLLMProviderConfig
+	}
+
+	return &openAILLMProvider{
+		baseUrl: strings.TrimRight(baseUrl, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{},
+	}, nil
+}
+
+func (p *openAILLMProvider) Name() string {
+	return constants.ENUM_LLM_PROVIDER_OPENAI
+}
+
+func (p *openAILLMProvider) Generate(ctx context.Context, req dto.LLMRequest) (string, error) {
+	res, err := p.send(ctx, req, false)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", dto.ErrReadHttpResponseBody
+	}
+
+	var completion dto.OpenAIChatResponse
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return "", dto.ErrParseJson
+	}
+
+	if len(completion.Choices) == 0 || completion.Choices[0].Message.Content == "" {
+		return "", dto.ErrLLMEmptyResponse
+	}
+	return completion.Choices[0].Message.Content, nil
+}
+
+func (p *openAILLMProvider) GenerateStream(ctx context.Context, req dto.LLMRequest, onChunk func(chunk string)) (string, error) {
+	res, err := p.send(ctx, req, true)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var builder strings.Builder
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk dto.OpenAIChatResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", dto.ErrParseJson
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				builder.WriteString(choice.Delta.Content)
+				onChunk(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", dto.ErrReadHttpResponseBody
+	}
+
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// Ping lists the models, which every OpenAI-compatible server supports and
+// which costs no tokens.
+func (p *openAILLMProvider) Ping(ctx context.Context) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", p.baseUrl+"/models", nil)
+	if err != nil {
+		return dto.ErrCreateHttpRequest
+	}
+	if p.apiKey != "" {
+		httpReq.Header.Add("Authorization", "Bearer "+p.apiKey)
+	}
+
+	res, err := p.client.Do(httpReq)
+	if err != nil {
+		return dto.ErrSendsHttpRequest
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s", dto.ErrNotOk, res.Status)
+	}
+	return nil
+}
+
+func (p *openAILLMProvider) Close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
+
+func (p *openAILLMProvider) send(ctx context.Context, req dto.LLMRequest, stream bool) (*http.Response, error) {
+	chatReq := dto.OpenAIChatRequest{
+		Model: req.Config.Model,
+		Messages: []dto.OpenAIChatMessage{
+			{Role: "system", Content: req.Instruction},
+			{Role: "user", Content: req.Input},
+		},
+		Temperature: req.Config.Temperature,
+		MaxTokens:   req.Config.MaxTokens,
+		Stream:      stream,
+	}
+	if req.JSON {
+		chatReq.ResponseFormat = &dto.OpenAIResponseFormat{Type: "json_object"}
+	}
+
+	jsonData, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, dto.ErrMarshallJson
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseUrl+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, dto.ErrCreateHttpRequest
+	}
+	httpReq.Header.Add("Content-Type", "application/json")
+	if p.apiKey != "" {
+		httpReq.Header.Add("Authorization", "Bearer "+p.apiKey)
+	}
+
+	res, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, dto.ErrSendsHttpRequest
+	}
+
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("%w: %s", dto.ErrNotOk, res.Status)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"review_product_tokopedia_be/dto"
+)
+
+// extractJSONObject returns the first complete JSON object in text. Models
+// often wrap their answer in ```json fences or add a sentence before it,
+// so anything around the object is ignored.
+func extractJSONObject(text string) (json.RawMessage, error) {
+	for i := strings.IndexByte(text, '{'); i >= 0; {
+		var raw json.RawMessage
+		if err := json.NewDecoder(strings.NewReader(text[i:])).Decode(&raw); err == nil {
+			return raw, nil
+		}
+
+		next := strings.IndexByte(text[i+1:], '{')
+		if next < 0 {
+			break
+		}
+		i += next + 1
+	}
+
+	return nil, dto.ErrLLMNoJSON
+}
+
+// decodeLLMObject extracts the JSON object from an LLM response, checks
+// that the required keys are present and decodes it into out.
+func decodeLLMObject(text string, out any, required ...string) error {
+	raw, err := extractJSONObject(text)
+	if err != nil {
+		return err
+	}
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return invalidLLMResponse("response is not a json object")
+	}
+	for _, key := range required {
+		value, ok := keys[key]
+		if !ok || string(value) == "null" {
+			return invalidLLMResponse("missing required key %q", key)
+		}
+	}
+
+	if err := json.Unmarshal(raw, out); err != nil {
+		return invalidLLMResponse("%s", err.Error())
+	}
+
+	return nil
+}
+
+// countSentences counts runs of text ending in '.', '!' or '?', plus any
+// trailing text without a terminator. Decimal points do not end a sentence.
+func countSentences(text string) int {
+	runes := []rune(strings.TrimSpace(text))
+	count := 0
+	inSentence := false
+	for i, r := range runes {
+		switch {
+		case r == '.' || r == '!' || r == '?':
+			if inSentence && (i+1 == len(runes) || unicode.IsSpace(runes[i+1])) {
+				count++
+				inSentence = false
+			}
+		case !unicode.IsSpace(r):
+			inSentence = true
+		}
+	}
+	if inSentence {
+		count++
+	}
+	return count
+}
+
+func invalidLLMResponse(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", dto.ErrLLMInvalidResponse, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"review_product_tokopedia_be/dto"
+)
+
+func TestExtractJSONObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr error
+	}{
+		{name: "plain", text: `{"summary":"Bagus."}`, want: `{"summary":"Bagus."}`},
+		{name: "fenced", text: "```json\n{\"summary\":\"Bagus.\"}\n```", want: `{"summary":"Bagus."}`},
+		{name: "leading prose", text: "Here is the result:\n{\"summary\":\"Bagus.\"}", want: `{"summary":"Bagus."}`},
+		{name: "trailing prose", text: "{\"summary\":\"Bagus.\"}\nLet me know if you need more.", want: `{"summary":"Bagus."}`},
+		{name: "brace in leading prose", text: "Use the {summary} key: {\"summary\":\"Bagus.\"}", want: `{"summary":"Bagus."}`},
+		{name: "nested", text: `{"labels":[{"id":"1"}]} trailing`, want: `{"labels":[{"id":"1"}]}`},
+		{name: "truncated", text: `{"summary":"Bagus, pengiriman`, wantErr: dto.ErrLLMNoJSON},
+		{name: "no json", text: "I cannot help with that.", wantErr: dto.ErrLLMNoJSON},
+		{name: "empty", text: "", wantErr: dto.ErrLLMNoJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := extractJSONObject(tt.text)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractJSONObject: %v", err)
+			}
+			if string(raw) != tt.want {
+				t.Errorf("raw = %s, want %s", raw, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeLLMObject(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr error
+	}{
+		{name: "present", text: `{"summary":"Bagus."}`},
+		{name: "missing key", text: `{"verdict":"Bagus."}`, wantErr: dto.ErrLLMInvalidResponse},
+		{name: "null key", text: `{"summary":null}`, wantErr: dto.ErrLLMInvalidResponse},
+		{name: "wrong type", text: `{"summary":42}`, wantErr: dto.ErrLLMInvalidResponse},
+		{name: "truncated", text: `{"summary":"Bag`, wantErr: dto.ErrLLMNoJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out struct {
+				Summary string `json:"summary"`
+			}
+			err := decodeLLMObject(tt.text, &out, "summary")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && out.Summary != "Bagus." {
+				t.Errorf("Summary = %q", out.Summary)
+			}
+		})
+	}
+}
+
+func TestCountSentences(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{text: "", want: 0},
+		{text: "Bagus.", want: 1},
+		{text: "Bagus. Cepat sampai!", want: 2},
+		{text: "Bagus! Murah? Ya.", want: 3},
+		{text: "Harga turun 2.5 persen. Mantap", want: 2},
+		{text: "Tanpa titik", want: 1},
+		{text: "Wah!!! Keren...", want: 2},
+		{text: "  Spasi di awal.  ", want: 1},
+	}
+
+	for _, tt := range tests {
+		if got := countSentences(tt.text); got != tt.want {
+			t.Errorf("countSentences(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
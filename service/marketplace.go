@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"review_product_tokopedia_be/dto"
+)
+
+type (
+	// MarketplaceProvider is the contract every supported marketplace
+	// implements so the analysis pipeline does not depend on a single one.
+	MarketplaceProvider interface {
+		// Name is the identifier stored alongside results, e.g. "tokopedia".
+		Name() string
+		// Hosts lists the URL hosts handled by this provider.
+		Hosts() []string
+		ResolveProduct(ctx context.Context, productUrl *url.URL) (dto.GetProductResponse, error)
+		GetReviews(ctx context.Context, req dto.GetReviewsRequest) ([]dto.ReviewResponse, error)
+		GetShopInfo(ctx context.Context, product dto.GetProductResponse) (dto.ShopInfoResponse, error)
+	}
+
+	MarketplaceRegistry interface {
+		Register(provider MarketplaceProvider)
+		// Resolve picks the provider responsible for the host of productUrl.
+		Resolve(productUrl string) (MarketplaceProvider, *url.URL, error)
+		Get(name string) (MarketplaceProvider, error)
+	}
+
+	marketplaceRegistry struct {
+		byHost map[string]MarketplaceProvider
+		byName map[string]MarketplaceProvider
+	}
+)
+
+func NewMarketplaceRegistry(providers ...MarketplaceProvider) MarketplaceRegistry {
+	r := &marketplaceRegistry{
+		byHost: make(map[string]MarketplaceProvider),
+		byName: make(map[string]MarketplaceProvider),
+	}
+	for _, provider := range providers {
+		r.Register(provider)
+	}
+	return r
+}
+
+func (r *marketplaceRegistry) Register(provider MarketplaceProvider) {
+	r.byName[provider.Name()] = provider
+	for _, host := range provider.Hosts() {
+		r.byHost[strings.ToLower(host)] = provider
+	}
+}
+
+func (r *marketplaceRegistry) Resolve(productUrl string) (MarketplaceProvider, *url.URL, error) {
+	if productUrl == "" {
+		return nil, nil, dto.ErrProductUrlMissing
+	}
+
+	parsedUrl, err := url.Parse(productUrl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provider, ok := r.byHost[strings.ToLower(parsedUrl.Hostname())]
+	if !ok {
+		return nil, nil, dto.ErrUnsupportedMarketplace
+	}
+
+	return provider, parsedUrl, nil
+}
+
+func (r *marketplaceRegistry) Get(name string) (MarketplaceProvider, error) {
+	provider, ok := r.byName[name]
+	if !ok {
+		return nil, dto.ErrMarketplaceNotFound
+	}
+	return provider, nil
+}
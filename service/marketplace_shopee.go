@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+const (
+	shopeeUrl            = "https://shopee.co.id"
+	shopeeImageUrl       = "https://down-id.img.susercontent.com/file/"
+	shopeeReviewsPerPage = 50
+)
+
+var (
+	// Matches both "/Product-Name-i.<shopid>.<itemid>" and
+	// "/product/<shopid>/<itemid>" style links.
+	shopeeSlugPattern    = regexp.MustCompile(`-i\.(\d+)\.(\d+)`)
+	shopeeProductPattern = regexp.MustCompile(`^/product/(\d+)/(\d+)`)
+)
+
+type shopeeProvider struct {
+	url string
+}
+
+// NewShopeeProvider calls the Shopee web API at baseUrl, or at
+// shopee.co.id when baseUrl is empty.
+func NewShopeeProvider(baseUrl string) MarketplaceProvider {
+	if baseUrl == "" {
+		baseUrl = shopeeUrl
+	}
+
+	return &shopeeProvider{
+		url: strings.TrimRight(baseUrl, "/"),
+	}
+}
+
+func (p *shopeeProvider) Name() string {
+	return constants.ENUM_PROVIDER_SHOPEE
+}
+
+func (p *shopeeProvider) Hosts() []string {
+	return []string{"shopee.co.id", "www.shopee.co.id"}
+}
+
+func (p *shopeeProvider) ResolveProduct(ctx context.Context, productUrl *url.URL) (dto.GetProductResponse, error) {
+	var shopId, itemId string
+	if match := shopeeSlugPattern.FindStringSubmatch(productUrl.Path); match != nil {
+		shopId, itemId = match[1], match[2]
+	} else if match := shopeeProductPattern.FindStringSubmatch(productUrl.Path); match != nil {
+		shopId, itemId = match[1], match[2]
+	} else {
+		return dto.GetProductResponse{}, dto.ErrProductUrlWrongFormat
+	}
+
+	var response dto.ShopeeItemResponse
+	endpoint := fmt.Sprintf("%s/api/v4/item/get?itemid=%s&shopid=%s", p.url, itemId, shopId)
+	if err := p.get(ctx, endpoint, productUrl.String(), &response); err != nil {
+		return dto.GetProductResponse{}, err
+	}
+
+	if response.Error != 0 || response.Data == nil {
+		return dto.GetProductResponse{}, dto.ErrProductNotFound
+	}
+
+	imageUrls := make([]string, 0, len(response.Data.Images))
+	for _, image := range response.Data.Images {
+		imageUrls = append(imageUrls, shopeeImageUrl+image)
+	}
+
+	return dto.GetProductResponse{
+		ProductName:        response.Data.Name,
+		ProductDescription: response.Data.Description,
+		ShopId:             shopId,
+		ProductId:          itemId,
+		ProductUrl:         fmt.Sprintf("%s/product/%s/%s", p.url, shopId, itemId),
+		ImageUrls:          imageUrls,
+	}, nil
+}
+
+// GetReviews pages through get_ratings. Shopee only filters on a single star
+// rating and has no sort or date parameters, so the remaining options are
+// applied to each page after it is fetched.
+func (p *shopeeProvider) GetReviews(ctx context.Context, req dto.GetReviewsRequest) ([]dto.ReviewResponse, error) {
+	opts, err := normalizeReviewOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	ratingType := 0
+	if len(opts.Ratings) == 1 {
+		ratingType = opts.Ratings[0]
+	}
+	filter := 0
+	if opts.WithMedia {
+		filter = 3
+	}
+
+	lastPage := (opts.MaxReviews + shopeeReviewsPerPage - 1) / shopeeReviewsPerPage
+	pages, err := fetchReviewPages(ctx, 0, lastPage-1, func(ctx context.Context, page int) ([]dto.ReviewResponse, error) {
+		var response dto.ShopeeRatingsResponse
+		endpoint := fmt.Sprintf(
+			"%s/api/v2/item/get_ratings?itemid=%s&shopid=%s&limit=%d&offset=%d&type=%d&filter=%d",
+			p.url, req.ProductId, req.ShopId, shopeeReviewsPerPage, page*shopeeReviewsPerPage, ratingType, filter,
+		)
+		if err := p.get(ctx, endpoint, req.ProductUrl, &response); err != nil {
+			return nil, err
+		}
+
+		reviews := make([]dto.ReviewResponse, 0, len(response.Data.Ratings))
+		for _, rating := range response.Data.Ratings {
+			var variant string
+			if len(rating.ProductItems) > 0 {
+				variant = rating.ProductItems[0].ModelName
+			}
+			reviews = append(reviews, dto.ReviewResponse{
+				ID:        strconv.FormatInt(rating.CmtID, 10),
+				Message:   rating.Comment,
+				Rating:    rating.RatingStar,
+				CreatedAt: time.Unix(rating.CTime, 0),
+				Variant:   variant,
+				HasMedia:  len(rating.Images) > 0 || len(rating.Videos) > 0,
+				Reviewer:  rating.AuthorUsername,
+			})
+		}
+		return reviews, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var allReviews []dto.ReviewResponse
+	for _, page := range pages {
+		for _, review := range page {
+			if strings.TrimSpace(review.Message) == "" ||
+				!hasRating(opts.Ratings, review.Rating) ||
+				!inReviewWindow(opts, review.CreatedAt) {
+				continue
+			}
+			allReviews = append(allReviews, review)
+			if len(allReviews) == opts.MaxReviews {
+				return allReviews, nil
+			}
+		}
+		if len(page) < shopeeReviewsPerPage {
+			break
+		}
+	}
+
+	return allReviews, nil
+}
+
+func (p *shopeeProvider) GetShopInfo(ctx context.Context, product dto.GetProductResponse) (dto.ShopInfoResponse, error) {
+	var response dto.ShopeeShopResponse
+	endpoint := fmt.Sprintf("%s/api/v4/shop/get_shop_detail?shopid=%s", p.url, product.ShopId)
+	if err := p.get(ctx, endpoint, product.ProductUrl, &response); err != nil {
+		return dto.ShopInfoResponse{}, err
+	}
+
+	if response.Error != 0 || response.Data == nil {
+		return dto.ShopInfoResponse{}, dto.ErrShopAvatarNotFound
+	}
+
+	var avatar string
+	if response.Data.Account.Portrait != "" {
+		avatar = shopeeImageUrl + response.Data.Account.Portrait
+	}
+
+	return dto.ShopInfoResponse{
+		Name:   response.Data.Name,
+		Avatar: avatar,
+	}, nil
+}
+
+func (p *shopeeProvider) get(ctx context.Context, endpoint string, referer string, out any) error {
+	client := &http.Client{}
+	shopeeReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return dto.ErrCreateHttpRequest
+	}
+
+	shopeeReq.Header.Add("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0.0.0 Safari/537.36")
+	shopeeReq.Header.Add("X-Api-Source", "pc")
+	shopeeReq.Header.Add("X-Requested-With", "XMLHttpRequest")
+	shopeeReq.Header.Add("Referer", referer)
+	shopeeReq.Header.Add("Accept", "application/json")
+
+	res, err := client.Do(shopeeReq)
+	if err != nil {
+		return dto.ErrSendsHttpRequest
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return dto.ErrReadHttpResponseBody
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return dto.ErrNotOk
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return dto.ErrParseJson
+	}
+
+	return nil
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+type tokopediaProvider struct {
+	tokopediaService TokopediaService
+}
+
+func NewTokopediaProvider(ts TokopediaService) MarketplaceProvider {
+	return &tokopediaProvider{
+		tokopediaService: ts,
+	}
+}
+
+func (p *tokopediaProvider) Name() string {
+	return constants.ENUM_PROVIDER_TOKOPEDIA
+}
+
+func (p *tokopediaProvider) Hosts() []string {
+	return []string{"www.tokopedia.com", "tokopedia.com", "tokopedia.link"}
+}
+
+func (p *tokopediaProvider) ResolveProduct(ctx context.Context, productUrl *url.URL) (dto.GetProductResponse, error) {
+	productReq, err := parseTokopediaUrl(productUrl)
+	if err != nil {
+		return dto.GetProductResponse{}, err
+	}
+
+	product, err := p.tokopediaService.GetProduct(ctx, productReq)
+	if err != nil {
+		return dto.GetProductResponse{}, err
+	}
+	product.ProductUrl = productReq.ProductUrl
+	product.ShopDomain = productReq.ShopDomain
+
+	return product, nil
+}
+
+func (p *tokopediaProvider) GetReviews(ctx context.Context, req dto.GetReviewsRequest) ([]dto.ReviewResponse, error) {
+	return p.tokopediaService.GetReviews(ctx, req)
+}
+
+func (p *tokopediaProvider) GetShopInfo(ctx context.Context, product dto.GetProductResponse) (dto.ShopInfoResponse, error) {
+	avatar, err := p.tokopediaService.GetShopAvatar(ctx, product.ShopDomain)
+	if err != nil {
+		return dto.ShopInfoResponse{}, err
+	}
+
+	return dto.ShopInfoResponse{
+		Name:   product.ShopName,
+		Avatar: avatar,
+	}, nil
+}
+
+// parseTokopediaUrl validates a Tokopedia product link, expanding
+// tokopedia.link short links, and builds the canonical product request.
+func parseTokopediaUrl(parsedUrl *url.URL) (dto.GetProductRequest, error) {
+	if strings.ToLower(parsedUrl.Hostname()) == "tokopedia.link" {
+		expandedUrl, err := expandUrl(parsedUrl.String())
+		if err != nil {
+			return dto.GetProductRequest{}, err
+		}
+
+		parsedUrl, err = url.Parse(expandedUrl)
+		if err != nil {
+			return dto.GetProductRequest{}, err
+		}
+	}
+
+	// Validate that the short link points back to tokopedia.com
+	if host := strings.ToLower(parsedUrl.Hostname()); host != "www.tokopedia.com" && host != "tokopedia.com" {
+		return dto.GetProductRequest{}, dto.ErrUnsupportedMarketplace
+	}
+
+	pathParts := strings.Split(parsedUrl.Path, "/")
+	if len(pathParts) < 3 {
+		return dto.GetProductRequest{}, dto.ErrProductUrlWrongFormat
+	}
+
+	return dto.GetProductRequest{
+		ShopDomain: pathParts[1],
+		ProductKey: pathParts[2],
+		ProductUrl: "https://www.tokopedia.com/" + pathParts[1] + "/" + pathParts[2],
+	}, nil
+}
+
+func expandUrl(shortUrl string) (string, error) {
+	client := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			// Prevent the default redirect behavior to handle it manually
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// First request
+	req1, err := http.NewRequest("GET", shortUrl, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	// Set headers for the first request
+	req1.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1")
+
+	// Make the first request
+	resp1, err := client.Do(req1)
+	if err != nil {
+		return "", fmt.Errorf("error making first request: %v", err)
+	}
+	defer resp1.Body.Close()
+
+	if resp1.StatusCode >= 400 {
+		return "", fmt.Errorf("unexpected status code from first request: %d", resp1.StatusCode)
+	}
+
+	newUrl1 := resp1.Header.Get("Location")
+	if newUrl1 == "" {
+		return "", errors.New("empty Location header in first response")
+	}
+
+	// Second request
+	req2, err := http.NewRequest("GET", newUrl1, nil)
+	if err != nil {
+		return "", fmt.Errorf("error creating second request: %v", err)
+	}
+	// Set headers for the second request
+	req2.Header.Set("User-Agent", "Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1")
+
+	// Make the second request
+	resp2, err := client.Do(req2)
+	if err != nil {
+		return "", fmt.Errorf("error making second request: %v", err)
+	}
+	defer resp2.Body.Close()
+
+	if resp2.StatusCode >= 400 {
+		return "", fmt.Errorf("unexpected status code from second request: %d", resp2.StatusCode)
+	}
+
+	finalUrl := resp2.Header.Get("Location")
+	if finalUrl == "" {
+		return "", errors.New("empty Location header in second response")
+	}
+
+	return finalUrl, nil
+}
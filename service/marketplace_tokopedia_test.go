@@ -0,0 +1,50 @@
+package service
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"review_product_tokopedia_be/dto"
+)
+
+func TestParseTokopediaUrl(t *testing.T) {
+	const canonical = "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro"
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "canonical", url: canonical},
+		{name: "bare domain", url: "https://tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro"},
+		{name: "mixed case host", url: "https://WWW.Tokopedia.COM/tokoaudio/earphone-bluetooth-tws-pro"},
+		{name: "explicit port", url: "https://www.tokopedia.com:443/tokoaudio/earphone-bluetooth-tws-pro?extParam=ivf"},
+		{name: "other marketplace", url: "https://shopee.co.id/tokoaudio/earphone-bluetooth-tws-pro", wantErr: dto.ErrUnsupportedMarketplace},
+		{name: "lookalike host", url: "https://tokopedia.com.evil.example/tokoaudio/earphone-bluetooth-tws-pro", wantErr: dto.ErrUnsupportedMarketplace},
+		{name: "shop page", url: "https://www.tokopedia.com/tokoaudio", wantErr: dto.ErrProductUrlWrongFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsedUrl, err := url.Parse(tt.url)
+			if err != nil {
+				t.Fatalf("url.Parse: %v", err)
+			}
+
+			req, err := parseTokopediaUrl(parsedUrl)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseTokopediaUrl: %v", err)
+			}
+			if req.ProductUrl != canonical || req.ShopDomain != "tokoaudio" || req.ProductKey != "earphone-bluetooth-tws-pro" {
+				t.Errorf("request = %+v", req)
+			}
+		})
+	}
+}
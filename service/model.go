@@ -4,31 +4,149 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-
+	"fmt"
 	"io"
 	"net/http"
-	"os"
+	"sync"
 
+	"review_product_tokopedia_be/constants"
 	"review_product_tokopedia_be/dto"
+
+	"github.com/sirupsen/logrus"
 )
 
+// modelPredictConcurrency bounds the per-statement requests in flight for
+// one Predict call.
+const modelPredictConcurrency = 8
+
 type (
 	ModelService interface {
 		Predict(ctx context.Context, req dto.PredictRequest) (dto.PredictResponse, error)
+		// Ping checks the ML service is reachable and accepts the API key
+		// without running a prediction.
+		Ping(ctx context.Context) error
 	}
 
 	modelService struct {
-		predictEndpoint string
+		predictEndpoint   string
+		apiKey            string
+		perStatementLimit int
 	}
 )
 
-func NewModelService() ModelService {
+// NewModelService talks to the ML predict endpoint. perStatementLimit is
+// the largest batch that may be labelled one statement at a time when the
+// service only reports counts; zero disables that fallback.
+func NewModelService(predictEndpoint string, apiKey string, perStatementLimit int) ModelService {
 	return &modelService{
-		predictEndpoint: os.Getenv("ML_URL"),
+		predictEndpoint:   predictEndpoint,
+		apiKey:            apiKey,
+		perStatementLimit: perStatementLimit,
 	}
 }
 
+// Predict labels the statements of a batch. The deployed ML service only
+// returns the Positive and Negative counts of a batch; without per-statement
+// predictions the counts are returned without Predictions and the reviews
+// stay unlabelled, unless the batch fits perStatementLimit and each
+// statement is sent on its own instead.
 func (s *modelService) Predict(ctx context.Context, req dto.PredictRequest) (dto.PredictResponse, error) {
+	if len(req.Statements) == 0 {
+		return dto.PredictResponse{}, nil
+	}
+
+	response, err := s.post(ctx, req)
+	if err != nil {
+		return dto.PredictResponse{}, err
+	}
+	if len(response.Predictions) > 0 {
+		if len(response.Predictions) != len(req.Statements) {
+			return dto.PredictResponse{}, fmt.Errorf("%w: got %d for %d statements", dto.ErrPredictionCount, len(response.Predictions), len(req.Statements))
+		}
+		return response, nil
+	}
+
+	if len(req.Statements) > s.perStatementLimit {
+		logrus.WithFields(logrus.Fields{
+			"statements":          len(req.Statements),
+			"per_statement_limit": s.perStatementLimit,
+		}).Warn("ML service reports batch counts only, statements stay unlabelled")
+		return response, nil
+	}
+
+	return s.predictEach(ctx, req.Statements)
+}
+
+// predictEach sends every statement in a request of its own, at most
+// modelPredictConcurrency at a time. The count-only response carries no
+// confidence, so Confidence stays nil.
+func (s *modelService) predictEach(ctx context.Context, statements []string) (dto.PredictResponse, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	labels := make([]string, len(statements))
+	sem := make(chan struct{}, modelPredictConcurrency)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	fail := func(err error) {
+		once.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+	for i, statement := range statements {
+		wg.Add(1)
+		go func(i int, statement string) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				fail(ctx.Err())
+				return
+			}
+			defer func() { <-sem }()
+
+			res, err := s.post(ctx, dto.PredictRequest{Statements: []string{statement}})
+			if err != nil {
+				fail(err)
+				return
+			}
+
+			switch {
+			case res.CountPositive == 1 && res.CountNegative == 0:
+				labels[i] = constants.ENUM_SENTIMENT_POSITIVE
+			case res.CountNegative == 1 && res.CountPositive == 0:
+				labels[i] = constants.ENUM_SENTIMENT_NEGATIVE
+			default:
+				fail(fmt.Errorf("%w: got %d positive and %d negative for 1 statement", dto.ErrPredictionCount, res.CountPositive, res.CountNegative))
+			}
+		}(i, statement)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return dto.PredictResponse{}, firstErr
+	}
+
+	response := dto.PredictResponse{
+		Predictions: make([]dto.StatementPrediction, len(statements)),
+	}
+	for i, label := range labels {
+		response.Predictions[i].Label = label
+		if label == constants.ENUM_SENTIMENT_POSITIVE {
+			response.CountPositive++
+		} else {
+			response.CountNegative++
+		}
+	}
+	return response, nil
+}
+
+// post sends one request to the predict endpoint.
+func (s *modelService) post(ctx context.Context, req dto.PredictRequest) (dto.PredictResponse, error) {
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return dto.PredictResponse{}, dto.ErrMarshallJson
@@ -44,7 +162,7 @@ func (s *modelService) Predict(ctx context.Context, req dto.PredictRequest) (dto
 		return dto.PredictResponse{}, dto.ErrCreateHttpRequest
 	}
 	httpReq.Header.Add("Content-Type", "application/json")
-	httpReq.Header.Add("api-key", os.Getenv("ML_API_KEY"))
+	httpReq.Header.Add("api-key", s.apiKey)
 
 	// Perform the HTTP request
 	res, err := client.Do(httpReq)
@@ -63,6 +181,10 @@ func (s *modelService) Predict(ctx context.Context, req dto.PredictRequest) (dto
 	if res.StatusCode == http.StatusInternalServerError {
 		return dto.PredictResponse{}, dto.ErrModelInternalServerError
 	}
+	// Any other error body would parse as zero counts
+	if res.StatusCode != http.StatusOK {
+		return dto.PredictResponse{}, fmt.Errorf("%w: %s", dto.ErrNotOk, res.Status)
+	}
 
 	// Parse the response JSON into the response DTO
 	var response dto.PredictResponse
@@ -73,3 +195,25 @@ func (s *modelService) Predict(ctx context.Context, req dto.PredictRequest) (dto
 
 	return response, nil
 }
+
+// Ping sends a GET to the predict endpoint. The endpoint only serves POST,
+// so any answer other than a server or authentication error means the
+// service is up.
+func (s *modelService) Ping(ctx context.Context) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", s.predictEndpoint, nil)
+	if err != nil {
+		return dto.ErrCreateHttpRequest
+	}
+	httpReq.Header.Add("api-key", s.apiKey)
+
+	res, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return dto.ErrSendsHttpRequest
+	}
+	res.Body.Close()
+
+	if res.StatusCode >= http.StatusInternalServerError || res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: %s", dto.ErrNotOk, res.Status)
+	}
+	return nil
+}
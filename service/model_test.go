@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"review_product_tokopedia_be/dto"
+)
+
+// newFakeModelServer answers like the deployed ML service: the Negative and
+// Positive counts of a batch, with extra merged into the response.
+func newFakeModelServer(t *testing.T, extra func(req dto.PredictRequest) map[string]any) (*httptest.Server, func() []int) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("api-key") != "model-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"detail": "unauthorized"})
+			return
+		}
+
+		var req dto.PredictRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		batches = append(batches, len(req.Statements))
+		mu.Unlock()
+
+		res := map[string]any{}
+		negative := 0
+		for _, statement := range req.Statements {
+			if strings.Contains(statement, "kecewa") {
+				negative++
+			}
+		}
+		res["Negative"] = negative
+		res["Positive"] = len(req.Statements) - negative
+		if extra != nil {
+			for key, value := range extra(req) {
+				res[key] = value
+			}
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), batches...)
+	}
+}
+
+func TestModelPredictCountsOnly(t *testing.T) {
+	server, batches := newFakeModelServer(t, nil)
+	s := NewModelService(server.URL, "model-key", 3)
+
+	statements := []string{"bagus", "kecewa berat", "mantap"}
+	res, err := s.Predict(context.Background(), dto.PredictRequest{Statements: statements})
+	if err != nil {
+		t.Fatalf("predict: %v", err)
+	}
+
+	want := []string{"positive", "negative", "positive"}
+	if len(res.Predictions) != len(want) {
+		t.Fatalf("got %d predictions, want %d", len(res.Predictions), len(want))
+	}
+	for i, prediction := range res.Predictions {
+		if prediction.Label != want[i] {
+			t.Errorf("statement %d labelled %q, want %q", i, prediction.Label, want[i])
+		}
+	}
+	if res.CountPositive != 2 || res.CountNegative != 1 {
+		t.Errorf("counts = %d positive, %d negative", res.CountPositive, res.CountNegative)
+	}
+
+	// Every call asks for the batch first, so a service that starts
+	// reporting predictions is picked up right away.
+	if _, err := s.Predict(context.Background(), dto.PredictRequest{Statements: statements}); err != nil {
+		t.Fatalf("second predict: %v", err)
+	}
+	multi := 0
+	for _, size := range batches() {
+		if size > 1 {
+			multi++
+		}
+	}
+	if multi != 2 || len(batches()) != 2*(1+len(statements)) {
+		t.Errorf("batch sizes sent = %v, want a batch then single statements, twice", batches())
+	}
+}
+
+func TestModelPredictCountsOnlyOverLimit(t *testing.T) {
+	statements := []string{"bagus", "kecewa berat", "mantap"}
+
+	for name, limit := range map[string]int{"disabled": 0, "batch over the limit": 2} {
+		t.Run(name, func(t *testing.T) {
+			server, batches := newFakeModelServer(t, nil)
+			s := NewModelService(server.URL, "model-key", limit)
+
+			res, err := s.Predict(context.Background(), dto.PredictRequest{Statements: statements})
+			if err != nil {
+				t.Fatalf("predict: %v", err)
+			}
+			if len(res.Predictions) != 0 || res.CountPositive != 2 || res.CountNegative != 1 {
+				t.Errorf("response = %+v, want the batch counts without predictions", res)
+			}
+			if sent := batches(); len(sent) != 1 || sent[0] != len(statements) {
+				t.Errorf("batch sizes sent = %v, want the single batch", sent)
+			}
+		})
+	}
+}
+
+func TestModelPredictReportedPredictions(t *testing.T) {
+	t.Run("used as is", func(t *testing.T) {
+		server, batches := newFakeModelServer(t, func(req dto.PredictRequest) map[string]any {
+			predictions := make([]dto.StatementPrediction, len(req.Statements))
+			for i := range predictions {
+				confidence := float32(0.8)
+				predictions[i] = dto.StatementPrediction{Label: "Positive", Confidence: &confidence}
+			}
+			return map[string]any{"predictions": predictions, "model_version": "v2"}
+		})
+		s := NewModelService(server.URL, "model-key", 10)
+
+		res, err := s.Predict(context.Background(), dto.PredictRequest{Statements: []string{"a", "b"}})
+		if err != nil {
+			t.Fatalf("predict: %v", err)
+		}
+		if len(res.Predictions) != 2 || res.ModelVersion != "v2" || res.Predictions[0].Confidence == nil || *res.Predictions[0].Confidence != 0.8 {
+			t.Errorf("response = %+v", res)
+		}
+		if len(batches()) != 1 {
+			t.Errorf("sent %d requests, want the single batch", len(batches()))
+		}
+	})
+
+	t.Run("count mismatch", func(t *testing.T) {
+		server, _ := newFakeModelServer(t, func(req dto.PredictRequest) map[string]any {
+			return map[string]any{"predictions": []dto.StatementPrediction{{Label: "Positive"}}}
+		})
+		s := NewModelService(server.URL, "model-key", 10)
+
+		_, err := s.Predict(context.Background(), dto.PredictRequest{Statements: []string{"a", "b"}})
+		if !errors.Is(err, dto.ErrPredictionCount) {
+			t.Errorf("err = %v, want ErrPredictionCount", err)
+		}
+	})
+}
+
+func TestModelPredictRejected(t *testing.T) {
+	server, _ := newFakeModelServer(t, nil)
+	s := NewModelService(server.URL, "wrong-key", 10)
+
+	_, err := s.Predict(context.Background(), dto.PredictRequest{Statements: []string{"a", "b"}})
+	if !errors.Is(err, dto.ErrNotOk) {
+		t.Errorf("err = %v, want ErrNotOk", err)
+	}
+}
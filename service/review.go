@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/repository"
+)
+
+type (
+	ReviewService interface {
+		GetAnalysedReviews(ctx context.Context, historyId string) ([]dto.AnalysedReview, error)
+	}
+
+	reviewService struct {
+		reviewRepo repository.ReviewRepository
+	}
+)
+
+func NewReviewService(reviewRepo repository.ReviewRepository) ReviewService {
+	return &reviewService{
+		reviewRepo: reviewRepo,
+	}
+}
+
+// GetAnalysedReviews returns the reviews of a history together with the
+// labels they were given, newest first.
+func (s *reviewService) GetAnalysedReviews(ctx context.Context, historyId string) ([]dto.AnalysedReview, error) {
+	sentiments, err := s.reviewRepo.GetReviewSentimentsByHistoryId(ctx, nil, historyId)
+	if err != nil {
+		return nil, dto.ErrGetAnalysedReviews
+	}
+
+	analysed := make([]dto.AnalysedReview, len(sentiments))
+	for i, sentiment := range sentiments {
+		analysed[i] = dto.AnalysedReview{
+			Review: dto.ReviewResponse{
+				ID:        sentiment.Review.ExternalID,
+				Message:   sentiment.Review.Message,
+				Rating:    sentiment.Review.Rating,
+				CreatedAt: sentiment.Review.ReviewedAt,
+				Variant:   sentiment.Review.Variant,
+				HasMedia:  sentiment.Review.HasMedia,
+				Reviewer:  sentiment.Review.Reviewer,
+			},
+			Prediction: dto.StatementPrediction{
+				Label:      sentiment.Label,
+				Confidence: sentiment.Confidence,
+			},
+			AspectLabel: dto.ReviewAspectLabel{
+				ID:        sentiment.Review.ExternalID,
+				Aspect:    sentiment.Aspect,
+				Sentiment: sentiment.AspectSentiment,
+			},
+			ModelVersion: sentiment.ModelVersion,
+		}
+	}
+
+	return analysed, nil
+}
+
+// reviewExternalId returns the marketplace ID of a review, falling back to a
+// content hash for providers that do not expose one.
+func reviewExternalId(review dto.ReviewResponse) string {
+	if review.ID != "" {
+		return review.ID
+	}
+
+	sum := sha1.Sum([]byte(review.Message + "|" + strconv.FormatInt(review.CreatedAt.Unix(), 10)))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"review_product_tokopedia_be/dto"
+)
+
+const reviewFetchConcurrency = 4
+
+// normalizeReviewOptions validates opts and fills in defaults.
+func normalizeReviewOptions(opts dto.ReviewOptions) (dto.ReviewOptions, error) {
+	if opts.MaxReviews <= 0 {
+		opts.MaxReviews = dto.DEFAULT_MAX_REVIEWS
+	}
+	if opts.MaxReviews > dto.LIMIT_MAX_REVIEWS {
+		opts.MaxReviews = dto.LIMIT_MAX_REVIEWS
+	}
+
+	switch opts.SortBy {
+	case "":
+		opts.SortBy = dto.REVIEW_SORT_NEWEST
+	case dto.REVIEW_SORT_NEWEST, dto.REVIEW_SORT_OLDEST, dto.REVIEW_SORT_HIGHEST_RATING,
+		dto.REVIEW_SORT_LOWEST_RATING, dto.REVIEW_SORT_MOST_HELPFUL:
+	default:
+		return dto.ReviewOptions{}, dto.ErrInvalidReviewSort
+	}
+
+	for _, rating := range opts.Ratings {
+		if rating < 1 || rating > 5 {
+			return dto.ReviewOptions{}, dto.ErrInvalidReviewRating
+		}
+	}
+
+	// Equivalent options must look the same wherever they are compared or
+	// hashed, such as in cache keys and stored histories, so ratings are
+	// sorted without duplicates and the window is kept in UTC.
+	if len(opts.Ratings) > 0 {
+		ratings := append([]int(nil), opts.Ratings...)
+		sort.Ints(ratings)
+		opts.Ratings = ratings[:0]
+		for i, rating := range ratings {
+			if i == 0 || rating != ratings[i-1] {
+				opts.Ratings = append(opts.Ratings, rating)
+			}
+		}
+	}
+	for _, t := range []**time.Time{&opts.Since, &opts.Until} {
+		if *t != nil {
+			utc := (*t).UTC()
+			*t = &utc
+		}
+	}
+
+	if opts.Since != nil && opts.Until != nil && !opts.Since.Before(*opts.Until) {
+		return dto.ReviewOptions{}, dto.ErrInvalidReviewWindow
+	}
+
+	return opts, nil
+}
+
+// ParseReviewOptions reads the review harvesting options from string
+// parameters looked up by name: max_reviews, sort, rating (comma separated),
+// since, until (YYYY-MM-DD or RFC3339) and with_media. param returns ""
+// for a parameter that is not set.
+func ParseReviewOptions(param func(name string) string) (dto.ReviewOptions, error) {
+	var opts dto.ReviewOptions
+
+	if v := param("max_reviews"); v != "" {
+		maxReviews, err := strconv.Atoi(v)
+		if err != nil {
+			return dto.ReviewOptions{}, dto.ErrInvalidReviewOptions
+		}
+		opts.MaxReviews = maxReviews
+	}
+
+	opts.SortBy = param("sort")
+
+	if v := param("rating"); v != "" {
+		for _, part := range strings.Split(v, ",") {
+			rating, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return dto.ReviewOptions{}, dto.ErrInvalidReviewRating
+			}
+			opts.Ratings = append(opts.Ratings, rating)
+		}
+	}
+
+	for key, dst := range map[string]**time.Time{"since": &opts.Since, "until": &opts.Until} {
+		v := param(key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			t, err = time.Parse(time.DateOnly, v)
+		}
+		if err != nil {
+			return dto.ReviewOptions{}, dto.ErrInvalidReviewOptions
+		}
+		*dst = &t
+	}
+
+	if v := param("with_media"); v != "" {
+		withMedia, err := strconv.ParseBool(v)
+		if err != nil {
+			return dto.ReviewOptions{}, dto.ErrInvalidReviewOptions
+		}
+		opts.WithMedia = withMedia
+	}
+
+	return opts, nil
+}
+
+// inReviewWindow reports whether a review created at t falls inside the
+// since/until window of opts.
+func inReviewWindow(opts dto.ReviewOptions, t time.Time) bool {
+	if opts.Since != nil && t.Before(*opts.Since) {
+		return false
+	}
+	if opts.Until != nil && !t.Before(*opts.Until) {
+		return false
+	}
+	return true
+}
+
+func hasRating(ratings []int, rating int) bool {
+	if len(ratings) == 0 {
+		return true
+	}
+	for _, r := range ratings {
+		if r == rating {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchReviewPages calls fetch for every page in [first, last] with at most
+// reviewFetchConcurrency requests in flight and returns the pages in order.
+func fetchReviewPages(ctx context.Context, first int, last int, fetch func(ctx context.Context, page int) ([]dto.ReviewResponse, error)) ([][]dto.ReviewResponse, error) {
+	if last < first {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pages := make([][]dto.ReviewResponse, last-first+1)
+	sem := make(chan struct{}, reviewFetchConcurrency)
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	for page := first; page <= last; page++ {
+		wg.Add(1)
+		go func(page int) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			reviews, err := fetch(ctx, page)
+			if err != nil {
+				// Keep the error that caused the cancellation rather than the
+				// context errors it triggers in the other fetches.
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+				return
+			}
+			pages[page-first] = reviews
+		}(page)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+
+	"review_product_tokopedia_be/constants"
+	"review_product_tokopedia_be/dto"
+)
+
+const (
+	defaultShopProducts          = 10
+	maxShopProducts              = 20
+	defaultShopReviewsPerProduct = 30
+	maxShopReviewsPerProduct     = 100
+	shopAnalysisConcurrency      = 2
+)
+
+type (
+	ShopAnalysisService interface {
+		// Analyze samples the newest reviews of the shop's best-selling
+		// products, runs the product pipeline on each of them and aggregates
+		// the results. onEvent may be nil.
+		Analyze(ctx context.Context, req dto.ShopAnalysisRequest, onEvent func(event dto.AnalysisEvent)) (dto.ShopAnalysisResult, error)
+	}
+
+	shopAnalysisService struct {
+		tokopediaService TokopediaService
+		analysisService  AnalysisService
+		geminiService    GeminiService
+	}
+)
+
+func NewShopAnalysisService(ts TokopediaService, as AnalysisService, gs GeminiService) ShopAnalysisService {
+	return &shopAnalysisService{
+		tokopediaService: ts,
+		analysisService:  as,
+		geminiService:    gs,
+	}
+}
+
+func (s *shopAnalysisService) Analyze(ctx context.Context, req dto.ShopAnalysisRequest, onEvent func(event dto.AnalysisEvent)) (dto.ShopAnalysisResult, error) {
+	req, err := normalizeShopAnalysisRequest(req)
+	if err != nil {
+		return dto.ShopAnalysisResult{}, analysisError(dto.ANALYSIS_STAGE_URL, dto.MESSAGE_FAILED_ANALYZE_SHOP, err)
+	}
+
+	emit := func(stage string, status string, data any) {
+		if onEvent != nil {
+			onEvent(dto.AnalysisEvent{Stage: stage, Status: status, Data: data})
+		}
+	}
+
+	emit(dto.SHOP_ANALYSIS_STAGE_CATALOGUE, constants.ENUM_STAGE_STATUS_RUNNING, nil)
+	shop, err := s.tokopediaService.GetShopProducts(ctx, req.ShopDomain, req.Products)
+	if err == nil && len(shop.Products) == 0 {
+		err = dto.ErrShopHasNoProducts
+	}
+	if err != nil {
+		emit(dto.SHOP_ANALYSIS_STAGE_CATALOGUE, constants.ENUM_STAGE_STATUS_FAILED, nil)
+		return dto.ShopAnalysisResult{}, analysisError(dto.SHOP_ANALYSIS_STAGE_CATALOGUE, dto.MESSAGE_FAILED_GET_SHOP_PRODUCTS, err)
+	}
+	emit(dto.SHOP_ANALYSIS_STAGE_CATALOGUE, constants.ENUM_STAGE_STATUS_SUCCEEDED, shop.Products)
+
+	emit(dto.SHOP_ANALYSIS_STAGE_PRODUCTS, constants.ENUM_STAGE_STATUS_RUNNING, nil)
+	results := s.analyzeProducts(ctx, shop.Products, req.ReviewsPerProduct, func(done int) {
+		emit(dto.SHOP_ANALYSIS_STAGE_PRODUCTS, constants.ENUM_STAGE_STATUS_PARTIAL, dto.ShopAnalysisProgressData{
+			Done:  done,
+			Total: len(shop.Products),
+		})
+	})
+	if err := ctx.Err(); err != nil {
+		return dto.ShopAnalysisResult{}, err
+	}
+
+	result := aggregateShopAnalysis(shop, results)
+	if result.ProductsAnalysed == 0 {
+		emit(dto.SHOP_ANALYSIS_STAGE_PRODUCTS, constants.ENUM_STAGE_STATUS_FAILED, nil)
+		return dto.ShopAnalysisResult{}, analysisError(dto.SHOP_ANALYSIS_STAGE_PRODUCTS, dto.MESSAGE_FAILED_ANALYZE_SHOP, dto.ErrShopProductsFailed)
+	}
+	emit(dto.SHOP_ANALYSIS_STAGE_PRODUCTS, constants.ENUM_STAGE_STATUS_SUCCEEDED, nil)
+
+	emit(dto.SHOP_ANALYSIS_STAGE_SUMMARIZE, constants.ENUM_STAGE_STATUS_RUNNING, nil)
+	summary, err := s.geminiService.Summarize(ctx, shopSummaryInput(result.Products))
+	if err != nil {
+		emit(dto.SHOP_ANALYSIS_STAGE_SUMMARIZE, constants.ENUM_STAGE_STATUS_FAILED, nil)
+		return dto.ShopAnalysisResult{}, analysisError(dto.SHOP_ANALYSIS_STAGE_SUMMARIZE, dto.MESSAGE_FAILED_ANALYZE, err)
+	}
+	result.Summary = summary
+	emit(dto.SHOP_ANALYSIS_STAGE_SUMMARIZE, constants.ENUM_STAGE_STATUS_SUCCEEDED, dto.AnalysisSummaryData{Text: summary})
+
+	return result, nil
+}
+
+// analyzeProducts runs the product pipeline as guest, so sampling a shop
+// does not add every product to the user's history. A failed product is
+// kept with its error instead of failing the whole shop.
+func (s *shopAnalysisService) analyzeProducts(ctx context.Context, products []dto.ShopProduct, reviewsPerProduct int, onDone func(done int)) []shopProductResult {
+	results := make([]shopProductResult, len(products))
+	sem := make(chan struct{}, shopAnalysisConcurrency)
+
+	var mu sync.Mutex
+	done := 0
+
+	var wg sync.WaitGroup
+	for i, product := range products {
+		wg.Add(1)
+		go func(i int, product dto.ShopProduct) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			result, err := s.analysisService.Analyze(ctx, product.ProductURL, dto.AnalysisOptions{
+				Reviews: dto.ReviewOptions{MaxReviews: reviewsPerProduct},
+			})
+			results[i] = shopProductResult{product: product, result: result.Result, err: err}
+
+			mu.Lock()
+			done++
+			onDone(done)
+			mu.Unlock()
+		}(i, product)
+	}
+	wg.Wait()
+
+	return results
+}
+
+type shopProductResult struct {
+	product dto.ShopProduct
+	result  dto.MLResult
+	err     error
+}
+
+func aggregateShopAnalysis(shop dto.GetShopProductsResponse, results []shopProductResult) dto.ShopAnalysisResult {
+	res := dto.ShopAnalysisResult{
+		ShopID:     shop.ShopID,
+		ShopName:   shop.ShopName,
+		ShopDomain: shop.ShopDomain,
+		ShopAvatar: shop.ShopAvatar,
+		Products:   make([]dto.ShopProductAnalysis, len(results)),
+	}
+
+	var labels []dto.ReviewAspectLabel
+	ratingSum := 0.0
+	for i, r := range results {
+		breakdown := dto.ShopProductAnalysis{
+			ProductID:   r.product.ProductID,
+			ProductName: r.product.ProductName,
+			ProductURL:  r.product.ProductURL,
+		}
+		if r.err != nil {
+			var analysisErr *dto.AnalysisError
+			breakdown.Error = r.err.Error()
+			if errors.As(r.err, &analysisErr) {
+				breakdown.Error = analysisErr.Message + ": " + analysisErr.Error()
+			}
+			res.Products[i] = breakdown
+			continue
+		}
+
+		breakdown.Rating = r.result.Rating
+		breakdown.Bintang = r.result.Bintang
+		breakdown.CountPositive = r.result.CountPositive
+		breakdown.CountNegative = r.result.CountNegative
+		breakdown.Packaging = r.result.Packaging
+		breakdown.Delivery = r.result.Delivery
+		breakdown.AdminResponse = r.result.AdminResponse
+		breakdown.ProductCondition = r.result.ProductCondition
+		breakdown.Summary = r.result.Summary
+		res.Products[i] = breakdown
+
+		res.ProductsAnalysed++
+		res.Rating += r.result.Rating
+		res.CountPositive += r.result.CountPositive
+		res.CountNegative += r.result.CountNegative
+		ratingSum += r.result.Bintang * float64(r.result.Rating)
+		labels = append(labels, r.result.AspectLabels...)
+	}
+
+	if res.Rating > 0 {
+		res.Bintang = math.Round(ratingSum/float64(res.Rating)*100) / 100
+	}
+
+	scores := scoreAspects(labels)
+	res.Packaging = scores.Packaging
+	res.Delivery = scores.Delivery
+	res.AdminResponse = scores.AdminResponse
+	res.ProductCondition = scores.ProductCondition
+
+	return res
+}
+
+// shopSummaryInput feeds the product summaries to the summarizer, one line
+// per product, instead of every review of the shop.
+func shopSummaryInput(products []dto.ShopProductAnalysis) string {
+	var builder strings.Builder
+	for _, product := range products {
+		if product.Error != "" || product.Summary == "" {
+			continue
+		}
+		builder.WriteString(product.ProductName)
+		builder.WriteString(": ")
+		builder.WriteString(product.Summary)
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
+
+func normalizeShopAnalysisRequest(req dto.ShopAnalysisRequest) (dto.ShopAnalysisRequest, error) {
+	// Accept a link to the shop page as well as the bare domain
+	req.ShopDomain = strings.TrimSpace(req.ShopDomain)
+	if parsed, err := url.Parse(req.ShopDomain); err == nil && parsed.Host != "" {
+		req.ShopDomain = strings.Split(strings.Trim(parsed.Path, "/"), "/")[0]
+	}
+	req.ShopDomain = strings.Trim(req.ShopDomain, "/")
+	if req.ShopDomain == "" {
+		return req, dto.ErrShopDomainMissing
+	}
+
+	if req.Products == 0 {
+		req.Products = defaultShopProducts
+	}
+	if req.ReviewsPerProduct == 0 {
+		req.ReviewsPerProduct = defaultShopReviewsPerProduct
+	}
+	if req.Products < 1 || req.Products > maxShopProducts ||
+		req.ReviewsPerProduct < 1 || req.ReviewsPerProduct > maxShopReviewsPerProduct {
+		return req, dto.ErrInvalidShopOptions
+	}
+
+	return req, nil
+}
@@ -1,228 +1,618 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"review_product_tokopedia_be/dto"
 )
 
+const (
+	tokopediaReviewsPerPage = 50
+	// tokopediaShopSortBestSelling orders a shop's products by units sold,
+	// the "Terlaris" option of the shop page.
+	tokopediaShopSortBestSelling = 8
+)
+
+// tokopediaPDPQuery fetches a product page layout. Only the fragments read
+// by GetProduct are requested; every component carries its __typename so
+// it can be decoded without relying on its position in the layout.
+const tokopediaPDPQuery = `fragment ProductVariant on pdpDataProductVariant {
+  variants {
+    name
+    option {
+      value
+      __typename
+    }
+    __typename
+  }
+  children {
+    productID
+    stock {
+      stock
+      __typename
+    }
+    __typename
+  }
+  __typename
+}
+
+fragment ProductMedia on pdpDataProductMedia {
+  media {
+    type
+    urlOriginal: URLOriginal
+    __typename
+  }
+  __typename
+}
+
+fragment ProductCategoryCarousel on pdpDataCategoryCarousel {
+  list {
+    categoryID
+    title
+    __typename
+  }
+  __typename
+}
+
+fragment ProductHighlight on pdpDataProductContent {
+  name
+  price {
+    value
+    currency
+    priceFmt
+    slashPriceFmt
+    discPercentage
+    __typename
+  }
+  stock {
+    value
+    stockWording
+    __typename
+  }
+  __typename
+}
+
+fragment ProductDetail on pdpDataProductDetail {
+  content {
+    title
+    subtitle
+  }
+  __typename
+}
+
+fragment ProductSocial on pdpDataSocialProof {
+  content {
+    title
+    subtitle
+    type
+    rating
+    __typename
+  }
+  __typename
+}
+
+query PDPGetLayoutQuery($shopDomain: String, $productKey: String, $layoutID: String, $apiVersion: Float, $userLocation: pdpUserLocation, $extParam: String, $tokonow: pdpTokoNow, $deviceID: String) {
+  pdpGetLayout(shopDomain: $shopDomain, productKey: $productKey, layoutID: $layoutID, apiVersion: $apiVersion, userLocation: $userLocation, extParam: $extParam, tokonow: $tokonow, deviceID: $deviceID) {
+    basicInfo {
+      id: productID
+      shopName
+      category {
+        id
+        name
+        detail {
+          id
+          name
+        }
+      }
+      txStats {
+        countSold
+      }
+      stats {
+        countReview
+        rating
+      }
+    }
+    components {
+      name
+      data {
+        ...ProductMedia
+        ...ProductHighlight
+        ...ProductDetail
+        ...ProductSocial
+        ...ProductVariant
+        ...ProductCategoryCarousel
+        __typename
+      }
+      __typename
+    }
+    __typename
+  }
+}
+`
+
+var tokopediaReviewSort = map[string]string{
+	dto.REVIEW_SORT_NEWEST:         "create_time desc",
+	dto.REVIEW_SORT_OLDEST:         "create_time asc",
+	dto.REVIEW_SORT_HIGHEST_RATING: "rating desc",
+	dto.REVIEW_SORT_LOWEST_RATING:  "rating asc",
+	dto.REVIEW_SORT_MOST_HELPFUL:   "informative_score desc",
+}
+
 type (
 	TokopediaService interface {
 		GetProduct(ctx context.Context, req dto.GetProductRequest) (dto.GetProductResponse, error)
 		GetReviews(ctx context.Context, req dto.GetReviewsRequest) ([]dto.ReviewResponse, error)
 		GetShopAvatar(ctx context.Context, shopDomain string) (string, error)
+		// GetShopProducts returns the shop's best-selling products, at most
+		// limit of them.
+		GetShopProducts(ctx context.Context, shopDomain string, limit int) (dto.GetShopProductsResponse, error)
+		// Ping sends the smallest possible query to the GraphQL gateway.
+		Ping(ctx context.Context) error
 	}
 
 	tokopediaService struct {
-		url string
+		url    string
+		client *http.Client
 	}
 )
 
-func NewTokopediaService() TokopediaService {
+const tokopediaGraphQLURL = "https://gql.tokopedia.com/graphql/"
+
+// NewTokopediaService talks to the Tokopedia GraphQL gateway at baseUrl
+// through client. An empty baseUrl means the public gateway and a nil
+// client means a plain http.Client; tests pass a replaying transport.
+func NewTokopediaService(baseUrl string, client *http.Client) TokopediaService {
+	if baseUrl == "" {
+		baseUrl = tokopediaGraphQLURL
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+
 	return &tokopediaService{
-		url: "https://gql.tokopedia.com/graphql/",
+		url:    baseUrl,
+		client: client,
 	}
 }
 
 func (s *tokopediaService) GetProduct(ctx context.Context, req dto.GetProductRequest) (dto.GetProductResponse, error) {
-	payload := strings.NewReader(fmt.Sprintf(`{
+	var response dto.PDPLayoutResponseTokopedia
+	err := s.postGraphQL(ctx, req.ProductUrl, "pdpGetLayout", map[string]any{
 		"operationName": "PDPGetLayoutQuery",
-		"variables": {
-			"shopDomain": "%s",
-			"productKey": "%s",
-			"apiVersion": 1
+		"variables": map[string]any{
+			"shopDomain": req.ShopDomain,
+			"productKey": req.ProductKey,
+			"apiVersion": 1,
 		},
-		"query": "fragment ProductVariant on pdpDataProductVariant {\n  errorCode\n  parentID\n  defaultChild\n  sizeChart\n  totalStockFmt\n  variants {\n    productVariantID\n    variantID\n    name\n    identifier\n    option {\n      picture {\n        urlOriginal: url\n        urlThumbnail: url100\n        __typename\n      }\n      productVariantOptionID\n      variantUnitValueID\n      value\n      hex\n      stock\n      __typename\n    }\n    __typename\n  }\n  children {\n    productID\n    price\n    priceFmt\n    slashPriceFmt\n    discPercentage\n    optionID\n    optionName\n    productName\n    productURL\n    picture {\n      urlOriginal: url\n      urlThumbnail: url100\n      __typename\n    }\n    stock {\n      stock\n      isBuyable\n      stockWordingHTML\n      minimumOrder\n      maximumOrder\n      __typename\n    }\n    isCOD\n    isWishlist\n    campaignInfo {\n      campaignID\n      campaignType\n      campaignTypeName\n      campaignIdentifier\n      background\n      discountPercentage\n      originalPrice\n      discountPrice\n      stock\n      stockSoldPercentage\n      startDate\n      endDate\n      endDateUnix\n         isAppsOnly\n      isActive\n      hideGimmick\n      isCheckImei\n      minOrder\n      __typename\n    }\n    thematicCampaign {\n      additionalInfo\n      background\n      campaignName\n       __typename\n    }\n    __typename\n  }\n  __typename\n}\n\nfragment ProductMedia on pdpDataProductMedia {\n  media {\n    type\n    urlOriginal: URLOriginal\n    urlThumbnail: URLThumbnail\n    urlMaxRes: URLMaxRes\n    videoUrl: videoURLAndroid\n    prefix\n    suffix\n    description\n    variantOptionID\n    __typename\n  }\n  videos {\n    source\n    url\n    __typename\n  }\n  __typename\n}\n\nfragment ProductCategoryCarousel on pdpDataCategoryCarousel {\n  linkText\n  titleCarousel\n   list {\n    categoryID\n     title\n        __typename\n  }\n  __typename\n}\n\nfragment ProductHighlight on pdpDataProductContent {\n  name\n }\n\nfragment ProductCustomInfo on pdpDataCustomInfo {\n  title\n   separator\n  description\n  __typename\n}\n\nfragment ProductInfo on pdpDataProductInfo {\n  row\n  content {\n    title\n    subtitle\n      __typename\n  }\n  __typename\n}\n\nfragment ProductDetail on pdpDataProductDetail {\n  content {\n    title\n    subtitle\n  }\n  __typename\n}\n\nfragment ProductDataInfo on pdpDataInfo {\n  title\n   __typename\n}\n\nfragment ProductSocial on pdpDataSocialProof {\n  row\n  content {\n    title\n    subtitle\n     type\n    rating\n    __typename\n  }\n  __typename\n}\n\nfragment ProductDetailMediaComponent on pdpDataProductDetailMediaComponent {\n  title\n  description\n  contentMedia {\n    url\n    ratio\n    type\n    __typename\n  }\n  show\n  ctaText\n  __typename\n}\n\nquery PDPGetLayoutQuery($shopDomain: String, $productKey: String, $layoutID: String, $apiVersion: Float, $userLocation: pdpUserLocation, $extParam: String, $tokonow: pdpTokoNow, $deviceID: String) {\n  pdpGetLayout(shopDomain: $shopDomain, productKey: $productKey, layoutID: $layoutID, apiVersion: $apiVersion, userLocation: $userLocation, extParam: $extParam, tokonow: $tokonow, deviceID: $deviceID) {\n           basicInfo {\n          id: productID\n        shopName\n    }\n    components {\n      name\n      data {\n        ...ProductMedia        ...ProductHighlight\n        ...ProductInfo\n        ...ProductDetail\n        ...ProductSocial\n        ...ProductDataInfo\n        ...ProductCustomInfo\n        ...ProductVariant\n        ...ProductCategoryCarousel\n        ...ProductDetailMediaComponent\n        __typename\n      }\n      __typename\n    }\n    __typename\n  }\n}\n"
-	}`, req.ShopDomain, req.ProductKey))
-
-	client := &http.Client{}
-	tokopediaReq, err := http.NewRequest("POST", s.url, payload)
+		"query": tokopediaPDPQuery,
+	}, &response)
 	if err != nil {
-		fmt.Println(err)
-		return dto.GetProductResponse{}, dto.ErrCreateHttpRequest
+		return dto.GetProductResponse{}, err
 	}
 
-	tokopediaReq.Header.Add("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0.0.0 Safari/537.36")
-	tokopediaReq.Header.Add("X-Source", "tokopedia-lite")
-	tokopediaReq.Header.Add("X-Tkpd-Lite-Service", "zeus")
-	tokopediaReq.Header.Add("Referer", req.ProductUrl)
-	tokopediaReq.Header.Add("X-TKPD-AKAMAI", "pdpGetLayout")
-	tokopediaReq.Header.Add("Content-Type", "application/json")
-
-	res, err := client.Do(tokopediaReq)
-	if err != nil {
-		return dto.GetProductResponse{}, dto.ErrSendsHttpRequest
+	layout := response.Data.PDPGetLayout
+	if len(response.Errors) > 0 || layout == nil || layout.BasicInfo.ID == "" {
+		return dto.GetProductResponse{}, dto.ErrProductNotFound
 	}
-	defer res.Body.Close()
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return dto.GetProductResponse{}, dto.ErrReadHttpResponseBody
+	product := dto.GetProductResponse{
+		ProductId: layout.BasicInfo.ID,
+		ShopName:  layout.BasicInfo.ShopName,
 	}
 
-	var response map[string]interface{}
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return dto.GetProductResponse{}, dto.ErrParseJson
+	category := layout.BasicInfo.Category
+	product.Category = dto.ProductCategory{ID: category.ID, Name: category.Name}
+	for _, detail := range category.Detail {
+		product.Category.Path = append(product.Category.Path, detail.Name)
+	}
+	product.SoldCount = layout.BasicInfo.TxStats.CountSold.Int()
+	product.ProductRating = float64(layout.BasicInfo.Stats.Rating)
+	product.TotalRatings = layout.BasicInfo.Stats.CountReview.Int()
+
+	// Components are matched on their data type rather than their position
+	// or name, which both change between layouts. A component that fails to
+	// decode only leaves its fields empty.
+	for _, component := range layout.Components {
+		for _, data := range component.Data {
+			var kind dto.PDPComponentTypeTokopedia
+			if err := json.Unmarshal(data, &kind); err != nil {
+				continue
+			}
+			applyPDPComponent(&product, kind.Typename, data)
+		}
 	}
 
-	// Check for errors in the response
-	if errors, ok := response["errors"].([]interface{}); ok && len(errors) > 0 {
-		return dto.GetProductResponse{}, dto.ErrProductNotFound
+	// The star breakdown is not part of the layout. It is only shown next to
+	// the analysis, so a failure here does not fail the product.
+	if breakdown, total, err := s.getRatingBreakdown(ctx, product.ProductId, req.ProductUrl); err == nil {
+		product.RatingBreakdown = breakdown
+		if total > 0 {
+			product.TotalRatings = total
+		}
 	}
 
-	// Extracting necessary data
-	productData := response["data"].(map[string]interface{})["pdpGetLayout"].(map[string]interface{})["components"].([]interface{})
-	var productName string
-	var description string
-	for _, component := range productData {
-		if component.(map[string]interface{})["name"].(string) == "product_detail" {
-			content := component.(map[string]interface{})["data"].([]interface{})[0].(map[string]interface{})["content"].([]interface{})
-			for _, c := range content {
-				if c.(map[string]interface{})["title"].(string) == "Deskripsi" {
-					description = c.(map[string]interface{})["subtitle"].(string)
-					break
-				}
+	return product, nil
+}
+
+// applyPDPComponent copies the fields of one layout component onto product.
+func applyPDPComponent(product *dto.GetProductResponse, typename string, data json.RawMessage) {
+	switch typename {
+	case "pdpDataProductContent":
+		var content dto.PDPProductContentTokopedia
+		if json.Unmarshal(data, &content) != nil {
+			return
+		}
+		product.ProductName = content.Name
+		product.Price = int64(content.Price.Value)
+		product.PriceFmt = content.Price.PriceFmt
+		product.OriginalPriceFmt = content.Price.SlashPriceFmt
+		product.DiscountPercentage = content.Price.DiscPercentage.Int()
+		if product.Stock == 0 {
+			product.Stock = content.Stock.Value.Int()
+		}
+
+	case "pdpDataProductMedia":
+		var media dto.PDPProductMediaTokopedia
+		if json.Unmarshal(data, &media) != nil {
+			return
+		}
+		for _, item := range media.Media {
+			if item.Type == "image" && item.URLOriginal != "" {
+				product.ImageUrls = append(product.ImageUrls, item.URLOriginal)
+			}
+		}
+
+	case "pdpDataProductDetail":
+		var detail dto.PDPProductDetailTokopedia
+		if json.Unmarshal(data, &detail) != nil {
+			return
+		}
+		for _, content := range detail.Content {
+			switch content.Title {
+			case "Deskripsi":
+				product.ProductDescription = content.Subtitle
+			case "Kondisi":
+				product.Condition = content.Subtitle
+			}
+		}
+
+	case "pdpDataProductVariant":
+		var variant dto.PDPProductVariantTokopedia
+		if json.Unmarshal(data, &variant) != nil {
+			return
+		}
+		for _, v := range variant.Variants {
+			options := make([]string, 0, len(v.Option))
+			for _, option := range v.Option {
+				options = append(options, option.Value)
+			}
+			product.Variants = append(product.Variants, dto.ProductVariant{Name: v.Name, Options: options})
+		}
+		// A variant product's stock is the sum of its children
+		if len(variant.Children) > 0 {
+			product.Stock = 0
+			for _, child := range variant.Children {
+				product.Stock += child.Stock.Stock.Int()
 			}
-		} else if component.(map[string]interface{})["name"].(string) == "product_content" {
-			productName = component.(map[string]interface{})["data"].([]interface{})[0].(map[string]interface{})["name"].(string)
 		}
-	}
 
-	// Extracting images
-	productMedia := response["data"].(map[string]interface{})["pdpGetLayout"].(map[string]interface{})["components"].([]interface{})[1].(map[string]interface{})["data"].([]interface{})[0].(map[string]interface{})["media"].([]interface{})
-	var imageUrls []string
-	for _, media := range productMedia {
-		if media.(map[string]interface{})["type"].(string) == "image" {
-			imageUrls = append(imageUrls, media.(map[string]interface{})["urlOriginal"].(string))
+	case "pdpDataSocialProof":
+		var social dto.PDPSocialProofTokopedia
+		if json.Unmarshal(data, &social) != nil {
+			return
 		}
+		for _, content := range social.Content {
+			if product.ProductRating == 0 && content.Rating > 0 {
+				product.ProductRating = content.Rating
+			}
+		}
+
+	case "pdpDataCategoryCarousel":
+		var carousel dto.PDPCategoryCarouselTokopedia
+		if json.Unmarshal(data, &carousel) != nil || product.Category.ID != "" || len(carousel.List) == 0 {
+			return
+		}
+		last := carousel.List[len(carousel.List)-1]
+		product.Category.ID = last.CategoryID
+		product.Category.Name = last.Title
 	}
+}
 
-	productResponse := dto.GetProductResponse{
-		ProductName:        productName,
-		ProductDescription: description,
-		ShopName:           response["data"].(map[string]interface{})["pdpGetLayout"].(map[string]interface{})["basicInfo"].(map[string]interface{})["shopName"].(string),
-		ProductId:          response["data"].(map[string]interface{})["pdpGetLayout"].(map[string]interface{})["basicInfo"].(map[string]interface{})["id"].(string),
-		ImageUrls:          imageUrls,
+func (s *tokopediaService) getRatingBreakdown(ctx context.Context, productId string, referer string) ([]dto.RatingBreakdown, int, error) {
+	var response dto.ProductRatingResponseTokopedia
+	err := s.postGraphQL(ctx, referer, "", map[string]any{
+		"operationName": "productRatingAndTopics",
+		"variables": map[string]any{
+			"productID": productId,
+		},
+		"query": "query productRatingAndTopics($productID: String!) {\n  productrevGetProductRatingAndTopics(productID: $productID) {\n    rating {\n      ratingScore\n      totalRating\n      detail {\n        rate\n        totalReviews\n        percentageFloat\n      }\n    }\n  }\n}\n",
+	}, &response)
+	if err != nil {
+		return nil, 0, err
 	}
 
-	return productResponse, nil
+	rating := response.Data.ProductrevGetProductRatingAndTopics.Rating
+	breakdown := make([]dto.RatingBreakdown, 0, len(rating.Detail))
+	for _, detail := range rating.Detail {
+		breakdown = append(breakdown, dto.RatingBreakdown{
+			Rate:       detail.Rate.Int(),
+			Count:      detail.TotalReviews.Int(),
+			Percentage: float64(detail.PercentageFloat),
+		})
+	}
 
+	return breakdown, rating.TotalRating.Int(), nil
 }
 
 func (s *tokopediaService) GetReviews(ctx context.Context, req dto.GetReviewsRequest) ([]dto.ReviewResponse, error) {
-	var allReviews []dto.ReviewResponse
+	opts, err := normalizeReviewOptions(req.Options)
+	if err != nil {
+		return nil, err
+	}
+	req.Options = opts
 
-	for page := 1; page <= 2; page++ {
-		// Prepare the request payload
-		payload := fmt.Sprintf(`{
-			"operationName": "productReviewList",
-			"variables": {
-				"productID": "%s",
-				"page": %d,
-				"limit": 50,
-				"sortBy": "create_time desc"
-			},
-			"query": "query productReviewList($productID: String!, $page: Int!, $limit: Int!, $sortBy: String) {\n  productrevGetProductReviewList(productID: $productID, page: $page, limit: $limit, sortBy: $sortBy) {\n    list {\n      message\n      productRating\n    }\n  }\n}\n"
-		}`, req.ProductId, page)
-
-		client := &http.Client{}
-
-		tokopediaReq, err := http.NewRequest("POST", s.url, strings.NewReader(payload))
-		if err != nil {
-			return nil, dto.ErrCreateHttpRequest
-		}
+	firstPage, hasNext, total, err := s.getReviewPage(ctx, req, 1)
+	if err != nil {
+		return nil, err
+	}
 
-		tokopediaReq.Header.Add("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0.0.0 Safari/537.36")
-		tokopediaReq.Header.Add("X-Source", "tokopedia-lite")
-		tokopediaReq.Header.Add("X-Tkpd-Lite-Service", "zeus")
-		tokopediaReq.Header.Add("Referer", req.ProductUrl)
-		tokopediaReq.Header.Add("Content-Type", "application/json")
+	lastPage := (opts.MaxReviews + tokopediaReviewsPerPage - 1) / tokopediaReviewsPerPage
+	if totalPages := (total + tokopediaReviewsPerPage - 1) / tokopediaReviewsPerPage; total > 0 && totalPages < lastPage {
+		lastPage = totalPages
+	}
 
-		res, err := client.Do(tokopediaReq)
-		if err != nil {
-			return nil, dto.ErrSendsHttpRequest
-		}
-		defer res.Body.Close()
+	// Newest-first pages only get older, so there is nothing left to fetch
+	// once the first page already reaches past the since bound.
+	if n := len(firstPage); n > 0 && opts.Since != nil && opts.SortBy == dto.REVIEW_SORT_NEWEST &&
+		firstPage[n-1].CreatedAt.Before(*opts.Since) {
+		hasNext = false
+	}
 
-		body, err := io.ReadAll(res.Body)
+	pages := [][]dto.ReviewResponse{firstPage}
+	if hasNext && lastPage > 1 {
+		rest, err := fetchReviewPages(ctx, 2, lastPage, func(ctx context.Context, page int) ([]dto.ReviewResponse, error) {
+			reviews, _, _, err := s.getReviewPage(ctx, req, page)
+			return reviews, err
+		})
 		if err != nil {
-			return nil, dto.ErrReadHttpResponseBody
+			return nil, err
 		}
+		pages = append(pages, rest...)
+	}
 
-		var response dto.ProductReviewResponseTokopedia
-		err = json.Unmarshal(body, &response)
-		if err != nil {
-			return nil, dto.ErrParseJson
+	// Pages are fetched concurrently by offset, so a review posted while
+	// paging shifts the list and shows up on two pages.
+	var allReviews []dto.ReviewResponse
+	seen := make(map[string]bool)
+	for _, page := range pages {
+		for _, review := range page {
+			if !inReviewWindow(opts, review.CreatedAt) {
+				continue
+			}
+			id := reviewExternalId(review)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			allReviews = append(allReviews, review)
+			if len(allReviews) == opts.MaxReviews {
+				return allReviews, nil
+			}
 		}
+	}
+
+	return allReviews, nil
+}
+
+// getReviewPage fetches a single page of productReviewList and reports
+// whether more pages exist along with the total number of reviews.
+func (s *tokopediaService) getReviewPage(ctx context.Context, req dto.GetReviewsRequest, page int) ([]dto.ReviewResponse, bool, int, error) {
+	var response dto.ProductReviewResponseTokopedia
+	err := s.postGraphQL(ctx, req.ProductUrl, "", map[string]any{
+		"operationName": "productReviewList",
+		"variables": map[string]any{
+			"productID": req.ProductId,
+			"page":      page,
+			"limit":     tokopediaReviewsPerPage,
+			"sortBy":    tokopediaReviewSort[req.Options.SortBy],
+			"filterBy":  tokopediaReviewFilter(req.Options),
+		},
+		"query": "query productReviewList($productID: String!, $page: Int!, $limit: Int!, $sortBy: String, $filterBy: String) {\n  productrevGetProductReviewList(productID: $productID, page: $page, limit: $limit, sortBy: $sortBy, filterBy: $filterBy) {\n    list {\n      id: feedbackID\n      variantName\n      message\n      productRating\n      reviewCreateTimestamp\n      isAnonymous\n      imageAttachments {\n        attachmentID\n      }\n      videoAttachments {\n        attachmentID\n      }\n      user {\n        fullName\n      }\n    }\n    hasNext\n    totalReviews\n  }\n}\n",
+	}, &response)
+	if err != nil {
+		return nil, false, 0, err
+	}
+	if len(response.Errors) > 0 {
+		return nil, false, 0, dto.ErrReviewsUnavailable
+	}
 
-		reviews := response.Data.ProductrevGetProductReviewList.List
-		for _, review := range reviews {
-			allReviews = append(allReviews, dto.ReviewResponse{
-				Message: review.Message,
-				Rating:  review.ProductRating,
-			})
+	reviewList := response.Data.ProductrevGetProductReviewList
+	reviews := make([]dto.ReviewResponse, 0, len(reviewList.List))
+	for _, review := range reviewList.List {
+		var createdAt time.Time
+		if ts, err := strconv.ParseInt(review.ReviewCreateTimestamp, 10, 64); err == nil {
+			createdAt = time.Unix(ts, 0)
 		}
 
-		if len(reviews) < 50 {
+		reviews = append(reviews, dto.ReviewResponse{
+			ID:        review.ID,
+			Message:   review.Message,
+			Rating:    review.ProductRating,
+			CreatedAt: createdAt,
+			Variant:   review.VariantName,
+			HasMedia:  len(review.ImageAttachments) > 0 || len(review.VideoAttachments) > 0,
+			Reviewer:  review.User.FullName,
+		})
+	}
+
+	return reviews, reviewList.HasNext && len(reviewList.List) == tokopediaReviewsPerPage, reviewList.TotalReviews, nil
+}
+
+func (s *tokopediaService) GetShopAvatar(ctx context.Context, shopDomain string) (string, error) {
+	var response dto.ShopAvatarResponseTokopedia
+	err := s.postGraphQL(ctx, "https://www.tokopedia.com/"+shopDomain, "pdpGetLayout", map[string]any{
+		"operationName": "ShopInfoCore",
+		"variables": map[string]any{
+			"id":     0,
+			"domain": shopDomain,
+		},
+		"query": "query ShopInfoCore($id: Int!, $domain: String) {\n  shopInfoByID(input: {shopIDs: [$id], fields: [\"assets\"], domain: $domain, source: \"shoppage\"}) {\n    result {\n      shopAssets {\n        avatar\n      }\n    }\n  }\n}\n",
+	}, &response)
+	if err != nil {
+		return "", err
+	}
+
+	if len(response.Data.ShopInfoByID.Result) > 0 {
+		shopAvatar := response.Data.ShopInfoByID.Result[0].ShopAssets.Avatar
+		return shopAvatar, nil
+	}
+
+	return "", dto.ErrShopAvatarNotFound
+
+}
+
+func (s *tokopediaService) GetShopProducts(ctx context.Context, shopDomain string, limit int) (dto.GetShopProductsResponse, error) {
+	referer := "https://www.tokopedia.com/" + shopDomain
+
+	var shopInfo dto.ShopInfoResponseTokopedia
+	err := s.postGraphQL(ctx, referer, "", map[string]any{
+		"operationName": "ShopInfoCore",
+		"variables": map[string]any{
+			"id":     0,
+			"domain": shopDomain,
+		},
+		"query": "query ShopInfoCore($id: Int!, $domain: String) {\n  shopInfoByID(input: {shopIDs: [$id], fields: [\"core\", \"assets\"], domain: $domain, source: \"shoppage\"}) {\n    result {\n      shopCore {\n        shopID\n        name\n        domain\n      }\n      shopAssets {\n        avatar\n      }\n    }\n  }\n}\n",
+	}, &shopInfo)
+	if err != nil {
+		return dto.GetShopProductsResponse{}, err
+	}
+	if len(shopInfo.Data.ShopInfoByID.Result) == 0 || shopInfo.Data.ShopInfoByID.Result[0].ShopCore.ShopID == "" {
+		return dto.GetShopProductsResponse{}, dto.ErrShopNotFound
+	}
+	shop := shopInfo.Data.ShopInfoByID.Result[0]
+
+	var shopProducts dto.ShopProductsResponseTokopedia
+	err = s.postGraphQL(ctx, referer, "", map[string]any{
+		"operationName": "ShopProducts",
+		"variables": map[string]any{
+			"sid":     shop.ShopCore.ShopID,
+			"page":    1,
+			"perPage": limit,
+			"sort":    tokopediaShopSortBestSelling,
+		},
+		"query": "query ShopProducts($sid: String!, $page: Int, $perPage: Int, $sort: Int) {\n  GetShopProduct(shopId: $sid, filter: {page: $page, perPage: $perPage, sort: $sort}) {\n    data {\n      product_id\n      name\n      product_url\n      stats {\n        averageRating\n        countReview\n      }\n    }\n  }\n}\n",
+	}, &shopProducts)
+	if err != nil {
+		return dto.GetShopProductsResponse{}, err
+	}
+	if len(shopProducts.Errors) > 0 {
+		return dto.GetShopProductsResponse{}, dto.ErrShopNotFound
+	}
+
+	products := make([]dto.ShopProduct, 0, len(shopProducts.Data.GetShopProduct.Data))
+	for _, product := range shopProducts.Data.GetShopProduct.Data {
+		rating, _ := strconv.ParseFloat(product.Stats.AverageRating, 64)
+		products = append(products, dto.ShopProduct{
+			ProductID:   product.ProductID,
+			ProductName: product.Name,
+			ProductURL:  product.ProductURL,
+			Rating:      rating,
+			ReviewCount: product.Stats.CountReview,
+		})
+		if len(products) == limit {
 			break
 		}
 	}
 
-	return allReviews, nil
+	return dto.GetShopProductsResponse{
+		ShopID:     shop.ShopCore.ShopID,
+		ShopName:   shop.ShopCore.Name,
+		ShopDomain: shop.ShopCore.Domain,
+		ShopAvatar: shop.ShopAssets.Avatar,
+		Products:   products,
+	}, nil
 }
 
-func (s *tokopediaService) GetShopAvatar(ctx context.Context, shopDomain string) (string, error) {
-	payload := strings.NewReader(fmt.Sprintf(` {
-        "operationName": "ShopInfoCore",
-        "variables": {
-            "id": 0,
-            "domain": "%s"
-        },
-        "query": "query ShopInfoCore($id: Int!, $domain: String) {\n  shopInfoByID(input: {shopIDs: [$id], fields: [\"active_product\", \"allow_manage_all\", \"assets\", \"core\", \"closed_info\", \"create_info\", \"favorite\", \"location\", \"status\", \"is_open\", \"other-goldos\", \"shipment\", \"shopstats\", \"shop-snippet\", \"other-shiploc\", \"shopHomeType\", \"branch-link\", \"goapotik\", \"fs_type\"], domain: $domain, source: \"shoppage\"}) {\n    result {\n                 shopAssets {\n        avatar\n          }\n                   }\n     }\n}\n"
-    }`, shopDomain))
-
-	client := &http.Client{}
-	tokopediaReq, err := http.NewRequest("POST", s.url, payload)
+// Ping sends a query for __typename, the cheapest the gateway answers.
+func (s *tokopediaService) Ping(ctx context.Context) error {
+	operation := map[string]any{
+		"operationName": "Ping",
+		"variables":     map[string]any{},
+		"query":         "query Ping {\n  __typename\n}\n",
+	}
+
+	var res dto.PingResponseTokopedia
+	if err := s.postGraphQL(ctx, "https://www.tokopedia.com/", "", operation, &res); err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("%w: %s", dto.ErrNotOk, res.Errors[0].Message)
+	}
+	return nil
+}
+
+// postGraphQL sends a single operation to the Tokopedia gateway and decodes
+// the response into out. akamai, when set, is sent as X-TKPD-AKAMAI, which
+// some operations need to get past the gateway's bot protection.
+func (s *tokopediaService) postGraphQL(ctx context.Context, referer string, akamai string, operation map[string]any, out any) error {
+	payload, err := json.Marshal(operation)
 	if err != nil {
-		return "", dto.ErrCreateHttpRequest
+		return dto.ErrMarshallJson
+	}
+
+	tokopediaReq, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(payload))
+	if err != nil {
+		return dto.ErrCreateHttpRequest
 	}
 
 	tokopediaReq.Header.Add("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0.0.0 Safari/537.36")
 	tokopediaReq.Header.Add("X-Source", "tokopedia-lite")
 	tokopediaReq.Header.Add("X-Tkpd-Lite-Service", "zeus")
-	tokopediaReq.Header.Add("Referer", "https://www.tokopedia.com/"+shopDomain)
-	tokopediaReq.Header.Add("X-TKPD-AKAMAI", "pdpGetLayout")
+	tokopediaReq.Header.Add("Referer", referer)
+	if akamai != "" {
+		tokopediaReq.Header.Add("X-TKPD-AKAMAI", akamai)
+	}
 	tokopediaReq.Header.Add("Content-Type", "application/json")
 
-	res, err := client.Do(tokopediaReq)
+	res, err := s.client.Do(tokopediaReq)
 	if err != nil {
-		return "", dto.ErrSendsHttpRequest
+		return dto.ErrSendsHttpRequest
 	}
 	defer res.Body.Close()
 
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
-		return "", dto.ErrReadHttpResponseBody
+		return dto.ErrReadHttpResponseBody
 	}
 
-	var response dto.ShopAvatarResponseTokopedia
-	err = json.Unmarshal(body, &response)
-	if err != nil {
-		return "", dto.ErrParseJson
+	if err := json.Unmarshal(body, out); err != nil {
+		return dto.ErrParseJson
 	}
 
-	if len(response.Data.ShopInfoByID.Result) > 0 {
-		shopAvatar := response.Data.ShopInfoByID.Result[0].ShopAssets.Avatar
-		return shopAvatar, nil
-	}
-
-	return "", dto.ErrShopAvatarNotFound
+	return nil
+}
 
+// tokopediaReviewFilter builds the filterBy argument of productReviewList,
+// e.g. "rating=1,2;withAttachment=true".
+func tokopediaReviewFilter(opts dto.ReviewOptions) string {
+	var filters []string
+	if len(opts.Ratings) > 0 {
+		ratings := make([]string, len(opts.Ratings))
+		for i, rating := range opts.Ratings {
+			ratings[i] = strconv.Itoa(rating)
+		}
+		filters = append(filters, "rating="+strings.Join(ratings, ","))
+	}
+	if opts.WithMedia {
+		filters = append(filters, "withAttachment=true")
+	}
+	return strings.Join(filters, ";")
 }
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/httpreplay"
+)
+
+// The golden files under testdata/tokopedia are replayed by default. To
+// re-record them against the live gateway, run
+//
+//	TOKOPEDIA_RECORD=1 go test ./service -run TestTokopedia
+//
+// and review the diff: a changed response shape is upstream schema drift.
+func newReplayTokopediaService(t *testing.T, scenario string) TokopediaService {
+	t.Helper()
+
+	transport := httpreplay.NewTransport(
+		filepath.Join("testdata", "tokopedia", scenario),
+		httpreplay.ModeFromEnv("TOKOPEDIA_RECORD"),
+		nil,
+	)
+	return NewTokopediaService("", &http.Client{Transport: transport})
+}
+
+func TestTokopediaGetProduct(t *testing.T) {
+	t.Run("simple layout", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "product_simple")
+
+		product, err := ts.GetProduct(context.Background(), dto.GetProductRequest{
+			ProductUrl: "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro",
+			ShopDomain: "tokoaudio",
+			ProductKey: "earphone-bluetooth-tws-pro",
+		})
+		if err != nil {
+			t.Fatalf("GetProduct: %v", err)
+		}
+
+		if product.ProductId != "2150001234" || product.ShopName != "Toko Audio" {
+			t.Errorf("basic info = %q %q", product.ProductId, product.ShopName)
+		}
+		if product.ProductName != "Earphone Bluetooth TWS Pro" {
+			t.Errorf("ProductName = %q", product.ProductName)
+		}
+		if product.ProductDescription == "" || product.Condition != "Baru" {
+			t.Errorf("detail = %q %q", product.ProductDescription, product.Condition)
+		}
+		if want := []string{"https://images.tokopedia.net/img/tws-1.jpg", "https://images.tokopedia.net/img/tws-2.jpg"}; !reflect.DeepEqual(product.ImageUrls, want) {
+			t.Errorf("ImageUrls = %v, want %v", product.ImageUrls, want)
+		}
+		if product.Price != 189000 || product.PriceFmt != "Rp189.000" || product.OriginalPriceFmt != "Rp250.000" || product.DiscountPercentage != 24 {
+			t.Errorf("price = %d %q %q %d", product.Price, product.PriceFmt, product.OriginalPriceFmt, product.DiscountPercentage)
+		}
+		if product.Stock != 320 {
+			t.Errorf("Stock = %d", product.Stock)
+		}
+		if want := []string{"Audio", "Earphone"}; product.Category.ID != "3712" || !reflect.DeepEqual(product.Category.Path, want) {
+			t.Errorf("Category = %+v", product.Category)
+		}
+		if product.SoldCount != 1520 || product.ProductRating != 4.8 {
+			t.Errorf("stats = %d %v", product.SoldCount, product.ProductRating)
+		}
+		if product.TotalRatings != 640 || len(product.RatingBreakdown) != 5 {
+			t.Fatalf("ratings = %d %v", product.TotalRatings, product.RatingBreakdown)
+		}
+		if top := product.RatingBreakdown[0]; top.Rate != 5 || top.Count != 540 || top.Percentage != 84.4 {
+			t.Errorf("RatingBreakdown[0] = %+v", top)
+		}
+	})
+
+	t.Run("variant layout", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "product_variant")
+
+		product, err := ts.GetProduct(context.Background(), dto.GetProductRequest{
+			ProductUrl: "https://www.tokopedia.com/sepatuku/sneakers-kanvas-putih",
+			ShopDomain: "sepatuku",
+			ProductKey: "sneakers-kanvas-putih",
+		})
+		if err != nil {
+			t.Fatalf("GetProduct: %v", err)
+		}
+
+		// Components come in a different order than the simple layout, with
+		// types the client does not read and one malformed entry.
+		if product.ProductName != "Sneakers Kanvas Putih" {
+			t.Errorf("ProductName = %q", product.ProductName)
+		}
+		if want := []dto.ProductVariant{
+			{Name: "Ukuran", Options: []string{"39", "40", "41"}},
+			{Name: "Warna", Options: []string{"Putih"}},
+		}; !reflect.DeepEqual(product.Variants, want) {
+			t.Errorf("Variants = %+v", product.Variants)
+		}
+		if product.Stock != 17 {
+			t.Errorf("Stock = %d, want the sum of the variant children", product.Stock)
+		}
+		if want := []string{"https://images.tokopedia.net/img/sneakers-1.jpg"}; !reflect.DeepEqual(product.ImageUrls, want) {
+			t.Errorf("ImageUrls = %v, want videos skipped", product.ImageUrls)
+		}
+		if product.ProductDescription != "" {
+			t.Errorf("ProductDescription = %q, want empty for the malformed detail", product.ProductDescription)
+		}
+		if product.DiscountPercentage != 0 || product.OriginalPriceFmt != "" {
+			t.Errorf("discount = %d %q", product.DiscountPercentage, product.OriginalPriceFmt)
+		}
+		if product.ProductRating != 4.6 {
+			t.Errorf("ProductRating = %v, want the social proof fallback", product.ProductRating)
+		}
+		// The rating call failed, which only leaves the breakdown empty.
+		if len(product.RatingBreakdown) != 0 || product.TotalRatings != 35 {
+			t.Errorf("ratings = %d %v", product.TotalRatings, product.RatingBreakdown)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "product_not_found")
+
+		_, err := ts.GetProduct(context.Background(), dto.GetProductRequest{
+			ProductUrl: "https://www.tokopedia.com/tokoaudio/produk-sudah-dihapus",
+			ShopDomain: "tokoaudio",
+			ProductKey: "produk-sudah-dihapus",
+		})
+		if !errors.Is(err, dto.ErrProductNotFound) {
+			t.Fatalf("err = %v, want %v", err, dto.ErrProductNotFound)
+		}
+	})
+}
+
+func TestTokopediaGetReviews(t *testing.T) {
+	t.Run("pagination", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "reviews_paginated")
+
+		reviews, err := ts.GetReviews(context.Background(), dto.GetReviewsRequest{
+			ProductUrl: "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro",
+			ProductId:  "2150001234",
+			Options:    dto.ReviewOptions{MaxReviews: 150},
+		})
+		if err != nil {
+			t.Fatalf("GetReviews: %v", err)
+		}
+
+		// 120 reviews over pages of 50, 50 and 20; no fourth page is asked for.
+		if len(reviews) != 120 {
+			t.Fatalf("len(reviews) = %d, want 120", len(reviews))
+		}
+		for i, review := range reviews {
+			if want := reviewFixtureID(i); review.ID != want {
+				t.Fatalf("reviews[%d].ID = %q, want %q in page order", i, review.ID, want)
+			}
+		}
+
+		first := reviews[0]
+		if first.Message == "" || first.Rating != 5 || first.Reviewer != "Budi" || first.Variant != "Hitam" || !first.HasMedia {
+			t.Errorf("reviews[0] = %+v", first)
+		}
+		if first.CreatedAt.Unix() != 1717200000 {
+			t.Errorf("reviews[0].CreatedAt = %v", first.CreatedAt)
+		}
+		if reviews[1].HasMedia {
+			t.Errorf("reviews[1].HasMedia = true, want false")
+		}
+	})
+
+	t.Run("max reviews stops paging", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "reviews_paginated")
+
+		reviews, err := ts.GetReviews(context.Background(), dto.GetReviewsRequest{
+			ProductUrl: "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro",
+			ProductId:  "2150001234",
+			Options:    dto.ReviewOptions{MaxReviews: 60},
+		})
+		if err != nil {
+			t.Fatalf("GetReviews: %v", err)
+		}
+		if len(reviews) != 60 || reviews[59].ID != reviewFixtureID(59) {
+			t.Fatalf("got %d reviews", len(reviews))
+		}
+	})
+
+	t.Run("shifted pages", func(t *testing.T) {
+		// A review posted while paging pushes rv-bx from the end of page 1
+		// to the start of page 2, and rv-dv off page 2.
+		ts := newReplayTokopediaService(t, "reviews_shifted")
+
+		reviews, err := ts.GetReviews(context.Background(), dto.GetReviewsRequest{
+			ProductUrl: "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro",
+			ProductId:  "2150001234",
+			Options:    dto.ReviewOptions{MaxReviews: 150},
+		})
+		if err != nil {
+			t.Fatalf("GetReviews: %v", err)
+		}
+
+		seen := make(map[string]bool, len(reviews))
+		for _, review := range reviews {
+			if seen[review.ID] {
+				t.Fatalf("review %q returned twice", review.ID)
+			}
+			seen[review.ID] = true
+		}
+		if len(reviews) != 119 || seen[reviewFixtureID(99)] {
+			t.Fatalf("len(reviews) = %d, want 119 without rv-dv", len(reviews))
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "reviews_empty")
+
+		reviews, err := ts.GetReviews(context.Background(), dto.GetReviewsRequest{
+			ProductUrl: "https://www.tokopedia.com/tokoaudio/produk-baru",
+			ProductId:  "2150009999",
+		})
+		if err != nil {
+			t.Fatalf("GetReviews: %v", err)
+		}
+		if len(reviews) != 0 {
+			t.Fatalf("len(reviews) = %d, want 0", len(reviews))
+		}
+	})
+
+	t.Run("error payload", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "reviews_error")
+
+		_, err := ts.GetReviews(context.Background(), dto.GetReviewsRequest{
+			ProductUrl: "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro",
+			ProductId:  "0",
+		})
+		if !errors.Is(err, dto.ErrReviewsUnavailable) {
+			t.Fatalf("err = %v, want %v", err, dto.ErrReviewsUnavailable)
+		}
+	})
+
+	t.Run("gateway error page", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "reviews_gateway_error")
+
+		_, err := ts.GetReviews(context.Background(), dto.GetReviewsRequest{
+			ProductUrl: "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro",
+			ProductId:  "2150001234",
+		})
+		if !errors.Is(err, dto.ErrParseJson) {
+			t.Fatalf("err = %v, want %v", err, dto.ErrParseJson)
+		}
+	})
+}
+
+func TestTokopediaGetShopAvatar(t *testing.T) {
+	t.Run("found", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "shop_avatar")
+
+		avatar, err := ts.GetShopAvatar(context.Background(), "tokoaudio")
+		if err != nil {
+			t.Fatalf("GetShopAvatar: %v", err)
+		}
+		if avatar != "https://images.tokopedia.net/img/seller/tokoaudio.png" {
+			t.Errorf("avatar = %q", avatar)
+		}
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ts := newReplayTokopediaService(t, "shop_avatar_not_found")
+
+		_, err := ts.GetShopAvatar(context.Background(), "toko-tidak-ada")
+		if !errors.Is(err, dto.ErrShopAvatarNotFound) {
+			t.Fatalf("err = %v, want %v", err, dto.ErrShopAvatarNotFound)
+		}
+	})
+}
+
+func TestTokopediaMissingRecording(t *testing.T) {
+	transport := httpreplay.NewTransport(t.TempDir(), httpreplay.ModeReplay, nil)
+	ts := NewTokopediaService("", &http.Client{Transport: transport})
+
+	_, err := ts.GetShopAvatar(context.Background(), "tokoaudio")
+	if !errors.Is(err, dto.ErrSendsHttpRequest) {
+		t.Fatalf("err = %v, want %v", err, dto.ErrSendsHttpRequest)
+	}
+}
+
+// reviewFixtureID is the feedback ID of the i-th review in the
+// reviews_paginated recordings.
+func reviewFixtureID(i int) string {
+	return "rv-" + string(rune('a'+i/26)) + string(rune('a'+i%26))
+}
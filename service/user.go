@@ -13,6 +13,7 @@ import (
 type (
 	UserService interface {
 		RegisterUser(ctx context.Context, req dto.UserCreateRequest) (dto.UserResponse, error)
+		RegisterAdmin(ctx context.Context, req dto.UserCreateRequest) (dto.UserResponse, error)
 		GetUserById(ctx context.Context, userId string) (dto.UserResponse, error)
 		GetUserByEmail(ctx context.Context, email string) (dto.UserResponse, error)
 		Verify(ctx context.Context, req dto.UserLoginRequest) (dto.UserLoginResponse, error)
@@ -32,6 +33,16 @@ func NewUserService(userRepo repository.UserRepository, jwtService JWTService) U
 }
 
 func (s *userService) RegisterUser(ctx context.Context, req dto.UserCreateRequest) (dto.UserResponse, error) {
+	return s.register(ctx, req, constants.ENUM_ROLE_USER)
+}
+
+// RegisterAdmin creates a user with the admin role. It is not exposed over
+// HTTP; operators call it from the user create command.
+func (s *userService) RegisterAdmin(ctx context.Context, req dto.UserCreateRequest) (dto.UserResponse, error) {
+	return s.register(ctx, req, constants.ENUM_ROLE_ADMIN)
+}
+
+func (s *userService) register(ctx context.Context, req dto.UserCreateRequest, role string) (dto.UserResponse, error) {
 	_, flag, _ := s.userRepo.CheckEmail(ctx, nil, req.Email)
 	if flag {
 		return dto.UserResponse{}, dto.ErrEmailAlreadyExists
@@ -39,7 +50,7 @@ func (s *userService) RegisterUser(ctx context.Context, req dto.UserCreateReques
 
 	user := entity.User{
 		Name:     req.Name,
-		Role:     constants.ENUM_ROLE_USER,
+		Role:     role,
 		Email:    req.Email,
 		Password: req.Password,
 	}
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"review_product_tokopedia_be/config"
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+	"review_product_tokopedia_be/repository"
+	"review_product_tokopedia_be/utils"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	watchlistPollInterval = 30 * time.Second
+	watchlistClaimLease   = 5 * time.Minute
+)
+
+type (
+	WatchlistService interface {
+		CreateWatchlist(ctx context.Context, req dto.WatchlistCreateRequest, userId string) (dto.WatchlistResponse, error)
+		GetWatchlists(ctx context.Context, userId string) ([]dto.WatchlistResponse, error)
+		GetWatchlistById(ctx context.Context, watchlistId string, userId string) (dto.WatchlistResponse, error)
+		UpdateWatchlist(ctx context.Context, req dto.WatchlistUpdateRequest, watchlistId string, userId string) (dto.WatchlistResponse, error)
+		DeleteWatchlist(ctx context.Context, watchlistId string, userId string) error
+
+		// Start launches the scheduler, which enqueues an analysis job for
+		// every due item until Stop is called.
+		Start()
+		Stop()
+	}
+
+	watchlistService struct {
+		watchlistRepo       repository.WatchlistRepository
+		analysisJobRepo     repository.AnalysisJobRepository
+		analysisJobService  AnalysisJobService
+		marketplaceRegistry MarketplaceRegistry
+		maxItems            int
+		dailyRuns           int
+
+		cancel context.CancelFunc
+		wg     sync.WaitGroup
+	}
+)
+
+func NewWatchlistService(
+	watchlistRepo repository.WatchlistRepository,
+	analysisJobRepo repository.AnalysisJobRepository,
+	analysisJobService AnalysisJobService,
+	marketplaceRegistry MarketplaceRegistry,
+	cfg config.WatchlistConfig,
+) WatchlistService {
+	return &watchlistService{
+		watchlistRepo:       watchlistRepo,
+		analysisJobRepo:     analysisJobRepo,
+		analysisJobService:  analysisJobService,
+		marketplaceRegistry: marketplaceRegistry,
+		maxItems:            cfg.MaxItems,
+		dailyRuns:           cfg.DailyRuns,
+	}
+}
+
+func (s *watchlistService) CreateWatchlist(ctx context.Context, req dto.WatchlistCreateRequest, userId string) (dto.WatchlistResponse, error) {
+	userUUID, err := uuid.Parse(userId)
+	if err != nil {
+		return dto.WatchlistResponse{}, dto.ErrInvalidUserId
+	}
+
+	if _, _, err := s.marketplaceRegistry.Resolve(req.ProductUrl); err != nil {
+		return dto.WatchlistResponse{}, err
+	}
+
+	nextRunAt, err := nextWatchlistRun(req.Schedule, time.Now())
+	if err != nil {
+		return dto.WatchlistResponse{}, err
+	}
+
+	active := req.Active == nil || *req.Active
+	if active {
+		if err := s.checkItemQuota(ctx, userId); err != nil {
+			return dto.WatchlistResponse{}, err
+		}
+	}
+
+	watchlist, err := s.watchlistRepo.CreateWatchlist(ctx, nil, entity.Watchlist{
+		ProductURL: req.ProductUrl,
+		Schedule:   req.Schedule,
+		Active:     active,
+		NextRunAt:  nextRunAt,
+		UserID:     userUUID,
+	})
+	if err != nil {
+		return dto.WatchlistResponse{}, dto.ErrCreateWatchlist
+	}
+
+	return toWatchlistResponse(watchlist), nil
+}
+
+func (s *watchlistService) GetWatchlists(ctx context.Context, userId string) ([]dto.WatchlistResponse, error) {
+	watchlists, err := s.watchlistRepo.GetWatchlists(ctx, nil, userId)
+	if err != nil {
+		return nil, dto.ErrGetWatchlists
+	}
+
+	res := make([]dto.WatchlistResponse, len(watchlists))
+	for i, watchlist := range watchlists {
+		res[i] = toWatchlistResponse(watchlist)
+	}
+
+	return res, nil
+}
+
+func (s *watchlistService) GetWatchlistById(ctx context.Context, watchlistId string, userId string) (dto.WatchlistResponse, error) {
+	watchlist, err := s.watchlistRepo.GetWatchlistById(ctx, nil, watchlistId, userId)
+	if err != nil {
+		return dto.WatchlistResponse{}, dto.ErrGetWatchlist
+	}
+
+	return toWatchlistResponse(watchlist), nil
+}
+
+func (s *watchlistService) UpdateWatchlist(ctx context.Context, req dto.WatchlistUpdateRequest, watchlistId string, userId string) (dto.WatchlistResponse, error) {
+	watchlist, err := s.watchlistRepo.GetWatchlistById(ctx, nil, watchlistId, userId)
+	if err != nil {
+		return dto.WatchlistResponse{}, dto.ErrGetWatchlist
+	}
+
+	fields := map[string]any{}
+
+	if req.ProductUrl != nil {
+		if _, _, err := s.marketplaceRegistry.Resolve(*req.ProductUrl); err != nil {
+			return dto.WatchlistResponse{}, err
+		}
+		fields["product_url"] = *req.ProductUrl
+		watchlist.ProductURL = *req.ProductUrl
+	}
+
+	if req.Schedule != nil {
+		nextRunAt, err := nextWatchlistRun(*req.Schedule, time.Now())
+		if err != nil {
+			return dto.WatchlistResponse{}, err
+		}
+		fields["schedule"] = *req.Schedule
+		fields["next_run_at"] = nextRunAt
+		watchlist.Schedule = *req.Schedule
+		watchlist.NextRunAt = nextRunAt
+	}
+
+	if req.Active != nil && *req.Active != watchlist.Active {
+		if *req.Active {
+			if err := s.checkItemQuota(ctx, userId); err != nil {
+				return dto.WatchlistResponse{}, err
+			}
+			// Resume from now instead of catching up on missed runs
+			if req.Schedule == nil {
+				nextRunAt, err := nextWatchlistRun(watchlist.Schedule, time.Now())
+				if err != nil {
+					return dto.WatchlistResponse{}, err
+				}
+				fields["next_run_at"] = nextRunAt
+				watchlist.NextRunAt = nextRunAt
+			}
+		}
+		fields["active"] = *req.Active
+		watchlist.Active = *req.Active
+	}
+
+	if len(fields) > 0 {
+		if err := s.watchlistRepo.UpdateWatchlist(ctx, nil, watchlistId, fields); err != nil {
+			return dto.WatchlistResponse{}, dto.ErrUpdateWatchlist
+		}
+	}
+
+	return toWatchlistResponse(watchlist), nil
+}
+
+func (s *watchlistService) DeleteWatchlist(ctx context.Context, watchlistId string, userId string) error {
+	if err := s.watchlistRepo.DeleteWatchlist(ctx, nil, watchlistId, userId); err != nil {
+		return dto.ErrDeleteWatchlist
+	}
+	return nil
+}
+
+func (s *watchlistService) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.schedule(ctx)
+	}()
+}
+
+func (s *watchlistService) Stop() {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *watchlistService) schedule(ctx context.Context) {
+	ticker := time.NewTicker(watchlistPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Dispatch everything that is due before going back to sleep
+		for ctx.Err() == nil {
+			now := time.Now()
+			watchlist, err := s.watchlistRepo.ClaimDueWatchlist(ctx, nil, now, watchlistClaimLease)
+			if err != nil {
+				if !errors.Is(err, gorm.ErrRecordNotFound) && ctx.Err() == nil {
+					logrus.WithError(err).Error("failed to claim due watchlist")
+				}
+				break
+			}
+			s.dispatch(ctx, watchlist, now)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch enqueues an analysis job for a claimed item and moves it to its
+// next run. The job worker runs the pipeline and stores the history.
+func (s *watchlistService) dispatch(ctx context.Context, watchlist entity.Watchlist, now time.Time) {
+	watchlistId := watchlist.ID.String()
+	log := logrus.WithField("watchlist_id", watchlistId)
+
+	fields := map[string]any{}
+	nextRunAt, err := nextWatchlistRun(watchlist.Schedule, now)
+	if err != nil {
+		fields["active"] = false
+		fields["last_error"] = err.Error()
+	} else {
+		fields["next_run_at"] = nextRunAt
+		fields["last_error"] = ""
+
+		if err := s.checkDailyQuota(ctx, watchlist.UserID.String(), now); err != nil {
+			fields["last_error"] = err.Error()
+		} else {
+			job, err := s.analysisJobService.CreateJob(ctx, dto.AnalysisJobCreateRequest{
+				ProductUrl:  watchlist.ProductURL,
+				WatchlistID: &watchlist.ID,
+			}, watchlist.UserID.String())
+			if err != nil {
+				fields["last_error"] = err.Error()
+			} else {
+				fields["last_run_at"] = now
+				fields["last_job_id"] = job.ID
+			}
+		}
+	}
+
+	if msg, _ := fields["last_error"].(string); msg != "" {
+		log.WithField("error", msg).Warn("scheduled analysis not enqueued")
+	}
+
+	if err := s.watchlistRepo.UpdateWatchlist(ctx, nil, watchlistId, fields); err != nil {
+		log.WithError(err).Error("failed to update watchlist after dispatch")
+	}
+}
+
+func (s *watchlistService) checkItemQuota(ctx context.Context, userId string) error {
+	count, err := s.watchlistRepo.CountActiveWatchlists(ctx, nil, userId)
+	if err != nil {
+		return dto.ErrGetWatchlists
+	}
+	if count >= int64(s.maxItems) {
+		return dto.ErrWatchlistQuota
+	}
+	return nil
+}
+
+func (s *watchlistService) checkDailyQuota(ctx context.Context, userId string, now time.Time) error {
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	count, err := s.analysisJobRepo.CountWatchlistJobsSince(ctx, nil, userId, startOfDay)
+	if err != nil {
+		return dto.ErrGetAnalysisJob
+	}
+	if count >= int64(s.dailyRuns) {
+		return dto.ErrWatchlistDailyQuota
+	}
+	return nil
+}
+
+func nextWatchlistRun(schedule string, after time.Time) (time.Time, error) {
+	cron, err := utils.ParseCron(schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next := cron.Next(after)
+	if next.IsZero() {
+		return time.Time{}, dto.ErrWatchlistNeverRuns
+	}
+	return next, nil
+}
+
+func toWatchlistResponse(watchlist entity.Watchlist) dto.WatchlistResponse {
+	return dto.WatchlistResponse{
+		ID:         watchlist.ID,
+		ProductURL: watchlist.ProductURL,
+		Schedule:   watchlist.Schedule,
+		Active:     watchlist.Active,
+		NextRunAt:  watchlist.NextRunAt,
+		LastRunAt:  watchlist.LastRunAt,
+		LastJobID:  watchlist.LastJobID,
+		LastError:  watchlist.LastError,
+		CreatedAt:  watchlist.CreatedAt,
+	}
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"review_product_tokopedia_be/dto"
+	"review_product_tokopedia_be/entity"
+	"review_product_tokopedia_be/repository"
+	"review_product_tokopedia_be/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const testWatchlistUrl = "https://www.tokopedia.com/tokoaudio/earphone-bluetooth-tws-pro"
+
+// fakeWatchlistRepository holds a single stored item and counts active
+// items from a fixed number.
+type fakeWatchlistRepository struct {
+	repository.WatchlistRepository
+
+	watchlist entity.Watchlist
+	active    int64
+	created   []entity.Watchlist
+	updates   []map[string]any
+}
+
+func (r *fakeWatchlistRepository) CreateWatchlist(ctx context.Context, tx *gorm.DB, watchlist entity.Watchlist) (entity.Watchlist, error) {
+	watchlist.ID = uuid.New()
+	r.created = append(r.created, watchlist)
+	return watchlist, nil
+}
+
+func (r *fakeWatchlistRepository) GetWatchlistById(ctx context.Context, tx *gorm.DB, watchlistId string, userId string) (entity.Watchlist, error) {
+	if r.watchlist.ID.String() != watchlistId || r.watchlist.UserID.String() != userId {
+		return entity.Watchlist{}, gorm.ErrRecordNotFound
+	}
+	return r.watchlist, nil
+}
+
+func (r *fakeWatchlistRepository) UpdateWatchlist(ctx context.Context, tx *gorm.DB, watchlistId string, fields map[string]any) error {
+	r.updates = append(r.updates, fields)
+	return nil
+}
+
+func (r *fakeWatchlistRepository) CountActiveWatchlists(ctx context.Context, tx *gorm.DB, userId string) (int64, error) {
+	return r.active, nil
+}
+
+// fakeWatchlistJobRepository reports a fixed number of scheduled jobs for
+// the day.
+type fakeWatchlistJobRepository struct {
+	repository.AnalysisJobRepository
+
+	jobsToday int64
+	since     time.Time
+}
+
+func (r *fakeWatchlistJobRepository) CountWatchlistJobsSince(ctx context.Context, tx *gorm.DB, userId string, since time.Time) (int64, error) {
+	r.since = since
+	return r.jobsToday, nil
+}
+
+type fakeAnalysisJobService struct {
+	AnalysisJobService
+
+	err      error
+	requests []dto.AnalysisJobCreateRequest
+}
+
+func (s *fakeAnalysisJobService) CreateJob(ctx context.Context, req dto.AnalysisJobCreateRequest, userId string) (dto.AnalysisJobResponse, error) {
+	s.requests = append(s.requests, req)
+	if s.err != nil {
+		return dto.AnalysisJobResponse{}, s.err
+	}
+	return dto.AnalysisJobResponse{ID: uuid.New()}, nil
+}
+
+func newTestWatchlistService(repo *fakeWatchlistRepository, jobRepo *fakeWatchlistJobRepository, jobs *fakeAnalysisJobService) *watchlistService {
+	return &watchlistService{
+		watchlistRepo:       repo,
+		analysisJobRepo:     jobRepo,
+		analysisJobService:  jobs,
+		marketplaceRegistry: NewMarketplaceRegistry(NewTokopediaProvider(nil)),
+		maxItems:            2,
+		dailyRuns:           3,
+	}
+}
+
+func TestWatchlistCreateQuota(t *testing.T) {
+	userId := uuid.NewString()
+	inactive := false
+
+	t.Run("under quota", func(t *testing.T) {
+		repo := &fakeWatchlistRepository{active: 1}
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, &fakeAnalysisJobService{})
+
+		before := time.Now()
+		res, err := s.CreateWatchlist(context.Background(), dto.WatchlistCreateRequest{ProductUrl: testWatchlistUrl, Schedule: "@daily"}, userId)
+		if err != nil {
+			t.Fatalf("CreateWatchlist: %v", err)
+		}
+		if !res.Active || len(repo.created) != 1 {
+			t.Fatalf("res = %+v, created = %d", res, len(repo.created))
+		}
+		if want, _ := nextWatchlistRun("@daily", before); !res.NextRunAt.Equal(want) {
+			t.Errorf("NextRunAt = %v, want %v", res.NextRunAt, want)
+		}
+	})
+
+	t.Run("at quota", func(t *testing.T) {
+		repo := &fakeWatchlistRepository{active: 2}
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, &fakeAnalysisJobService{})
+
+		_, err := s.CreateWatchlist(context.Background(), dto.WatchlistCreateRequest{ProductUrl: testWatchlistUrl, Schedule: "@daily"}, userId)
+		if !errors.Is(err, dto.ErrWatchlistQuota) {
+			t.Fatalf("err = %v, want %v", err, dto.ErrWatchlistQuota)
+		}
+		if len(repo.created) != 0 {
+			t.Errorf("created %d items over the quota", len(repo.created))
+		}
+	})
+
+	t.Run("inactive items do not count", func(t *testing.T) {
+		repo := &fakeWatchlistRepository{active: 2}
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, &fakeAnalysisJobService{})
+
+		res, err := s.CreateWatchlist(context.Background(), dto.WatchlistCreateRequest{ProductUrl: testWatchlistUrl, Schedule: "@daily", Active: &inactive}, userId)
+		if err != nil {
+			t.Fatalf("CreateWatchlist: %v", err)
+		}
+		if res.Active {
+			t.Errorf("Active = true, want false")
+		}
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		repo := &fakeWatchlistRepository{}
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, &fakeAnalysisJobService{})
+
+		for spec, want := range map[string]error{
+			"every day":  utils.ErrInvalidCron,
+			"0 0 30 2 *": dto.ErrWatchlistNeverRuns,
+		} {
+			if _, err := s.CreateWatchlist(context.Background(), dto.WatchlistCreateRequest{ProductUrl: testWatchlistUrl, Schedule: spec}, userId); !errors.Is(err, want) {
+				t.Errorf("schedule %q: err = %v, want %v", spec, err, want)
+			}
+		}
+		if len(repo.created) != 0 {
+			t.Errorf("created %d items with a bad schedule", len(repo.created))
+		}
+	})
+}
+
+func TestWatchlistUpdate(t *testing.T) {
+	userId := uuid.New()
+	stale := time.Now().Add(-72 * time.Hour)
+	active := true
+
+	newRepo := func(activeCount int64) *fakeWatchlistRepository {
+		return &fakeWatchlistRepository{
+			active: activeCount,
+			watchlist: entity.Watchlist{
+				ID:         uuid.New(),
+				ProductURL: testWatchlistUrl,
+				Schedule:   "@hourly",
+				Active:     false,
+				NextRunAt:  stale,
+				UserID:     userId,
+			},
+		}
+	}
+
+	t.Run("reactivating at quota", func(t *testing.T) {
+		repo := newRepo(2)
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, &fakeAnalysisJobService{})
+
+		_, err := s.UpdateWatchlist(context.Background(), dto.WatchlistUpdateRequest{Active: &active}, repo.watchlist.ID.String(), userId.String())
+		if !errors.Is(err, dto.ErrWatchlistQuota) {
+			t.Fatalf("err = %v, want %v", err, dto.ErrWatchlistQuota)
+		}
+		if len(repo.updates) != 0 {
+			t.Errorf("updates = %v, want none", repo.updates)
+		}
+	})
+
+	t.Run("reactivating resumes from now", func(t *testing.T) {
+		repo := newRepo(1)
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, &fakeAnalysisJobService{})
+
+		before := time.Now()
+		res, err := s.UpdateWatchlist(context.Background(), dto.WatchlistUpdateRequest{Active: &active}, repo.watchlist.ID.String(), userId.String())
+		if err != nil {
+			t.Fatalf("UpdateWatchlist: %v", err)
+		}
+		if len(repo.updates) != 1 {
+			t.Fatalf("updates = %d, want 1", len(repo.updates))
+		}
+		nextRunAt, _ := repo.updates[0]["next_run_at"].(time.Time)
+		if !nextRunAt.After(before) || !res.NextRunAt.Equal(nextRunAt) || repo.updates[0]["active"] != true {
+			t.Errorf("fields = %v, response next run %v", repo.updates[0], res.NextRunAt)
+		}
+	})
+
+	t.Run("new schedule moves the next run", func(t *testing.T) {
+		repo := newRepo(0)
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, &fakeAnalysisJobService{})
+
+		schedule := "30 8 * * 1-5"
+		before := time.Now()
+		if _, err := s.UpdateWatchlist(context.Background(), dto.WatchlistUpdateRequest{Schedule: &schedule}, repo.watchlist.ID.String(), userId.String()); err != nil {
+			t.Fatalf("UpdateWatchlist: %v", err)
+		}
+		want, _ := nextWatchlistRun(schedule, before)
+		if got, _ := repo.updates[0]["next_run_at"].(time.Time); !got.Equal(want) || repo.updates[0]["schedule"] != schedule {
+			t.Errorf("fields = %v, want next run %v", repo.updates[0], want)
+		}
+	})
+
+	t.Run("invalid schedule", func(t *testing.T) {
+		repo := newRepo(0)
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, &fakeAnalysisJobService{})
+
+		schedule := "* * *"
+		_, err := s.UpdateWatchlist(context.Background(), dto.WatchlistUpdateRequest{Schedule: &schedule}, repo.watchlist.ID.String(), userId.String())
+		if !errors.Is(err, utils.ErrInvalidCron) || len(repo.updates) != 0 {
+			t.Errorf("err = %v, updates = %v", err, repo.updates)
+		}
+	})
+}
+
+func TestWatchlistDispatch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	watchlist := entity.Watchlist{
+		ID:         uuid.New(),
+		ProductURL: testWatchlistUrl,
+		Schedule:   "@hourly",
+		Active:     true,
+		UserID:     uuid.New(),
+	}
+
+	t.Run("enqueues a job and moves to the next run", func(t *testing.T) {
+		repo := &fakeWatchlistRepository{}
+		jobRepo := &fakeWatchlistJobRepository{jobsToday: 2}
+		jobs := &fakeAnalysisJobService{}
+		s := newTestWatchlistService(repo, jobRepo, jobs)
+
+		s.dispatch(context.Background(), watchlist, now)
+
+		if len(jobs.requests) != 1 || jobs.requests[0].WatchlistID == nil || *jobs.requests[0].WatchlistID != watchlist.ID {
+			t.Fatalf("job requests = %+v", jobs.requests)
+		}
+		if !jobRepo.since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("daily quota counted since %v", jobRepo.since)
+		}
+		fields := repo.updates[0]
+		if got, _ := fields["next_run_at"].(time.Time); !got.Equal(now.Add(time.Hour)) {
+			t.Errorf("next_run_at = %v, want %v", got, now.Add(time.Hour))
+		}
+		if fields["last_run_at"] != now || fields["last_job_id"] == nil || fields["last_error"] != "" {
+			t.Errorf("fields = %v", fields)
+		}
+	})
+
+	t.Run("daily quota reached", func(t *testing.T) {
+		repo := &fakeWatchlistRepository{}
+		jobs := &fakeAnalysisJobService{}
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{jobsToday: 3}, jobs)
+
+		s.dispatch(context.Background(), watchlist, now)
+
+		if len(jobs.requests) != 0 {
+			t.Fatalf("enqueued %d jobs over the daily quota", len(jobs.requests))
+		}
+		fields := repo.updates[0]
+		if got, _ := fields["next_run_at"].(time.Time); !got.Equal(now.Add(time.Hour)) {
+			t.Errorf("next_run_at = %v, want %v", got, now.Add(time.Hour))
+		}
+		if fields["last_error"] != dto.ErrWatchlistDailyQuota.Error() || fields["last_run_at"] != nil {
+			t.Errorf("fields = %v", fields)
+		}
+	})
+
+	t.Run("job creation fails", func(t *testing.T) {
+		repo := &fakeWatchlistRepository{}
+		jobs := &fakeAnalysisJobService{err: dto.ErrCreateAnalysisJob}
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, jobs)
+
+		s.dispatch(context.Background(), watchlist, now)
+
+		fields := repo.updates[0]
+		if fields["last_error"] != dto.ErrCreateAnalysisJob.Error() || fields["next_run_at"] == nil {
+			t.Errorf("fields = %v", fields)
+		}
+	})
+
+	t.Run("schedule that never runs deactivates the item", func(t *testing.T) {
+		repo := &fakeWatchlistRepository{}
+		jobs := &fakeAnalysisJobService{}
+		s := newTestWatchlistService(repo, &fakeWatchlistJobRepository{}, jobs)
+
+		never := watchlist
+		never.Schedule = "0 0 31 4 *"
+		s.dispatch(context.Background(), never, now)
+
+		fields := repo.updates[0]
+		if len(jobs.requests) != 0 || fields["active"] != false || fields["last_error"] != dto.ErrWatchlistNeverRuns.Error() {
+			t.Errorf("fields = %v, job requests = %d", fields, len(jobs.requests))
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidCron = errors.New("invalid cron schedule, use five fields (minute hour day month weekday) or @hourly, @daily, @weekly, @monthly")
+
+// CronSchedule is a parsed five-field cron expression. Each field is a
+// bitset of the values it matches.
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// Like cron, when both day fields are restricted a day matches if
+	// either of them does.
+	domAny, dowAny bool
+}
+
+var cronShortcuts = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// ParseCron parses "minute hour day-of-month month day-of-week" with
+// support for *, lists, ranges and steps, e.g. "*/30 8-17 * * 1-5".
+func ParseCron(spec string) (CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if shortcut, ok := cronShortcuts[strings.ToLower(spec)]; ok {
+		spec = shortcut
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return CronSchedule{}, ErrInvalidCron
+	}
+
+	var schedule CronSchedule
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return CronSchedule{}, err
+	}
+	if schedule.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return CronSchedule{}, err
+	}
+
+	// Both 0 and 7 mean Sunday
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	schedule.domAny = fields[2] == "*"
+	schedule.dowAny = fields[4] == "*"
+
+	return schedule, nil
+}
+
+func parseCronField(field string, min int, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if rangePart, stepPart, ok := strings.Cut(part, "/"); ok {
+			value, err := strconv.Atoi(stepPart)
+			if err != nil || value <= 0 {
+				return 0, ErrInvalidCron
+			}
+			part, step = rangePart, value
+		}
+
+		low, high := min, max
+		if part != "*" {
+			lowPart, highPart, isRange := strings.Cut(part, "-")
+			value, err := strconv.Atoi(lowPart)
+			if err != nil {
+				return 0, ErrInvalidCron
+			}
+			low, high = value, value
+			if isRange {
+				if high, err = strconv.Atoi(highPart); err != nil {
+					return 0, ErrInvalidCron
+				}
+			} else if step > 1 {
+				// "5/15" means every 15 starting at 5
+				high = max
+			}
+		}
+
+		if low < min || high > max || low > high {
+			return 0, ErrInvalidCron
+		}
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next returns the first time after t that matches the schedule, at minute
+// precision, or the zero time if none exists within five years.
+func (s CronSchedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s CronSchedule) matchesDay(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"@yearly",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 0 *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"1-x * * * *",
+		"a * * * *",
+		",5 * * * *",
+	}
+
+	for _, spec := range specs {
+		if _, err := ParseCron(spec); !errors.Is(err, ErrInvalidCron) {
+			t.Errorf("ParseCron(%q) err = %v, want %v", spec, err, ErrInvalidCron)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	// 1 January 2024 is a Monday.
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{name: "step", spec: "*/15 * * * *", from: at(2024, 1, 1, 10, 7), want: at(2024, 1, 1, 10, 15)},
+		{name: "strictly after", spec: "*/15 * * * *", from: at(2024, 1, 1, 10, 15), want: at(2024, 1, 1, 10, 30)},
+		{name: "seconds truncated", spec: "*/15 * * * *", from: at(2024, 1, 1, 10, 14).Add(30 * time.Second), want: at(2024, 1, 1, 10, 15)},
+		{name: "step from start", spec: "5/20 * * * *", from: at(2024, 1, 1, 10, 26), want: at(2024, 1, 1, 10, 45)},
+		{name: "list", spec: "0,30 * * * *", from: at(2024, 1, 1, 10, 10), want: at(2024, 1, 1, 10, 30)},
+		{name: "range with step", spec: "0 9-17/4 * * *", from: at(2024, 1, 1, 14, 0), want: at(2024, 1, 1, 17, 0)},
+		{name: "range with step wraps to next day", spec: "0 9-17/4 * * *", from: at(2024, 1, 1, 17, 0), want: at(2024, 1, 2, 9, 0)},
+		{name: "weekdays skip the weekend", spec: "30 8 * * 1-5", from: at(2024, 1, 5, 9, 0), want: at(2024, 1, 8, 8, 30)},
+		{name: "dow 0 is sunday", spec: "0 0 * * 0", from: at(2024, 1, 1, 0, 0), want: at(2024, 1, 7, 0, 0)},
+		{name: "dow 7 is sunday", spec: "0 0 * * 7", from: at(2024, 1, 1, 0, 0), want: at(2024, 1, 7, 0, 0)},
+		{name: "dom only", spec: "0 0 15 * *", from: at(2024, 1, 1, 0, 0), want: at(2024, 1, 15, 0, 0)},
+		{name: "dom or dow matches dow first", spec: "0 0 15 * 5", from: at(2024, 1, 1, 0, 0), want: at(2024, 1, 5, 0, 0)},
+		{name: "dom or dow matches dom first", spec: "0 0 15 * 5", from: at(2024, 1, 13, 0, 0), want: at(2024, 1, 15, 0, 0)},
+		{name: "dom and month", spec: "0 0 1 3 *", from: at(2024, 1, 1, 0, 0), want: at(2024, 3, 1, 0, 0)},
+		{name: "across month", spec: "0 12 1 * *", from: at(2024, 1, 31, 13, 0), want: at(2024, 2, 1, 12, 0)},
+		{name: "across year", spec: "0 0 1 1 *", from: at(2024, 12, 31, 23, 59), want: at(2025, 1, 1, 0, 0)},
+		{name: "last minute of the year", spec: "59 23 31 12 *", from: at(2024, 12, 31, 23, 59), want: at(2025, 12, 31, 23, 59)},
+		{name: "skips short months", spec: "0 0 31 * *", from: at(2024, 4, 1, 0, 0), want: at(2024, 5, 31, 0, 0)},
+		{name: "leap day", spec: "0 0 29 2 *", from: at(2024, 3, 1, 0, 0), want: at(2028, 2, 29, 0, 0)},
+		{name: "hourly", spec: "@hourly", from: at(2024, 1, 1, 10, 0), want: at(2024, 1, 1, 11, 0)},
+		{name: "daily", spec: "@daily", from: at(2024, 1, 1, 10, 0), want: at(2024, 1, 2, 0, 0)},
+		{name: "shortcut case", spec: " @DAILY ", from: at(2024, 1, 1, 10, 0), want: at(2024, 1, 2, 0, 0)},
+		{name: "midnight", spec: "@midnight", from: at(2024, 1, 1, 10, 0), want: at(2024, 1, 2, 0, 0)},
+		{name: "weekly", spec: "@weekly", from: at(2024, 1, 1, 10, 0), want: at(2024, 1, 7, 0, 0)},
+		{name: "monthly", spec: "@monthly", from: at(2024, 1, 1, 10, 0), want: at(2024, 2, 1, 0, 0)},
+		{name: "never", spec: "0 0 30 2 *", from: at(2024, 1, 1, 0, 0)},
+		{name: "day that does not exist", spec: "0 0 31 4 *", from: at(2024, 1, 1, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.spec)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -26,3 +26,13 @@ func BuildResponseFailed(message string, err string, data any) Response {
 	}
 	return res
 }
+
+func BuildResponseSuccessWithMeta(message string, data any, meta any) Response {
+	res := Response{
+		Status:  true,
+		Message: message,
+		Data:    data,
+		Meta:    meta,
+	}
+	return res
+}